@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/PhilipSchmid/echo-app/internal/client"
+	"github.com/spf13/pflag"
+)
+
+// runClient implements "echo-app client", probing a deployment over every
+// selected protocol. It returns 0 when all probes pass, 1 on failures and 2
+// on usage errors.
+func runClient(args []string, stdout, stderr io.Writer) int {
+	flags := pflag.NewFlagSet("client", pflag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		_, _ = fmt.Fprintf(stderr, "Usage: echo-app client [flags] <host>\n\nProbes a running echo-app and validates its responses.\n\nFlags:\n")
+		flags.PrintDefaults()
+	}
+	protocols := flags.String("protocols", strings.Join(client.Protocols, ","), "Comma-separated protocols to probe ("+strings.Join(client.Protocols, ", ")+")")
+	requests := flags.IntP("requests", "n", 1, "Requests per protocol, each on a new connection")
+	timeout := flags.Duration("timeout", 5*time.Second, "Timeout per request")
+	insecure := flags.Bool("insecure-skip-verify", true, "Skip certificate verification for TLS, QUIC and gRPC over TLS (echo-app serves a self-signed certificate)")
+	grpcTLS := flags.Bool("grpc-tls", false, "Connect to the gRPC listener with TLS, for servers started with --grpc-tls")
+	output := flags.StringP("output", "o", "text", "Report format (text, json)")
+	ports := map[string]*string{
+		client.ProtocolHTTP: flags.String("http-port", "8080", "HTTP listener port"),
+		client.ProtocolH2C:  flags.String("h2c-port", "", "H2C listener port (default: HTTP port)"),
+		client.ProtocolTLS:  flags.String("tls-port", "8443", "TLS listener port"),
+		client.ProtocolQUIC: flags.String("quic-port", "4433", "QUIC listener port"),
+		client.ProtocolTCP:  flags.String("tcp-port", "9090", "TCP listener port"),
+		client.ProtocolGRPC: flags.String("grpc-port", "50051", "gRPC listener port"),
+	}
+	if err := flags.Parse(args); err != nil {
+		if err == pflag.ErrHelp {
+			return 0
+		}
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+	if *output != "text" && *output != "json" {
+		_, _ = fmt.Fprintf(stderr, "invalid output format: %s\n", *output)
+		return 2
+	}
+
+	opts := client.Options{
+		Host:               flags.Arg(0),
+		Ports:              make(map[string]string, len(ports)),
+		Requests:           *requests,
+		Timeout:            *timeout,
+		InsecureSkipVerify: *insecure,
+		GRPCTLS:            *grpcTLS,
+	}
+	for _, protocol := range strings.Split(*protocols, ",") {
+		if protocol = strings.ToLower(strings.TrimSpace(protocol)); protocol != "" {
+			opts.Protocols = append(opts.Protocols, protocol)
+		}
+	}
+	for protocol, port := range ports {
+		opts.Ports[protocol] = *port
+	}
+	// H2C shares the HTTP listener unless told otherwise
+	if opts.Ports[client.ProtocolH2C] == "" {
+		opts.Ports[client.ProtocolH2C] = opts.Ports[client.ProtocolHTTP]
+	}
+	if err := opts.Validate(); err != nil {
+		_, _ = fmt.Fprintf(stderr, "invalid client options: %v\n", err)
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	report := client.Run(ctx, opts)
+
+	write := report.WriteText
+	if *output == "json" {
+		write = report.WriteJSON
+	}
+	if err := write(stdout); err != nil {
+		_, _ = fmt.Fprintf(stderr, "failed to write report: %v\n", err)
+		return 1
+	}
+	if !report.OK() {
+		return 1
+	}
+	return 0
+}
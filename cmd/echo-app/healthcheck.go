@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/PhilipSchmid/echo-app/internal/config"
+	"github.com/PhilipSchmid/echo-app/internal/healthcheck"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// runHealthcheck implements "echo-app healthcheck", checking the local server
+// for container HEALTHCHECKs. It accepts the server flags and environment
+// variables so ports match the running server, and returns 0 when healthy, 1
+// when not and 2 on usage errors.
+func runHealthcheck(args []string, stdout, stderr io.Writer) int {
+	own := pflag.NewFlagSet("healthcheck", pflag.ContinueOnError)
+	check := own.String("check", healthcheck.CheckHealth, "Check to run (health, ready, listeners)")
+	timeout := own.Duration("timeout", 3*time.Second, "Timeout for the whole check")
+
+	flags := pflag.NewFlagSet("healthcheck", pflag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		_, _ = fmt.Fprintf(stderr, "Usage: echo-app healthcheck [flags] [server flags]\n\nChecks the local echo-app and exits non-zero when it is unhealthy.\nServer flags and ECHO_APP_* variables select the ports to check.\n\nFlags:\n%s", own.FlagUsages())
+	}
+	registerFlags(flags)
+	flags.AddFlagSet(own)
+	if err := flags.Parse(args); err != nil {
+		if err == pflag.ErrHelp {
+			return 0
+		}
+		return 2
+	}
+	if flags.NArg() != 0 || *timeout <= 0 {
+		flags.Usage()
+		return 2
+	}
+
+	if err := viper.BindPFlags(flags); err != nil {
+		_, _ = fmt.Fprintf(stderr, "failed to bind flags: %v\n", err)
+		return 2
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "invalid configuration: %v\n", err)
+		return 2
+	}
+	cfg.ApplyLogging()
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	results, err := healthcheck.Run(ctx, cfg, *check)
+	if err != nil {
+		_, _ = fmt.Fprintln(stderr, err)
+		return 2
+	}
+
+	code := 0
+	for _, result := range results {
+		status := "ok"
+		if result.Err != nil {
+			status = result.Err.Error()
+			code = 1
+		}
+		_, _ = fmt.Fprintf(stdout, "%s %s: %s\n", result.Name, result.Target, status)
+	}
+	return code
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/PhilipSchmid/echo-app/internal/loadgen"
+	"github.com/spf13/pflag"
+)
+
+// runLoadgen implements "echo-app loadgen", driving traffic against a target
+// and reporting latency and instance distribution. It returns 0 when every
+// request succeeds, 1 on failed requests and 2 on usage errors.
+func runLoadgen(args []string, stdout, stderr io.Writer) int {
+	flags := pflag.NewFlagSet("loadgen", pflag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		_, _ = fmt.Fprintf(stderr, "Usage: echo-app loadgen [flags] <target>\n\nSends load to an echo-app. The target is a URL for HTTP protocols and host:port for gRPC and TCP.\n\nFlags:\n")
+		flags.PrintDefaults()
+	}
+	protocol := flags.StringP("protocol", "p", loadgen.ProtocolHTTP1, "Protocol to use ("+strings.Join(loadgen.Protocols, ", ")+")")
+	concurrency := flags.IntP("concurrency", "c", 10, "Number of workers, each with its own connection")
+	rate := flags.Float64P("rate", "r", 0, "Requests per second across all workers (0 sends as fast as possible)")
+	duration := flags.DurationP("duration", "d", 10*time.Second, "How long to send requests (0 runs until --requests are sent)")
+	requests := flags.Int64P("requests", "n", 0, "Total number of requests (0 runs for --duration)")
+	timeout := flags.Duration("timeout", 5*time.Second, "Timeout per request")
+	insecure := flags.Bool("insecure-skip-verify", true, "Skip certificate verification for https targets and gRPC over TLS (echo-app serves a self-signed certificate)")
+	grpcTLS := flags.Bool("grpc-tls", false, "Connect to the gRPC listener with TLS, for servers started with --grpc-tls")
+	output := flags.StringP("output", "o", "text", "Report format (text, json, csv)")
+	if err := flags.Parse(args); err != nil {
+		if err == pflag.ErrHelp {
+			return 0
+		}
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	opts := loadgen.Options{
+		Protocol:           strings.ToLower(*protocol),
+		Target:             flags.Arg(0),
+		Concurrency:        *concurrency,
+		Rate:               *rate,
+		Duration:           *duration,
+		Requests:           *requests,
+		Timeout:            *timeout,
+		InsecureSkipVerify: *insecure,
+		GRPCTLS:            *grpcTLS,
+	}
+	// A request count alone bounds the run instead of the default duration
+	if flags.Changed("requests") && !flags.Changed("duration") {
+		opts.Duration = 0
+	}
+	var write func(r *loadgen.Report, w io.Writer) error
+	switch *output {
+	case "text":
+		write = (*loadgen.Report).WriteText
+	case "json":
+		write = (*loadgen.Report).WriteJSON
+	case "csv":
+		write = (*loadgen.Report).WriteCSV
+	default:
+		_, _ = fmt.Fprintf(stderr, "invalid output format: %s\n", *output)
+		return 2
+	}
+	if err := opts.Validate(); err != nil {
+		_, _ = fmt.Fprintf(stderr, "invalid loadgen options: %v\n", err)
+		return 2
+	}
+
+	// Interrupting ends the test early and still prints the report
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	report, err := loadgen.Run(ctx, opts)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "load test failed: %v\n", err)
+		return 2
+	}
+	if err := write(report, stdout); err != nil {
+		_, _ = fmt.Fprintf(stderr, "failed to write report: %v\n", err)
+		return 1
+	}
+	if !report.OK() {
+		return 1
+	}
+	return 0
+}
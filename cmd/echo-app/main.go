@@ -2,46 +2,38 @@ package main
 
 import (
 	"context"
-	"fmt"
 	"os"
 	"os/signal"
+	"reflect"
 	"syscall"
 	"time"
 
+	"github.com/PhilipSchmid/echo-app/internal/accesslog"
 	"github.com/PhilipSchmid/echo-app/internal/config"
 	"github.com/PhilipSchmid/echo-app/internal/health"
+	"github.com/PhilipSchmid/echo-app/internal/reload"
 	"github.com/PhilipSchmid/echo-app/internal/server"
-	"github.com/PhilipSchmid/echo-app/internal/utils"
+	"github.com/PhilipSchmid/echo-app/internal/tracing"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
 
 func main() {
+	// Subcommands ship in the same binary as the scratch image has no other tools
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "client":
+			os.Exit(runClient(os.Args[2:], os.Stdout, os.Stderr))
+		case "healthcheck":
+			os.Exit(runHealthcheck(os.Args[2:], os.Stdout, os.Stderr))
+		case "loadgen":
+			os.Exit(runLoadgen(os.Args[2:], os.Stdout, os.Stderr))
+		}
+	}
+
 	// Define command-line flags
-	pflag.String("message", "", "Custom message")
-	pflag.String("node", "", "Node name")
-	pflag.Bool("print-http-request-headers", false, "Print HTTP request headers")
-	pflag.Bool("tls", false, "Enable TLS server")
-	pflag.Bool("h2c", false, "Enable HTTP/2 cleartext (h2c) on the HTTP listener")
-	pflag.Bool("tcp", false, "Enable TCP server")
-	pflag.Bool("grpc", false, "Enable gRPC server")
-	pflag.Bool("quic", false, "Enable QUIC server")
-	pflag.Bool("metrics", true, "Enable metrics server")
-	pflag.String("http-port", "8080", "HTTP server port")
-	pflag.String("tls-port", "8443", "TLS server port")
-	pflag.String("tcp-port", "9090", "TCP server port")
-	pflag.String("grpc-port", "50051", "gRPC server port")
-	pflag.String("quic-port", "4433", "QUIC server port")
-	pflag.String("metrics-port", "3000", "Metrics server port")
-	pflag.String("log-level", "info", "Log level (debug, info, warn, error)")
-	pflag.Int64("max-request-size", 10485760, "Maximum request body size in bytes (default: 10MB)")
-	pflag.String("external-readiness-probe-type", "none", "External readiness probe type: none, http, tcp, or icmp")
-	pflag.String("external-readiness-probe-target", "", "External readiness probe target URL, host:port, or host/IP")
-	pflag.Duration("external-readiness-probe-interval", 10*time.Second, "External readiness probe interval")
-	pflag.Duration("external-readiness-probe-timeout", 2*time.Second, "External readiness probe timeout")
-	pflag.String("external-readiness-http-method", "GET", "HTTP method for external readiness HTTP probes")
-	pflag.Int("external-readiness-http-expected-status", 200, "Expected HTTP status for external readiness HTTP probes")
+	registerFlags(pflag.CommandLine)
 
 	// Parse the flags
 	pflag.Parse()
@@ -51,103 +43,328 @@ func main() {
 		logrus.Fatalf("Failed to bind command-line flags: %v", err)
 	}
 
-	// Load configuration
+	// Load and validate the configuration, reporting every problem at once
 	cfg, err := config.Load()
 	if err != nil {
-		logrus.Fatalf("Failed to load configuration: %v", err)
+		for _, err := range config.Errors(err) {
+			logrus.Errorf("Invalid configuration: %v", err)
+		}
+		os.Exit(1)
+	}
+	cfg.ApplyLogging()
+
+	// Print the effective configuration without starting any listener
+	if viper.GetBool("validate-only") {
+		if err := cfg.WriteEffective(os.Stdout); err != nil {
+			logrus.Fatalf("Failed to write configuration: %v", err)
+		}
+		return
+	}
+
+	// Set up access logging
+	if cfg.AccessLog.Enabled {
+		accessLogger, err := accesslog.New(cfg.AccessLog)
+		if err != nil {
+			logrus.Fatalf("Failed to create access log: %v", err)
+		}
+		accesslog.SetDefault(accessLogger)
+		defer func() {
+			if err := accessLogger.Close(); err != nil {
+				logrus.Errorf("Failed to close access log: %v", err)
+			}
+		}()
 	}
 
-	// Validate configuration
-	if err := validateConfig(cfg); err != nil {
-		logrus.Fatalf("Invalid configuration: %v", err)
+	// Set up trace context propagation and span export
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		logrus.Fatalf("Failed to set up tracing: %v", err)
 	}
 
 	// Create shared health/readiness checker
-	healthChecker := health.NewChecker(cfg.ExternalReadinessProbe)
+	healthChecker := health.NewChecker(cfg.ExternalReadiness)
 
 	// Create server manager
 	manager := server.NewManager(cfg, healthChecker)
 
-	// Register servers based on configuration
-	// Always start HTTP server
-	manager.RegisterServer(server.NewHTTPServer(cfg, false))
-
-	if cfg.TLS {
-		manager.RegisterServer(server.NewHTTPServer(cfg, true))
-	}
-	if cfg.TCP {
-		manager.RegisterServer(server.NewTCPServer(cfg))
-	}
-	if cfg.GRPC {
-		manager.RegisterServer(server.NewGRPCServer(cfg))
-	}
-	if cfg.QUIC {
-		manager.RegisterServer(server.NewQUICServer(cfg))
+	// Register a server per configured listener
+	for _, l := range cfg.Listeners {
+		if err := manager.RegisterListener(cfg, l); err != nil {
+			logrus.Fatalf("Failed to create listener: %v", err)
+		}
 	}
 	if cfg.Metrics {
-		manager.RegisterServer(server.NewMetricsServer(cfg, healthChecker))
+		manager.RegisterServer(server.NewMetricsServer(cfg, healthChecker).WithManager(manager))
 	}
 
 	// Create context for server lifecycle
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	// Start all servers
-	healthChecker.Start(ctx)
+	// Start all servers. The readiness probe stops first on shutdown so it
+	// cannot mark a draining app ready again.
+	probeCtx, stopProbe := context.WithCancel(ctx)
+	defer stopProbe()
+	healthChecker.Start(probeCtx)
 
+	// Fail fast unless the policy is to keep serving on the listeners that started
+	failed := false
 	if err := manager.Start(ctx); err != nil {
-		logrus.Errorf("Failed to start servers: %v", err)
+		for _, err := range config.Errors(err) {
+			logrus.Errorf("Startup failed: %v", err)
+		}
+		if cfg.ListenerFailurePolicy != config.FailurePolicyDegrade {
+			failed = true
+		}
+	}
+	publishAddresses(ctx, manager, cfg.PortsFile)
+
+	// Reload the configuration on SIGHUP and whenever the config file changes
+	var fileChanges <-chan struct{}
+	if path := viper.GetString("config"); path != "" {
+		if fileChanges, err = reload.Watch(ctx, path); err != nil {
+			logrus.Warnf("Config file changes will not be reloaded: %v", err)
+		}
 	}
 
 	// Wait for shutdown signal
 	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-	<-sigChan
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	for running := !failed; running; {
+		select {
+		case err := <-manager.Failed():
+			logrus.Errorf("Shutting down: %v", err)
+			failed, running = true, false
+		case sig := <-sigChan:
+			if sig != syscall.SIGHUP {
+				running = false
+				break
+			}
+			cfg = reloadConfig(cfg, manager, healthChecker, reload.TriggerSignal)
+		case <-fileChanges:
+			cfg = reloadConfig(cfg, manager, healthChecker, reload.TriggerFile)
+		}
+	}
+	if cfg.PortsFile != "" {
+		if err := os.Remove(cfg.PortsFile); err != nil && !os.IsNotExist(err) {
+			logrus.Warnf("Failed to remove ports file: %v", err)
+		}
+	}
+
+	// Drain: stop being ready, keep serving for the drain delay so endpoints
+	// are removed, then close connections within the shutdown timeout. A
+	// second signal cuts the delay short. Failed apps do not wait.
+	drainDelay := cfg.DrainDelay
+	if failed {
+		drainDelay = 0
+	}
+	drainCtx, skipDelay := context.WithCancel(context.Background())
+	go func() {
+		for sig := range sigChan {
+			if sig != syscall.SIGHUP {
+				skipDelay()
+				return
+			}
+		}
+	}()
+	stopProbe()
+	logrus.Infof("Shutting down servers (drain delay: %v, timeout: %v)...", drainDelay, cfg.ShutdownTimeout)
+	shutdownErr := manager.Drain(drainCtx, drainDelay, cfg.ShutdownTimeout)
+	skipDelay()
 
 	// Cancel context to signal shutdown
 	cancel()
 
-	// Graceful shutdown with timeout
-	shutdownTimeout := 30 * time.Second
-	logrus.Infof("Shutting down servers (timeout: %v)...", shutdownTimeout)
+	// Flush spans of requests completed during shutdown
+	tracingCtx, tracingCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer tracingCancel()
+	if err := shutdownTracing(tracingCtx); err != nil {
+		logrus.Errorf("Failed to flush traces: %v", err)
+	}
 
-	if err := manager.Shutdown(shutdownTimeout); err != nil {
-		logrus.Errorf("Shutdown error: %v", err)
+	if shutdownErr != nil {
+		logrus.Errorf("Shutdown error: %v", shutdownErr)
+		os.Exit(1)
+	}
+	if failed {
 		os.Exit(1)
 	}
 
 	logrus.Info("Shutdown complete")
 }
 
-// validateConfig validates the configuration
-func validateConfig(cfg *config.Config) error {
-	// Validate ports
-	if !utils.IsValidPort(cfg.HTTPPort) {
-		return fmt.Errorf("invalid HTTP port: %s", cfg.HTTPPort)
-	}
-	if cfg.TLS && !utils.IsValidPort(cfg.TLSPort) {
-		return fmt.Errorf("invalid TLS port: %s", cfg.TLSPort)
+// publishAddresses writes the addresses the servers bound to the ports file,
+// if one is configured, once every server is listening. The servers log their
+// bound addresses themselves.
+func publishAddresses(ctx context.Context, manager *server.Manager, portsFile string) {
+	if portsFile == "" {
+		return
 	}
-	if cfg.TCP && !utils.IsValidPort(cfg.TCPPort) {
-		return fmt.Errorf("invalid TCP port: %s", cfg.TCPPort)
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	if err := server.WritePortsFile(portsFile, manager.Addresses(ctx)); err != nil {
+		logrus.Errorf("%v", err)
+		return
 	}
-	if cfg.GRPC && !utils.IsValidPort(cfg.GRPCPort) {
-		return fmt.Errorf("invalid gRPC port: %s", cfg.GRPCPort)
+	logrus.Infof("Bound addresses written to %s", portsFile)
+}
+
+// registerFlags defines the server flags on fs
+func registerFlags(fs *pflag.FlagSet) {
+	fs.String("config", "", "Path to a YAML or TOML config file")
+	fs.Bool("validate-only", false, "Validate the configuration, print the effective configuration and exit")
+	fs.String("message", "", "Custom message")
+	fs.String("node", "", "Node name")
+	fs.Bool("print-http-request-headers", false, "Print HTTP request headers")
+	fs.Bool("tls", false, "Enable TLS server")
+	fs.Bool("h2c", false, "Enable HTTP/2 cleartext (h2c) on the HTTP listener")
+	fs.Bool("tcp", false, "Enable TCP server")
+	fs.Bool("grpc", false, "Enable gRPC server")
+	fs.Bool("grpc-tls", false, "Serve gRPC over TLS with the self-signed certificate")
+	fs.Bool("quic", false, "Enable QUIC server")
+	fs.Bool("webtransport", false, "Enable the WebTransport echo endpoint on the QUIC listener")
+	fs.Bool("metrics", true, "Enable metrics server")
+	fs.String("http-port", "8080", "HTTP server port")
+	fs.String("tls-port", "8443", "TLS server port")
+	fs.String("tcp-port", "9090", "TCP server port")
+	fs.String("grpc-port", "50051", "gRPC server port")
+	fs.String("quic-port", "4433", "QUIC server port")
+	fs.String("metrics-port", "3000", "Metrics server port")
+	fs.String("http-address", "", "Listen address as host:port, [ipv6]:port or unix:///path (default: all interfaces on the port)")
+	fs.String("tls-address", "", "Listen address as host:port, [ipv6]:port or unix:///path (default: all interfaces on the port)")
+	fs.String("tcp-address", "", "Listen address as host:port, [ipv6]:port or unix:///path (default: all interfaces on the port)")
+	fs.String("grpc-address", "", "Listen address as host:port, [ipv6]:port or unix:///path (default: all interfaces on the port)")
+	fs.String("quic-address", "", "Listen address as host:port, [ipv6]:port or unix:///path (default: all interfaces on the port)")
+	fs.String("metrics-address", "", "Listen address as host:port, [ipv6]:port or unix:///path (default: all interfaces on the port)")
+	fs.String("socket-mode", "", "Permissions of Unix domain sockets, such as 0660 (default: umask)")
+	fs.String("ports-file", "", "Write the bound listener addresses to this JSON file, e.g. for ports picked with port 0")
+	fs.String("listener-failure-policy", config.FailurePolicyExit, "What to do when a listener fails after startup: exit, restart (with backoff) or degrade (keep serving, not ready)")
+	fs.Duration("drain-delay", 0, "How long to keep serving after becoming not ready on SIGTERM, so load balancers stop routing here first")
+	fs.Duration("shutdown-timeout", 30*time.Second, "How long to wait for in-flight requests on shutdown before closing connections")
+	fs.String("admin-token", "", "Bearer token required to change listeners and probes through the admin API (disabled when empty)")
+	fs.String("log-level", "info", "Log level (debug, info, warn, error)")
+	fs.String("log-format", "text", "Application log format (text, json)")
+	fs.String("tcp-format", "json", "TCP response format (json, pretty, yaml, text, html, msgpack)")
+	fs.Int64("max-request-size", 10485760, "Maximum request body size in bytes (default: 10MB)")
+	fs.String("request-id-header", "X-Request-ID", "Header used to accept and return request IDs")
+	fs.Bool("alt-svc", true, "Advertise HTTP/3 via Alt-Svc on TLS responses when QUIC is enabled")
+	fs.String("alt-svc-port", "", "Port advertised in Alt-Svc headers (default: QUIC port)")
+	fs.Duration("alt-svc-max-age", 24*time.Hour, "How long clients may cache the Alt-Svc advertisement")
+	fs.String("external-readiness-probe-type", "none", "External readiness probe type: none, http, tcp, icmp, grpc, dns, or tls")
+	fs.String("external-readiness-probe-target", "", "External readiness probe target URL, host:port, host/IP, or DNS name")
+	fs.Duration("external-readiness-probe-interval", 10*time.Second, "External readiness probe interval")
+	fs.Duration("external-readiness-probe-timeout", 2*time.Second, "External readiness probe timeout")
+	fs.Int("external-readiness-probe-success-threshold", 1, "Consecutive passing checks before an external readiness probe counts as ready")
+	fs.Int("external-readiness-probe-failure-threshold", 3, "Consecutive failing checks before an external readiness probe counts as not ready")
+	fs.Duration("external-readiness-probe-max-interval", 0, "Cap of the probe interval, doubling after every failed check while not ready (0 disables backoff)")
+	fs.String("external-readiness-http-method", "GET", "HTTP method for external readiness HTTP probes")
+	fs.Int("external-readiness-http-expected-status", 200, "Expected HTTP status for external readiness HTTP probes")
+	fs.String("external-readiness-http-headers", "", "Comma-separated \"Name: value\" headers sent by external readiness HTTP probes")
+	fs.String("external-readiness-http-body", "", "Request body sent by external readiness HTTP probes")
+	fs.String("external-readiness-http-expected-statuses", "", "Comma-separated HTTP statuses and ranges such as 200-299,304 accepted by external readiness HTTP probes (replaces the expected status)")
+	fs.String("external-readiness-http-body-contains", "", "Substring the response body of external readiness HTTP probes must contain")
+	fs.String("external-readiness-http-body-regex", "", "Regular expression the response body of external readiness HTTP probes must match")
+	fs.String("external-readiness-http-body-json-path", "", "JSON path such as $.status that must exist in the JSON response of external readiness HTTP probes")
+	fs.String("external-readiness-http-body-json-value", "", "Value expected at the JSON path of external readiness HTTP probes (default: any)")
+	fs.String("external-readiness-http-redirect-policy", config.HTTPRedirectFollow, "How external readiness HTTP probes handle redirects: follow, none, or same-host")
+	fs.String("external-readiness-grpc-service", "", "Service asked by gRPC health readiness probes (default: the whole server)")
+	fs.Bool("external-readiness-grpc-tls", false, "Connect to gRPC readiness probe targets with TLS")
+	fs.String("external-readiness-dns-record-type", "IP", "Record type DNS readiness probes look up: IP, A, AAAA, CNAME, SRV, TXT, or MX")
+	fs.String("external-readiness-dns-server", "", "DNS server as host[:port] for DNS readiness probes (default: system resolver)")
+	fs.String("external-readiness-dns-expected-records", "", "Comma-separated records the DNS readiness probe answer must contain")
+	fs.String("external-readiness-tls-server-name", "", "Server name sent and verified by HTTPS, TLS and gRPC readiness probes (default: target host)")
+	fs.Duration("external-readiness-tls-min-validity", 0, "How long certificates must remain valid for TLS readiness probes to pass")
+	fs.Bool("external-readiness-tls-insecure-skip-verify", false, "Skip certificate chain and name verification in HTTPS, TLS and gRPC readiness probes")
+	fs.String("external-readiness-tls-ca-file", "", "PEM CA certificates verifying HTTPS, TLS and gRPC readiness probe targets (default: system roots)")
+	fs.String("external-readiness-tls-cert-file", "", "PEM client certificate presented by HTTPS, TLS and gRPC readiness probes")
+	fs.String("external-readiness-tls-key-file", "", "PEM key of the readiness probe client certificate")
+	fs.String("external-readiness-policy", config.ReadinessPolicyAll, "How external readiness probes combine: all, any, or quorum")
+	fs.Int("external-readiness-quorum", 0, "Passing external readiness probes required by the quorum policy (default: majority)")
+	fs.Bool("access-log", false, "Enable structured access logging")
+	fs.String("access-log-format", "json", "Access log format (json, logfmt, common, combined)")
+	fs.String("access-log-fields", "", "Comma-separated access log fields for json/logfmt (default: all)")
+	fs.Bool("access-log-include-headers", false, "Include request headers in access log records")
+	fs.Bool("access-log-include-body", false, "Include request bodies in access log records")
+	fs.Int64("access-log-max-body-size", 4096, "Maximum number of request body bytes to log")
+	fs.String("access-log-redact", "Authorization,Proxy-Authorization,Cookie,Set-Cookie,X-Api-Key,password,token,secret", "Comma-separated header names and body keys to redact")
+	fs.String("access-log-output", "stdout", "Access log output (stdout, stderr, file, syslog)")
+	fs.String("access-log-file", "", "Access log file path when output is file")
+	fs.Int64("access-log-file-max-size", 100, "Rotate the access log file after this many megabytes")
+	fs.Int("access-log-file-max-backups", 3, "Number of rotated access log files to keep")
+	fs.String("access-log-syslog-socket", "/dev/log", "Syslog Unix socket when output is syslog")
+	fs.Bool("tracing", false, "Export OpenTelemetry spans via OTLP")
+	fs.String("tracing-exporter", "otlp-grpc", "Trace exporter (otlp-grpc, otlp-http)")
+	fs.String("tracing-endpoint", "", "OTLP collector endpoint as host:port or URL (default: exporter default or OTEL_EXPORTER_OTLP_ENDPOINT)")
+	fs.Bool("tracing-insecure", true, "Export spans without TLS")
+	fs.Float64("tracing-sample-ratio", 1.0, "Fraction of new traces to sample (0-1); sampled parents are always followed")
+	fs.String("tracing-service-name", "echo-app", "Service name reported in exported spans")
+	fs.Bool("chain", false, "Enable the /chain endpoint on the HTTP, TLS and QUIC listeners")
+	fs.String("chain-allowed-upstreams", "", "Comma-separated upstreams /chain requests may name with ?upstream= besides the chain upstreams, matched by scheme and host:port")
+	fs.String("chain-upstreams", "", "Comma-separated upstreams called by /chain when a request names none (http://, https://, grpc://, grpcs://, tcp://)")
+	fs.Duration("chain-timeout", 5*time.Second, "Default per-call timeout for /chain upstreams")
+	fs.Int("chain-max-upstreams", 10, "Maximum number of upstreams per /chain request")
+	fs.Int("chain-max-depth", 10, "Maximum number of chained hops")
+	fs.String("chain-propagate-headers", config.DefaultChainPropagateHeaders, "Comma-separated request headers forwarded to /chain upstreams")
+	fs.Bool("chain-insecure-skip-verify", false, "Skip certificate verification for https and grpcs upstreams")
+	fs.Bool("diagnostics", false, "Enable the /debug/resolve, /debug/dial and /debug/http endpoints on the metrics server")
+	fs.String("diagnostics-token", "", "Bearer token required by the diagnostics endpoints")
+	fs.String("diagnostics-allowed-cidrs", config.DefaultDiagnosticsAllowedCIDRs, "Comma-separated source CIDRs allowed to call the diagnostics endpoints (empty allows all)")
+	fs.Duration("diagnostics-timeout", 5*time.Second, "Timeout for each diagnostics lookup, dial or request")
+}
+
+// reloadConfig loads the configuration again and applies it to the running
+// servers, then to readiness and logging, and returns the configuration in
+// effect. The current configuration stays in effect if the new one is invalid
+// or the servers reject it.
+func reloadConfig(current *config.Config, manager *server.Manager, healthChecker *health.Checker, trigger string) *config.Config {
+	logrus.Infof("Reloading configuration (trigger: %s)...", trigger)
+	cfg, err := config.Load()
+	applied := current
+	if err == nil {
+		applied, err = manager.Reload(cfg)
 	}
-	if cfg.QUIC && !utils.IsValidPort(cfg.QUICPort) {
-		return fmt.Errorf("invalid QUIC port: %s", cfg.QUICPort)
+	reload.Record(trigger, err)
+	if applied != cfg {
+		for _, err := range config.Errors(err) {
+			logrus.Errorf("Configuration reload failed: %v", err)
+		}
+		return current
 	}
-	if cfg.Metrics && !utils.IsValidPort(cfg.MetricsPort) {
-		return fmt.Errorf("invalid metrics port: %s", cfg.MetricsPort)
+
+	cfg.ApplyLogging()
+	healthChecker.SetReadiness(cfg.ExternalReadiness)
+	for _, setting := range restartRequired(current, cfg) {
+		logrus.Warnf("Changes to the %s settings take effect after a restart", setting)
 	}
-	if cfg.ExternalReadinessProbe.Enabled() {
-		// Keep "ping" as a compatibility alias for the in-process ICMP probe.
-		switch cfg.ExternalReadinessProbe.Type {
-		case "http", "https", "tcp", "ping", "icmp":
-		default:
-			return fmt.Errorf("invalid external readiness probe type: %s", cfg.ExternalReadinessProbe.Type)
+	if err != nil {
+		for _, err := range config.Errors(err) {
+			logrus.Errorf("Configuration reloaded with errors: %v", err)
 		}
+	} else {
+		logrus.Info("Configuration reloaded")
 	}
+	// Restarted listeners may have bound new ephemeral ports
+	publishAddresses(context.Background(), manager, cfg.PortsFile)
+	return cfg
+}
 
-	return nil
+// restartRequired lists the changed settings that are only applied on startup
+func restartRequired(current, next *config.Config) []string {
+	var settings []string
+	if current.Metrics != next.Metrics || current.MetricsAddress != next.MetricsAddress {
+		settings = append(settings, "metrics")
+	}
+	if current.SocketMode != next.SocketMode {
+		settings = append(settings, "socket mode")
+	}
+	if !reflect.DeepEqual(current.Diagnostics, next.Diagnostics) {
+		settings = append(settings, "diagnostics")
+	}
+	if !reflect.DeepEqual(current.AccessLog, next.AccessLog) {
+		settings = append(settings, "access log")
+	}
+	if !reflect.DeepEqual(current.Tracing, next.Tracing) {
+		settings = append(settings, "tracing")
+	}
+	return settings
 }
@@ -0,0 +1,164 @@
+package echoapp
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/PhilipSchmid/echo-app/internal/handlers"
+	"github.com/PhilipSchmid/echo-app/internal/server"
+	"github.com/PhilipSchmid/echo-app/proto"
+	"github.com/quic-go/quic-go/http3"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// URL returns the base URL of the HTTP, TLS, QUIC or metrics server name.
+// Servers on Unix sockets are addressed as localhost and reached through
+// HTTPClient.
+func (a *App) URL(name string) (string, error) {
+	protocol, _, addr, err := a.httpServer(name)
+	if err != nil {
+		return "", err
+	}
+	scheme := "http"
+	if protocol != ProtocolHTTP {
+		scheme = "https"
+	}
+	host := addr.Address
+	if addr.Network == "unix" {
+		host = "localhost"
+	}
+	return scheme + "://" + host, nil
+}
+
+// HTTPClient returns a client for the HTTP, TLS, QUIC or metrics server name.
+// It trusts the self-signed certificate, speaks HTTP/2 cleartext to H2C
+// listeners and HTTP/3 to QUIC listeners, and dials Unix sockets. Call
+// CloseIdleConnections when done with it.
+func (a *App) HTTPClient(name string) (*http.Client, error) {
+	protocol, h2c, addr, err := a.httpServer(name)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig := &tls.Config{InsecureSkipVerify: true} // #nosec G402 -- echo-app serves a self-signed certificate
+
+	if protocol == ProtocolQUIC {
+		return &http.Client{Transport: &http3.Transport{TLSClientConfig: tlsConfig}}, nil
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	transport.DialContext = dialer(addr)
+	if h2c {
+		transport.Protocols = new(http.Protocols)
+		transport.Protocols.SetUnencryptedHTTP2(true)
+	}
+	return &http.Client{Transport: transport}, nil
+}
+
+// httpServer returns the protocol, whether it serves HTTP/2 cleartext, and
+// the bound address of the server name, which must serve HTTP
+func (a *App) httpServer(name string) (string, bool, server.BoundAddress, error) {
+	if name == MetricsName {
+		addr, err := a.bound(name)
+		return ProtocolHTTP, false, addr, err
+	}
+	l, addr, err := a.listener(name)
+	if err != nil {
+		return "", false, addr, err
+	}
+	switch l.Protocol {
+	case ProtocolHTTP, ProtocolTLS, ProtocolQUIC:
+		return l.Protocol, l.H2C, addr, nil
+	}
+	return "", false, addr, fmt.Errorf("%s is a %s listener, not an HTTP one", name, l.Protocol)
+}
+
+// Get requests path from the HTTP, TLS or QUIC listener name and returns the
+// echo response
+func (a *App) Get(ctx context.Context, name, path string) (*HTTPResponse, error) {
+	base, err := a.URL(name)
+	if err != nil {
+		return nil, err
+	}
+	client, err := a.HTTPClient(name)
+	if err != nil {
+		return nil, err
+	}
+	defer client.CloseIdleConnections()
+	if c, ok := client.Transport.(io.Closer); ok {
+		defer func() { _ = c.Close() }()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, base+"/"+strings.TrimPrefix(path, "/"), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	var response HTTPResponse
+	if err := handlers.DecodeResponse(resp.Body, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// ReadTCP connects to the TCP listener name and returns the response it
+// writes. The listener must use the json format.
+func (a *App) ReadTCP(ctx context.Context, name string) (*TCPResponse, error) {
+	l, addr, err := a.listener(name)
+	if err != nil {
+		return nil, err
+	}
+	if l.Protocol != ProtocolTCP {
+		return nil, fmt.Errorf("%s is a %s listener, not a TCP one", name, l.Protocol)
+	}
+	conn, err := dialer(addr)(ctx, "", "")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = conn.Close() }()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	var response TCPResponse
+	if err := handlers.DecodeResponse(conn, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// Echo calls EchoService/Echo on the gRPC listener name
+func (a *App) Echo(ctx context.Context, name string) (*proto.EchoResponse, error) {
+	l, addr, err := a.listener(name)
+	if err != nil {
+		return nil, err
+	}
+	if l.Protocol != ProtocolGRPC {
+		return nil, fmt.Errorf("%s is a %s listener, not a gRPC one", name, l.Protocol)
+	}
+	creds := insecure.NewCredentials()
+	if l.TLS {
+		creds = credentials.NewTLS(&tls.Config{InsecureSkipVerify: true}) // #nosec G402 -- echo-app serves a self-signed certificate
+	}
+	// gRPC resolves unix:///path targets itself
+	conn, err := grpc.NewClient(addr.Address, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = conn.Close() }()
+	return proto.NewEchoServiceClient(conn).Echo(ctx, &proto.EchoRequest{})
+}
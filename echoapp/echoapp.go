@@ -0,0 +1,279 @@
+// Package echoapp runs echo-app servers inside another Go program, typically a
+// test, so its listeners can be exercised without starting the binary:
+//
+//	app := echoapp.NewTest(t, echoapp.Options{
+//		Message:   "hello",
+//		Listeners: []echoapp.Listener{{Protocol: echoapp.ProtocolHTTP}, {Protocol: echoapp.ProtocolGRPC}},
+//	})
+//	resp, err := app.Get(ctx, "http", "/")
+//
+// Listeners bind ephemeral loopback ports unless given an address, and Addrs
+// reports the addresses picked.
+package echoapp
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/PhilipSchmid/echo-app/internal/config"
+	"github.com/PhilipSchmid/echo-app/internal/handlers"
+	"github.com/PhilipSchmid/echo-app/internal/health"
+	"github.com/PhilipSchmid/echo-app/internal/server"
+)
+
+// Listener protocols
+const (
+	ProtocolHTTP = config.ListenerHTTP // HTTP/1.1, and HTTP/2 cleartext with H2C
+	ProtocolTLS  = config.ListenerTLS  // HTTPS with a self-signed certificate
+	ProtocolTCP  = config.ListenerTCP  // Raw TCP, writing the response on connect
+	ProtocolGRPC = config.ListenerGRPC // gRPC EchoService
+	ProtocolQUIC = config.ListenerQUIC // HTTP/3 over QUIC
+)
+
+// DefaultAddress is where listeners without an address are bound: an
+// ephemeral port on the IPv4 loopback interface
+const DefaultAddress = "127.0.0.1:0"
+
+// MetricsName is the name Addrs reports the metrics server under
+const MetricsName = "Metrics"
+
+// defaultShutdownTimeout bounds Shutdown when its context has no deadline
+const defaultShutdownTimeout = 30 * time.Second
+
+// Response types returned by the clients
+type (
+	HTTPResponse = handlers.HTTPResponse // HTTP, TLS and QUIC listeners
+	TCPResponse  = handlers.TCPResponse  // TCP listeners
+	BaseResponse = handlers.BaseResponse // Fields every response carries
+)
+
+// Listener is an echo listener to start
+type Listener struct {
+	Name         string // Defaults to the protocol, or protocol-N for further listeners of the same protocol
+	Protocol     string // One of the Protocol constants
+	Address      string // host:port, [ipv6]:port or unix:///path (default: DefaultAddress)
+	Message      string // Overrides Options.Message
+	H2C          bool   // HTTP only: also serve HTTP/2 cleartext
+	TLS          bool   // gRPC only: serve over TLS with the self-signed certificate
+	WebTransport bool   // QUIC only: enable the /webtransport endpoint
+	Format       string // TCP only: json, pretty, yaml, text, html or msgpack (default: json)
+}
+
+// Options configures the servers
+type Options struct {
+	Message      string     // Message included in every response
+	Node         string     // Node name included in every response
+	PrintHeaders bool       // Include the HTTP request headers in responses
+	Listeners    []Listener // Listeners to start (default: a single HTTP listener)
+	Metrics      bool       // Start the metrics server with /metrics, /health and /ready
+	MetricsAddr  string     // Address of the metrics server (default: DefaultAddress)
+}
+
+// App is a set of running echo-app servers
+type App struct {
+	cfg     *config.Config
+	manager *server.Manager
+	health  *health.Checker
+
+	mu     sync.Mutex
+	cancel context.CancelFunc // Stops the servers started by Start, nil before
+	addrs  map[string]server.BoundAddress
+}
+
+// New validates opts and prepares the servers without starting them
+func New(opts Options) (*App, error) {
+	cfg := config.Default()
+	cfg.Message, cfg.Node, cfg.PrintHeaders = opts.Message, opts.Node, opts.PrintHeaders
+	cfg.Metrics = opts.Metrics
+	cfg.MetricsAddress = opts.MetricsAddr
+	if cfg.MetricsAddress == "" {
+		cfg.MetricsAddress = DefaultAddress
+	}
+	if err := cfg.SetListeners(configListeners(opts.Listeners)); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	app := &App{
+		cfg:    cfg,
+		health: health.NewChecker(cfg.ExternalReadiness),
+	}
+	app.manager = server.NewManager(cfg, app.health)
+	for _, l := range cfg.Listeners {
+		if err := app.manager.RegisterListener(cfg, l); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Metrics {
+		app.manager.RegisterServer(server.NewMetricsServer(cfg, app.health).WithManager(app.manager))
+	}
+	return app, nil
+}
+
+// configListeners converts the listeners to their configuration, naming
+// unnamed ones after their protocol and binding them to DefaultAddress
+func configListeners(listeners []Listener) []config.Listener {
+	if len(listeners) == 0 {
+		listeners = []Listener{{Protocol: ProtocolHTTP}}
+	}
+	counts := make(map[string]int)
+	result := make([]config.Listener, 0, len(listeners))
+	for _, l := range listeners {
+		counts[l.Protocol]++
+		if l.Name == "" {
+			l.Name = l.Protocol
+			if n := counts[l.Protocol]; n > 1 {
+				l.Name = fmt.Sprintf("%s-%d", l.Protocol, n)
+			}
+		}
+		if l.Address == "" {
+			l.Address = DefaultAddress
+		}
+		result = append(result, config.Listener{
+			Name:         l.Name,
+			Protocol:     l.Protocol,
+			Address:      l.Address,
+			Message:      l.Message,
+			H2C:          l.H2C,
+			TLS:          l.TLS,
+			WebTransport: l.WebTransport,
+			Format:       l.Format,
+		})
+	}
+	return result
+}
+
+// Start starts every server and waits until they are listening, returning
+// every startup failure. The servers run until Shutdown.
+func (a *App) Start(ctx context.Context) error {
+	a.mu.Lock()
+	if a.cancel != nil {
+		a.mu.Unlock()
+		return fmt.Errorf("echo-app servers can only be started once")
+	}
+	runCtx, cancel := context.WithCancel(context.Background())
+	a.cancel = cancel
+	a.mu.Unlock()
+
+	a.health.Start(runCtx)
+	if err := a.manager.Start(runCtx); err != nil {
+		_ = a.Shutdown(context.Background())
+		return err
+	}
+
+	bound := make(map[string]server.BoundAddress)
+	for _, addr := range a.manager.Addresses(ctx) {
+		bound[addr.Name] = addr
+	}
+	a.mu.Lock()
+	a.addrs = bound
+	a.mu.Unlock()
+	return nil
+}
+
+// Shutdown gracefully stops the servers, waiting for in-flight requests until
+// ctx is done
+func (a *App) Shutdown(ctx context.Context) error {
+	a.mu.Lock()
+	cancel := a.cancel
+	a.addrs = nil
+	a.mu.Unlock()
+	if cancel == nil {
+		return nil
+	}
+
+	timeout := defaultShutdownTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+	err := a.manager.Shutdown(timeout)
+	cancel()
+	return err
+}
+
+// Addrs returns the addresses the servers are listening on by name: host:port,
+// or unix:///path for Unix sockets. It is empty before Start.
+func (a *App) Addrs() map[string]string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	addrs := make(map[string]string, len(a.addrs))
+	for name, addr := range a.addrs {
+		addrs[name] = addr.Address
+	}
+	return addrs
+}
+
+// Addr returns the address the server name is listening on
+func (a *App) Addr(name string) (string, error) {
+	addr, err := a.bound(name)
+	if err != nil {
+		return "", err
+	}
+	return addr.Address, nil
+}
+
+// bound returns the bound address of the server name
+func (a *App) bound(name string) (server.BoundAddress, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	addr, ok := a.addrs[name]
+	if !ok {
+		return server.BoundAddress{}, fmt.Errorf("no running server named %q", name)
+	}
+	return addr, nil
+}
+
+// listener returns the configured listener name and its bound address
+func (a *App) listener(name string) (config.Listener, server.BoundAddress, error) {
+	addr, err := a.bound(name)
+	if err != nil {
+		return config.Listener{}, addr, err
+	}
+	for _, l := range a.cfg.Listeners {
+		if l.Name == name {
+			return l, addr, nil
+		}
+	}
+	return config.Listener{}, addr, fmt.Errorf("%s is not an echo listener", name)
+}
+
+// NewTest starts the servers for the duration of t, failing t if they cannot
+// start, and shuts them down when t completes
+func NewTest(t testing.TB, opts Options) *App {
+	t.Helper()
+	app, err := New(opts)
+	if err != nil {
+		t.Fatalf("invalid echo-app options: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := app.Start(ctx); err != nil {
+		t.Fatalf("failed to start echo-app: %v", err)
+	}
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := app.Shutdown(ctx); err != nil {
+			t.Errorf("failed to shut down echo-app: %v", err)
+		}
+	})
+	return app
+}
+
+// dialer dials the bound address, including Unix sockets
+func dialer(addr server.BoundAddress) func(ctx context.Context, _, _ string) (net.Conn, error) {
+	return func(ctx context.Context, _, _ string) (net.Conn, error) {
+		var d net.Dialer
+		network, target := "tcp", addr.Address
+		if addr.Network == "unix" {
+			network, target = "unix", addr.Address[len("unix://"):]
+		}
+		return d.DialContext(ctx, network, target)
+	}
+}
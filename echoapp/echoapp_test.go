@@ -0,0 +1,152 @@
+package echoapp
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApp_AllProtocols(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "tcp.sock")
+	app := NewTest(t, Options{
+		Message: "hello",
+		Node:    "test-node",
+		Metrics: true,
+		Listeners: []Listener{
+			{Protocol: ProtocolHTTP},
+			{Protocol: ProtocolHTTP, H2C: true, Message: "cleartext"},
+			{Protocol: ProtocolTLS},
+			{Name: "raw", Protocol: ProtocolTCP, Address: "unix://" + socket},
+			{Protocol: ProtocolGRPC},
+			{Protocol: ProtocolQUIC},
+		},
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	addrs := app.Addrs()
+	assert.Len(t, addrs, 7)
+	for _, name := range []string{"http", "http-2", "tls", "grpc", "quic", MetricsName} {
+		assert.Regexp(t, `^127\.0\.0\.1:[1-9][0-9]*$`, addrs[name], name)
+	}
+	assert.Equal(t, "unix://"+socket, addrs["raw"])
+
+	tests := []struct {
+		name     string
+		listener string
+		version  string
+		message  string
+	}{
+		{"http", "HTTP", "HTTP/1.1", "hello"},
+		{"http-2", "H2C", "HTTP/2.0", "cleartext"},
+		{"tls", "TLS", "HTTP/2.0", "hello"},
+		{"quic", "QUIC", "HTTP/3.0", "hello"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := app.Get(ctx, tt.name, "/path")
+			require.NoError(t, err)
+			assert.Equal(t, tt.listener, resp.Listener)
+			assert.Equal(t, tt.version, resp.HTTPVersion)
+			assert.Equal(t, tt.message, resp.Message)
+			assert.Equal(t, "test-node", resp.Node)
+			assert.Equal(t, "/path", resp.HTTPEndpoint)
+			assert.Equal(t, addrs[tt.name], resp.LocalAddr)
+		})
+	}
+
+	tcp, err := app.ReadTCP(ctx, "raw")
+	require.NoError(t, err)
+	assert.Equal(t, "TCP", tcp.Listener)
+	assert.Equal(t, "hello", tcp.Message)
+
+	echo, err := app.Echo(ctx, "grpc")
+	require.NoError(t, err)
+	assert.Equal(t, "gRPC", echo.GetListener())
+	assert.Equal(t, "hello", echo.GetMessage())
+	assert.Equal(t, addrs["grpc"], echo.GetLocalAddress())
+
+	url, err := app.URL(MetricsName)
+	require.NoError(t, err)
+	client, err := app.HTTPClient(MetricsName)
+	require.NoError(t, err)
+	defer client.CloseIdleConnections()
+	resp, err := client.Get(url + "/health")
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, 200, resp.StatusCode)
+}
+
+func TestApp_WrongProtocol(t *testing.T) {
+	app := NewTest(t, Options{Listeners: []Listener{{Protocol: ProtocolHTTP}, {Protocol: ProtocolTCP}}})
+	ctx := context.Background()
+
+	_, err := app.Get(ctx, "tcp", "/")
+	assert.ErrorContains(t, err, "tcp is a tcp listener, not an HTTP one")
+	_, err = app.Echo(ctx, "http")
+	assert.ErrorContains(t, err, "http is a http listener, not a gRPC one")
+	_, err = app.ReadTCP(ctx, "missing")
+	assert.ErrorContains(t, err, `no running server named "missing"`)
+}
+
+func TestNew_InvalidOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    Options
+		wantErr string
+	}{
+		{
+			name:    "unknown protocol",
+			opts:    Options{Listeners: []Listener{{Protocol: "ftp"}}},
+			wantErr: `invalid protocol "ftp"`,
+		},
+		{
+			name:    "duplicate names",
+			opts:    Options{Listeners: []Listener{{Name: "a", Protocol: ProtocolHTTP}, {Name: "a", Protocol: ProtocolTCP}}},
+			wantErr: "duplicate listener name: a",
+		},
+		{
+			name:    "invalid address",
+			opts:    Options{Listeners: []Listener{{Protocol: ProtocolHTTP, Address: "127.0.0.1:99999"}}},
+			wantErr: "invalid port",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.opts)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestApp_StartFailure(t *testing.T) {
+	blocker := NewTest(t, Options{})
+	addr, err := blocker.Addr("http")
+	require.NoError(t, err)
+
+	app, err := New(Options{Listeners: []Listener{{Protocol: ProtocolHTTP, Address: addr}}})
+	require.NoError(t, err)
+	err = app.Start(context.Background())
+	assert.ErrorContains(t, err, "failed to start http server: failed to listen on "+addr)
+	assert.Empty(t, app.Addrs())
+}
+
+func TestApp_Shutdown(t *testing.T) {
+	app, err := New(Options{})
+	require.NoError(t, err)
+	assert.NoError(t, app.Shutdown(context.Background()), "shutdown before start")
+
+	require.NoError(t, app.Start(context.Background()))
+	assert.ErrorContains(t, app.Start(context.Background()), "can only be started once")
+	_, err = app.Get(context.Background(), "http", "/")
+	require.NoError(t, err)
+
+	require.NoError(t, app.Shutdown(context.Background()))
+	assert.Empty(t, app.Addrs())
+	_, err = app.Get(context.Background(), "http", "/")
+	assert.ErrorContains(t, err, "no running server")
+}
@@ -0,0 +1,470 @@
+package accesslog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/PhilipSchmid/echo-app/internal/config"
+	"github.com/sirupsen/logrus"
+)
+
+// redacted replaces the value of sensitive headers and body fields
+const redacted = "[REDACTED]"
+
+// Fields lists every field name that can be selected for json and logfmt records
+var Fields = []string{
+	"time",
+	"request_id",
+	"listener",
+	"protocol",
+	"source_ip",
+	"remote_addr",
+	"method",
+	"path",
+	"query",
+	"host",
+	"status",
+	"grpc_code",
+	"bytes_in",
+	"bytes_out",
+	"duration_ms",
+	"user_agent",
+	"referer",
+	"error",
+	"headers",
+	"body",
+}
+
+// Record describes a single request, connection or RPC
+type Record struct {
+	Time       time.Time
+	RequestID  string
+	Listener   string
+	Protocol   string
+	RemoteAddr string
+	Method     string
+	Path       string
+	Query      string
+	Host       string
+	Status     int
+	GRPCCode   string
+	BytesIn    int64
+	BytesOut   int64
+	Duration   time.Duration
+	UserAgent  string
+	Referer    string
+	Error      string
+	Headers    map[string][]string
+	Body       []byte
+}
+
+// Logger writes access log records to the configured sink
+type Logger struct {
+	mu             sync.Mutex
+	out            io.Writer
+	closer         io.Closer
+	format         string
+	fields         []string
+	includeHeaders bool
+	includeBody    bool
+	maxBodySize    int64
+	redact         map[string]bool
+	jsonPattern    *regexp.Regexp
+	formPattern    *regexp.Regexp
+}
+
+var defaultLogger atomic.Pointer[Logger]
+
+// SetDefault installs the logger used by all listeners. Passing nil disables
+// access logging.
+func SetDefault(l *Logger) {
+	defaultLogger.Store(l)
+}
+
+// Default returns the logger used by all listeners, or nil when access
+// logging is disabled. All Logger methods are safe to call on nil.
+func Default() *Logger {
+	return defaultLogger.Load()
+}
+
+// New creates a Logger writing to the output selected in cfg
+func New(cfg config.AccessLog) (*Logger, error) {
+	var out io.Writer
+	var closer io.Closer
+	switch cfg.Output {
+	case config.AccessLogOutputStdout, "":
+		out = os.Stdout
+	case config.AccessLogOutputStderr:
+		out = os.Stderr
+	case config.AccessLogOutputFile:
+		f, err := newRotatingFile(cfg.File, cfg.FileMaxSize*1024*1024, cfg.FileMaxBackups)
+		if err != nil {
+			return nil, err
+		}
+		out, closer = f, f
+	case config.AccessLogOutputSyslog:
+		w, err := newSyslogWriter(cfg.SyslogSocket, "echo-app")
+		if err != nil {
+			return nil, err
+		}
+		out, closer = w, w
+	default:
+		return nil, fmt.Errorf("invalid access log output: %s", cfg.Output)
+	}
+
+	l, err := NewWriter(out, cfg)
+	if err != nil {
+		if closer != nil {
+			_ = closer.Close()
+		}
+		return nil, err
+	}
+	l.closer = closer
+	return l, nil
+}
+
+// NewWriter creates a Logger writing to out
+func NewWriter(out io.Writer, cfg config.AccessLog) (*Logger, error) {
+	fields, err := selectFields(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	redact := make(map[string]bool, len(cfg.Redact))
+	quoted := make([]string, 0, len(cfg.Redact))
+	for _, name := range cfg.Redact {
+		redact[strings.ToLower(name)] = true
+		quoted = append(quoted, regexp.QuoteMeta(name))
+	}
+
+	// Match "key": "value" pairs and key=value form fields
+	var jsonPattern, formPattern *regexp.Regexp
+	if len(quoted) > 0 {
+		keys := strings.Join(quoted, "|")
+		jsonPattern = regexp.MustCompile(`(?i)("(?:` + keys + `)"\s*:\s*)"(?:[^"\\]|\\.)*"?`)
+		formPattern = regexp.MustCompile(`(?i)((?:^|&)(?:` + keys + `)=)[^&]*`)
+	}
+
+	format := cfg.Format
+	if format == "" {
+		format = config.AccessLogFormatJSON
+	}
+
+	return &Logger{
+		out:            out,
+		format:         format,
+		fields:         fields,
+		includeHeaders: cfg.IncludeHeaders,
+		includeBody:    cfg.IncludeBody,
+		maxBodySize:    cfg.MaxBodySize,
+		redact:         redact,
+		jsonPattern:    jsonPattern,
+		formPattern:    formPattern,
+	}, nil
+}
+
+// selectFields validates the configured field list, falling back to all fields
+func selectFields(cfg config.AccessLog) ([]string, error) {
+	selected := cfg.Fields
+	if len(selected) == 0 {
+		selected = Fields
+	}
+
+	known := make(map[string]bool, len(Fields))
+	for _, name := range Fields {
+		known[name] = true
+	}
+
+	fields := make([]string, 0, len(selected))
+	for _, name := range selected {
+		name = strings.ToLower(name)
+		if !known[name] {
+			return nil, fmt.Errorf("unknown access log field: %s", name)
+		}
+		if (name == "headers" && !cfg.IncludeHeaders) || (name == "body" && !cfg.IncludeBody) {
+			continue
+		}
+		fields = append(fields, name)
+	}
+	return fields, nil
+}
+
+// IncludeHeaders reports whether records should carry request headers
+func (l *Logger) IncludeHeaders() bool {
+	return l != nil && l.includeHeaders
+}
+
+// IncludeBody reports whether records should carry the request body
+func (l *Logger) IncludeBody() bool {
+	return l != nil && l.includeBody
+}
+
+// MaxBodySize returns the maximum number of body bytes to capture
+func (l *Logger) MaxBodySize() int64 {
+	if l == nil {
+		return 0
+	}
+	return l.maxBodySize
+}
+
+// Log writes a record. It is a no-op on a nil Logger.
+func (l *Logger) Log(rec Record) {
+	if l == nil {
+		return
+	}
+
+	line := l.encode(rec)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := l.out.Write(line); err != nil {
+		logrus.Errorf("Failed to write access log record: %v", err)
+	}
+}
+
+// Close releases the underlying sink
+func (l *Logger) Close() error {
+	if l == nil || l.closer == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.closer.Close()
+}
+
+// encode renders a record in the configured format, terminated by a newline
+func (l *Logger) encode(rec Record) []byte {
+	switch l.format {
+	case config.AccessLogFormatCommon:
+		return encodeCommon(rec, false)
+	case config.AccessLogFormatCombined:
+		return encodeCommon(rec, true)
+	case config.AccessLogFormatLogfmt:
+		return l.encodeLogfmt(rec)
+	default:
+		return l.encodeJSON(rec)
+	}
+}
+
+// value returns the value of a named field, or nil when it is empty
+func (l *Logger) value(rec Record, name string) any {
+	switch name {
+	case "time":
+		return rec.Time.Format(time.RFC3339Nano)
+	case "request_id":
+		return nonEmpty(rec.RequestID)
+	case "listener":
+		return nonEmpty(rec.Listener)
+	case "protocol":
+		return nonEmpty(rec.Protocol)
+	case "source_ip":
+		return nonEmpty(sourceIP(rec.RemoteAddr))
+	case "remote_addr":
+		return nonEmpty(rec.RemoteAddr)
+	case "method":
+		return nonEmpty(rec.Method)
+	case "path":
+		return nonEmpty(rec.Path)
+	case "query":
+		return nonEmpty(rec.Query)
+	case "host":
+		return nonEmpty(rec.Host)
+	case "status":
+		if rec.Status == 0 {
+			return nil
+		}
+		return rec.Status
+	case "grpc_code":
+		return nonEmpty(rec.GRPCCode)
+	case "bytes_in":
+		return rec.BytesIn
+	case "bytes_out":
+		return rec.BytesOut
+	case "duration_ms":
+		return float64(rec.Duration.Microseconds()) / 1000
+	case "user_agent":
+		return nonEmpty(rec.UserAgent)
+	case "referer":
+		return nonEmpty(rec.Referer)
+	case "error":
+		return nonEmpty(rec.Error)
+	case "headers":
+		if len(rec.Headers) == 0 {
+			return nil
+		}
+		return l.redactHeaders(rec.Headers)
+	case "body":
+		if len(rec.Body) == 0 {
+			return nil
+		}
+		return string(l.redactBody(rec.Body))
+	}
+	return nil
+}
+
+func (l *Logger) encodeJSON(rec Record) []byte {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	first := true
+	for _, name := range l.fields {
+		value := l.value(rec, name)
+		if value == nil {
+			continue
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			continue
+		}
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+		buf.WriteString(strconv.Quote(name))
+		buf.WriteByte(':')
+		buf.Write(data)
+	}
+	buf.WriteString("}\n")
+	return buf.Bytes()
+}
+
+func (l *Logger) encodeLogfmt(rec Record) []byte {
+	var parts []string
+	for _, name := range l.fields {
+		value := l.value(rec, name)
+		if value == nil {
+			continue
+		}
+		if headers, ok := value.(map[string][]string); ok {
+			names := make([]string, 0, len(headers))
+			for header := range headers {
+				names = append(names, header)
+			}
+			sort.Strings(names)
+			for _, header := range names {
+				parts = append(parts, "header."+header+"="+logfmtValue(strings.Join(headers[header], ", ")))
+			}
+			continue
+		}
+		parts = append(parts, name+"="+logfmtValue(fmt.Sprint(value)))
+	}
+	return []byte(strings.Join(parts, " ") + "\n")
+}
+
+// logfmtValue quotes values that would otherwise be ambiguous
+func logfmtValue(value string) string {
+	if value == "" || strings.ContainsAny(value, " =\"\t\r\n") {
+		return strconv.Quote(value)
+	}
+	return value
+}
+
+// encodeCommon renders the Common Log Format, optionally extended to the
+// Combined Log Format with referer and user agent
+func encodeCommon(rec Record, combined bool) []byte {
+	target := rec.Path
+	if rec.Query != "" {
+		target += "?" + rec.Query
+	}
+	status := "-"
+	if rec.Status != 0 {
+		status = strconv.Itoa(rec.Status)
+	}
+	size := "-"
+	if rec.BytesOut > 0 {
+		size = strconv.FormatInt(rec.BytesOut, 10)
+	}
+
+	line := fmt.Sprintf("%s - - [%s] \"%s %s %s\" %s %s",
+		dash(sourceIP(rec.RemoteAddr)),
+		rec.Time.Format("02/Jan/2006:15:04:05 -0700"),
+		dash(rec.Method), dash(target), dash(rec.Protocol),
+		status, size)
+	if combined {
+		line += fmt.Sprintf(" %s %s", strconv.Quote(dash(rec.Referer)), strconv.Quote(dash(rec.UserAgent)))
+	}
+	return []byte(line + "\n")
+}
+
+// redactHeaders returns a copy of headers with sensitive values masked
+func (l *Logger) redactHeaders(headers map[string][]string) map[string][]string {
+	out := make(map[string][]string, len(headers))
+	for name, values := range headers {
+		if l.redact[strings.ToLower(name)] {
+			out[name] = []string{redacted}
+			continue
+		}
+		out[name] = values
+	}
+	return out
+}
+
+// redactBody masks sensitive keys in JSON and form bodies and truncates the
+// result. Bodies that do not parse as JSON, e.g. because they were truncated
+// on capture, are redacted by pattern instead.
+func (l *Logger) redactBody(body []byte) []byte {
+	if len(l.redact) > 0 {
+		var doc any
+		if json.Unmarshal(body, &doc) == nil {
+			if data, err := json.Marshal(l.redactValue(doc)); err == nil {
+				body = data
+			}
+		} else {
+			body = l.jsonPattern.ReplaceAll(body, []byte(`${1}"`+redacted+`"`))
+			body = l.formPattern.ReplaceAll(body, []byte("${1}"+redacted))
+		}
+	}
+	if l.maxBodySize > 0 && int64(len(body)) > l.maxBodySize {
+		body = body[:l.maxBodySize]
+	}
+	return body
+}
+
+func (l *Logger) redactValue(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for key, value := range t {
+			if l.redact[strings.ToLower(key)] {
+				t[key] = redacted
+				continue
+			}
+			t[key] = l.redactValue(value)
+		}
+	case []any:
+		for i, item := range t {
+			t[i] = l.redactValue(item)
+		}
+	}
+	return v
+}
+
+func sourceIP(remoteAddr string) string {
+	if host, _, err := net.SplitHostPort(remoteAddr); err == nil {
+		return host
+	}
+	return remoteAddr
+}
+
+func nonEmpty(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
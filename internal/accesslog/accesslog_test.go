@@ -0,0 +1,243 @@
+package accesslog
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PhilipSchmid/echo-app/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testRecord() Record {
+	return Record{
+		Time:       time.Date(2024, 8, 6, 12, 9, 46, 0, time.UTC),
+		RequestID:  "0190d6a4-8f1e-7c3a-9b2d-1f4e5a6b7c8d",
+		Listener:   "HTTP",
+		Protocol:   "HTTP/1.1",
+		RemoteAddr: "192.168.65.1:51234",
+		Method:     "POST",
+		Path:       "/login",
+		Query:      "debug=1",
+		Host:       "echo.example.com",
+		Status:     200,
+		BytesIn:    42,
+		BytesOut:   128,
+		Duration:   1500 * time.Microsecond,
+		UserAgent:  "curl/8.4.0",
+		Referer:    "https://example.com/",
+		Headers: map[string][]string{
+			"Authorization": {"Bearer secret"},
+			"Accept":        {"*/*"},
+		},
+		Body: []byte(`{"user":"alice","password":"hunter2"}`),
+	}
+}
+
+func newTestLogger(t *testing.T, cfg config.AccessLog) (*Logger, *bytes.Buffer) {
+	t.Helper()
+	var buf bytes.Buffer
+	l, err := NewWriter(&buf, cfg)
+	require.NoError(t, err)
+	return l, &buf
+}
+
+func TestLogger_JSON(t *testing.T) {
+	l, buf := newTestLogger(t, config.AccessLog{Format: config.AccessLogFormatJSON})
+	l.Log(testRecord())
+
+	var rec map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &rec))
+	assert.Equal(t, "0190d6a4-8f1e-7c3a-9b2d-1f4e5a6b7c8d", rec["request_id"])
+	assert.Equal(t, "HTTP", rec["listener"])
+	assert.Equal(t, "192.168.65.1", rec["source_ip"])
+	assert.Equal(t, "POST", rec["method"])
+	assert.Equal(t, float64(200), rec["status"])
+	assert.Equal(t, 1.5, rec["duration_ms"])
+	assert.Equal(t, "curl/8.4.0", rec["user_agent"])
+	// Headers and body are only logged when explicitly included
+	assert.NotContains(t, rec, "headers")
+	assert.NotContains(t, rec, "body")
+	// Empty fields are omitted
+	assert.NotContains(t, rec, "grpc_code")
+	assert.True(t, strings.HasSuffix(buf.String(), "}\n"))
+}
+
+func TestLogger_FieldSelection(t *testing.T) {
+	l, buf := newTestLogger(t, config.AccessLog{
+		Format: config.AccessLogFormatJSON,
+		Fields: []string{"method", "path", "status"},
+	})
+	l.Log(testRecord())
+	assert.Equal(t, `{"method":"POST","path":"/login","status":200}`+"\n", buf.String())
+}
+
+func TestLogger_UnknownField(t *testing.T) {
+	_, err := NewWriter(&bytes.Buffer{}, config.AccessLog{Fields: []string{"method", "bogus"}})
+	assert.ErrorContains(t, err, "bogus")
+}
+
+func TestLogger_Logfmt(t *testing.T) {
+	l, buf := newTestLogger(t, config.AccessLog{
+		Format:         config.AccessLogFormatLogfmt,
+		Fields:         []string{"method", "path", "status", "user_agent", "headers"},
+		IncludeHeaders: true,
+		Redact:         []string{"Authorization"},
+	})
+	l.Log(testRecord())
+	assert.Equal(t, `method=POST path=/login status=200 user_agent=curl/8.4.0 header.Accept=*/* header.Authorization=[REDACTED]`+"\n", buf.String())
+}
+
+func TestLogger_CommonAndCombined(t *testing.T) {
+	l, buf := newTestLogger(t, config.AccessLog{Format: config.AccessLogFormatCommon})
+	l.Log(testRecord())
+	assert.Equal(t, `192.168.65.1 - - [06/Aug/2024:12:09:46 +0000] "POST /login?debug=1 HTTP/1.1" 200 128`+"\n", buf.String())
+
+	l, buf = newTestLogger(t, config.AccessLog{Format: config.AccessLogFormatCombined})
+	l.Log(testRecord())
+	assert.Equal(t, `192.168.65.1 - - [06/Aug/2024:12:09:46 +0000] "POST /login?debug=1 HTTP/1.1" 200 128 "https://example.com/" "curl/8.4.0"`+"\n", buf.String())
+
+	// Non-HTTP records use dashes for missing request line parts
+	l, buf = newTestLogger(t, config.AccessLog{Format: config.AccessLogFormatCommon})
+	l.Log(Record{Time: testRecord().Time, Listener: "TCP", Protocol: "TCP", RemoteAddr: "10.0.0.1:4000"})
+	assert.Equal(t, `10.0.0.1 - - [06/Aug/2024:12:09:46 +0000] "- - TCP" - -`+"\n", buf.String())
+}
+
+func TestLogger_Redaction(t *testing.T) {
+	l, buf := newTestLogger(t, config.AccessLog{
+		Format:         config.AccessLogFormatJSON,
+		Fields:         []string{"headers", "body"},
+		IncludeHeaders: true,
+		IncludeBody:    true,
+		MaxBodySize:    1024,
+		Redact:         []string{"authorization", "password"},
+	})
+	l.Log(testRecord())
+
+	var rec struct {
+		Headers map[string][]string `json:"headers"`
+		Body    string              `json:"body"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &rec))
+	assert.Equal(t, []string{redacted}, rec.Headers["Authorization"])
+	assert.Equal(t, []string{"*/*"}, rec.Headers["Accept"])
+	assert.JSONEq(t, `{"user":"alice","password":"[REDACTED]"}`, rec.Body)
+}
+
+func TestLogger_RedactionOfUnparsableBodies(t *testing.T) {
+	l, _ := newTestLogger(t, config.AccessLog{
+		IncludeBody: true,
+		MaxBodySize: 1024,
+		Redact:      []string{"password", "token"},
+	})
+
+	// Truncated JSON is redacted by pattern
+	assert.Equal(t, `{"user":"alice","password":"[REDACTED]"`, string(l.redactBody([]byte(`{"user":"alice","password":"hun`))))
+	// Form bodies are redacted per field
+	assert.Equal(t, `user=alice&token=[REDACTED]&x=1`, string(l.redactBody([]byte(`user=alice&token=abc&x=1`))))
+}
+
+func TestLogger_BodyTruncation(t *testing.T) {
+	l, _ := newTestLogger(t, config.AccessLog{IncludeBody: true, MaxBodySize: 4})
+	assert.Equal(t, "abcd", string(l.redactBody([]byte("abcdefgh"))))
+}
+
+func TestNilLogger(t *testing.T) {
+	var l *Logger
+	assert.False(t, l.IncludeHeaders())
+	assert.False(t, l.IncludeBody())
+	assert.Zero(t, l.MaxBodySize())
+	assert.NotPanics(t, func() { l.Log(testRecord()) })
+	assert.NoError(t, l.Close())
+}
+
+func TestDefaultLogger(t *testing.T) {
+	defer SetDefault(nil)
+
+	assert.Nil(t, Default())
+	l, buf := newTestLogger(t, config.AccessLog{Fields: []string{"listener"}})
+	SetDefault(l)
+	Default().Log(Record{Listener: "TCP"})
+	assert.Equal(t, `{"listener":"TCP"}`+"\n", buf.String())
+}
+
+func TestNew_FileOutputRotates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "access.log")
+	f, err := newRotatingFile(path, 64, 2)
+	require.NoError(t, err)
+	defer func() { _ = f.Close() }()
+
+	line := []byte(strings.Repeat("a", 39) + "\n") // 40 bytes
+	for i := 0; i < 5; i++ {
+		_, err := f.Write(line)
+		require.NoError(t, err)
+	}
+
+	// Each file holds a single 40 byte line, only two backups are kept
+	for _, name := range []string{path, path + ".1", path + ".2"} {
+		data, err := os.ReadFile(name)
+		require.NoError(t, err)
+		assert.Equal(t, line, data)
+	}
+	_, err = os.Stat(path + ".3")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestNew_FileOutput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "access.log")
+	l, err := New(config.AccessLog{
+		Format:      config.AccessLogFormatJSON,
+		Fields:      []string{"listener"},
+		Output:      config.AccessLogOutputFile,
+		File:        path,
+		FileMaxSize: 1,
+	})
+	require.NoError(t, err)
+
+	l.Log(Record{Listener: "gRPC"})
+	require.NoError(t, l.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, `{"listener":"gRPC"}`+"\n", string(data))
+}
+
+func TestNew_SyslogOutput(t *testing.T) {
+	// Unix socket paths are limited in length, so avoid the long t.TempDir()
+	dir, err := os.MkdirTemp("", "syslog")
+	require.NoError(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+	socket := filepath.Join(dir, "log.sock")
+
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		t.Skipf("unixgram sockets not supported: %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	l, err := New(config.AccessLog{
+		Format:       config.AccessLogFormatJSON,
+		Fields:       []string{"listener"},
+		Output:       config.AccessLogOutputSyslog,
+		SyslogSocket: socket,
+	})
+	require.NoError(t, err)
+	defer func() { _ = l.Close() }()
+
+	l.Log(Record{Listener: "QUIC"})
+
+	buf := make([]byte, 1024)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(2*time.Second)))
+	n, err := conn.Read(buf)
+	require.NoError(t, err)
+	msg := string(buf[:n])
+	assert.True(t, strings.HasPrefix(msg, "<30>"), msg)
+	assert.Contains(t, msg, "echo-app[")
+	assert.True(t, strings.HasSuffix(msg, `: {"listener":"QUIC"}`), msg)
+}
@@ -0,0 +1,83 @@
+package accesslog
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// syslogPriority is facility daemon (3) with severity informational (6)
+const syslogPriority = 3*8 + 6
+
+// syslogWriter sends each record as an RFC 3164 message to a local syslog
+// Unix socket. It is implemented here rather than with log/syslog so the
+// binary still builds for Windows.
+type syslogWriter struct {
+	mu     sync.Mutex
+	socket string
+	tag    string
+	conn   net.Conn
+}
+
+// newSyslogWriter connects to the syslog daemon listening on socket
+func newSyslogWriter(socket, tag string) (*syslogWriter, error) {
+	w := &syslogWriter{socket: socket, tag: tag}
+	if err := w.connect(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// connect dials the socket, preferring datagrams like most syslog daemons
+func (w *syslogWriter) connect() error {
+	var lastErr error
+	for _, network := range []string{"unixgram", "unix"} {
+		conn, err := net.Dial(network, w.socket)
+		if err == nil {
+			w.conn = conn
+			return nil
+		}
+		lastErr = err
+	}
+	return fmt.Errorf("failed to connect to syslog socket %s: %w", w.socket, lastErr)
+}
+
+// Write sends p as a single syslog message, reconnecting once on failure
+func (w *syslogWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	msg := fmt.Sprintf("<%d>%s %s[%d]: %s", syslogPriority, time.Now().Format(time.Stamp), w.tag, os.Getpid(), bytes.TrimRight(p, "\n"))
+
+	if w.conn != nil {
+		if _, err := w.conn.Write([]byte(msg)); err == nil {
+			return len(p), nil
+		}
+		_ = w.conn.Close()
+		w.conn = nil
+	}
+
+	if err := w.connect(); err != nil {
+		return 0, err
+	}
+	if _, err := w.conn.Write([]byte(msg)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close closes the syslog connection
+func (w *syslogWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
@@ -0,0 +1,199 @@
+// Package client probes a running echo-app over each of its protocols and
+// validates the responses against the server's response schemas
+package client
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"sort"
+	"time"
+)
+
+// Protocols probed by the client
+const (
+	ProtocolHTTP = "http"
+	ProtocolH2C  = "h2c"
+	ProtocolTLS  = "tls"
+	ProtocolQUIC = "quic"
+	ProtocolTCP  = "tcp"
+	ProtocolGRPC = "grpc"
+)
+
+// Protocols lists all supported protocols in probing order
+var Protocols = []string{ProtocolHTTP, ProtocolH2C, ProtocolTLS, ProtocolQUIC, ProtocolTCP, ProtocolGRPC}
+
+// maxErrors limits how many distinct errors a probe reports
+const maxErrors = 5
+
+// Options configures a client run
+type Options struct {
+	Host               string
+	Ports              map[string]string // Port per protocol
+	Protocols          []string
+	Requests           int // Requests per protocol, each on a new connection
+	Timeout            time.Duration
+	InsecureSkipVerify bool // echo-app serves a self-signed certificate
+	GRPCTLS            bool // The gRPC listener serves TLS, as with --grpc-tls
+}
+
+// Probe summarises the requests made over one protocol
+type Probe struct {
+	Protocol  string         `json:"protocol"`
+	Target    string         `json:"target"`
+	Requests  int            `json:"requests"`
+	Failures  int            `json:"failures"`
+	Latency   *Latency       `json:"latency,omitempty"`
+	Hostnames map[string]int `json:"hostnames,omitempty"`
+	Errors    []string       `json:"errors,omitempty"`
+}
+
+// OK reports whether every request succeeded
+func (p Probe) OK() bool {
+	return p.Failures == 0
+}
+
+// Latency is the distribution of successful request latencies
+type Latency struct {
+	MinMs float64 `json:"min_ms"`
+	AvgMs float64 `json:"avg_ms"`
+	P50Ms float64 `json:"p50_ms"`
+	P95Ms float64 `json:"p95_ms"`
+	MaxMs float64 `json:"max_ms"`
+}
+
+// Report is the outcome of a client run
+type Report struct {
+	Probes []Probe `json:"probes"`
+}
+
+// OK reports whether every probe succeeded
+func (r Report) OK() bool {
+	for _, probe := range r.Probes {
+		if !probe.OK() {
+			return false
+		}
+	}
+	return true
+}
+
+// requestFunc performs one request against target and returns the hostname
+// of the responding instance
+type requestFunc func(ctx context.Context, target string, opts Options) (string, error)
+
+var requests = map[string]requestFunc{
+	ProtocolHTTP: requestHTTP,
+	ProtocolH2C:  requestH2C,
+	ProtocolTLS:  requestTLS,
+	ProtocolQUIC: requestQUIC,
+	ProtocolTCP:  requestTCP,
+	ProtocolGRPC: requestGRPC,
+}
+
+// Validate checks the options before a run
+func (o Options) Validate() error {
+	if o.Host == "" {
+		return fmt.Errorf("missing target host")
+	}
+	if len(o.Protocols) == 0 {
+		return fmt.Errorf("no protocols selected")
+	}
+	for _, protocol := range o.Protocols {
+		if _, ok := requests[protocol]; !ok {
+			return fmt.Errorf("unsupported protocol %q: must be one of %v", protocol, Protocols)
+		}
+		if o.Ports[protocol] == "" {
+			return fmt.Errorf("missing port for protocol %s", protocol)
+		}
+	}
+	if o.Requests <= 0 {
+		return fmt.Errorf("requests must be greater than zero")
+	}
+	if o.Timeout <= 0 {
+		return fmt.Errorf("timeout must be greater than zero")
+	}
+	return nil
+}
+
+// Run probes each selected protocol in turn
+func Run(ctx context.Context, opts Options) Report {
+	var report Report
+	for _, protocol := range opts.Protocols {
+		report.Probes = append(report.Probes, runProbe(ctx, protocol, opts))
+	}
+	return report
+}
+
+func runProbe(ctx context.Context, protocol string, opts Options) Probe {
+	probe := Probe{
+		Protocol:  protocol,
+		Target:    net.JoinHostPort(opts.Host, opts.Ports[protocol]),
+		Requests:  opts.Requests,
+		Hostnames: make(map[string]int),
+	}
+	request := requests[protocol]
+
+	var latencies []time.Duration
+	for i := 0; i < opts.Requests; i++ {
+		reqCtx, cancel := context.WithTimeout(ctx, opts.Timeout)
+		start := time.Now()
+		hostname, err := request(reqCtx, probe.Target, opts)
+		elapsed := time.Since(start)
+		cancel()
+
+		if err != nil {
+			probe.Failures++
+			probe.addError(err)
+			continue
+		}
+		latencies = append(latencies, elapsed)
+		probe.Hostnames[hostname]++
+	}
+	probe.Latency = summarise(latencies)
+	return probe
+}
+
+// addError records err unless it was seen before or the limit is reached
+func (p *Probe) addError(err error) {
+	message := err.Error()
+	for _, seen := range p.Errors {
+		if seen == message {
+			return
+		}
+	}
+	if len(p.Errors) < maxErrors {
+		p.Errors = append(p.Errors, message)
+	}
+}
+
+// summarise returns the latency distribution, or nil without samples
+func summarise(latencies []time.Duration) *Latency {
+	if len(latencies) == 0 {
+		return nil
+	}
+	sorted := append([]time.Duration(nil), latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var total time.Duration
+	for _, latency := range sorted {
+		total += latency
+	}
+	return &Latency{
+		MinMs: milliseconds(sorted[0]),
+		AvgMs: milliseconds(total / time.Duration(len(sorted))),
+		P50Ms: milliseconds(percentile(sorted, 0.50)),
+		P95Ms: milliseconds(percentile(sorted, 0.95)),
+		MaxMs: milliseconds(sorted[len(sorted)-1]),
+	}
+}
+
+// percentile returns the nearest-rank percentile p of sorted
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	return sorted[max(rank, 0)]
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
@@ -0,0 +1,281 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/PhilipSchmid/echo-app/echoapp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// port returns the port of a listener address
+func port(t *testing.T, addr string) string {
+	t.Helper()
+	_, p, err := net.SplitHostPort(addr)
+	require.NoError(t, err)
+	return p
+}
+
+// startEchoApp serves every protocol on random loopback ports and returns
+// the port per protocol
+func startEchoApp(t *testing.T) map[string]string {
+	t.Helper()
+	app := echoapp.NewTest(t, echoapp.Options{Listeners: []echoapp.Listener{
+		{Protocol: echoapp.ProtocolHTTP, H2C: true},
+		{Protocol: echoapp.ProtocolTLS},
+		{Protocol: echoapp.ProtocolQUIC},
+		{Protocol: echoapp.ProtocolTCP},
+		{Protocol: echoapp.ProtocolGRPC},
+	}})
+	addrs := app.Addrs()
+	ports := map[string]string{
+		ProtocolHTTP: port(t, addrs[echoapp.ProtocolHTTP]),
+		ProtocolTLS:  port(t, addrs[echoapp.ProtocolTLS]),
+		ProtocolQUIC: port(t, addrs[echoapp.ProtocolQUIC]),
+		ProtocolTCP:  port(t, addrs[echoapp.ProtocolTCP]),
+		ProtocolGRPC: port(t, addrs[echoapp.ProtocolGRPC]),
+	}
+	// HTTP/1.1 and h2c share one listener, as with --h2c
+	ports[ProtocolH2C] = ports[ProtocolHTTP]
+	return ports
+}
+
+func TestRun_AllProtocols(t *testing.T) {
+	report := Run(context.Background(), Options{
+		Host:               "127.0.0.1",
+		Ports:              startEchoApp(t),
+		Protocols:          Protocols,
+		Requests:           3,
+		Timeout:            5 * time.Second,
+		InsecureSkipVerify: true,
+	})
+
+	require.Len(t, report.Probes, len(Protocols))
+	for i, probe := range report.Probes {
+		assert.Equal(t, Protocols[i], probe.Protocol)
+		assert.True(t, probe.OK(), "%s: %v", probe.Protocol, probe.Errors)
+		assert.Equal(t, 3, probe.Requests)
+		require.NotNil(t, probe.Latency, probe.Protocol)
+		assert.LessOrEqual(t, probe.Latency.MinMs, probe.Latency.MaxMs)
+		total := 0
+		for _, count := range probe.Hostnames {
+			total += count
+		}
+		assert.Equal(t, 3, total, probe.Protocol)
+	}
+	assert.True(t, report.OK())
+}
+
+func TestRun_Failures(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	closed := port(t, lis.Addr().String())
+	require.NoError(t, lis.Close())
+
+	report := Run(context.Background(), Options{
+		Host:      "127.0.0.1",
+		Ports:     map[string]string{ProtocolHTTP: closed},
+		Protocols: []string{ProtocolHTTP},
+		Requests:  3,
+		Timeout:   time.Second,
+	})
+
+	require.Len(t, report.Probes, 1)
+	probe := report.Probes[0]
+	assert.Equal(t, 3, probe.Failures)
+	assert.Nil(t, probe.Latency)
+	assert.Empty(t, probe.Hostnames)
+	// Repeated errors are reported once
+	require.Len(t, probe.Errors, 1)
+	assert.Contains(t, probe.Errors[0], "connection refused")
+	assert.False(t, report.OK())
+}
+
+func TestRun_TLSVerification(t *testing.T) {
+	ports := startEchoApp(t)
+	report := Run(context.Background(), Options{
+		Host:      "127.0.0.1",
+		Ports:     ports,
+		Protocols: []string{ProtocolTLS},
+		Requests:  1,
+		Timeout:   time.Second,
+	})
+	require.Len(t, report.Probes[0].Errors, 1)
+	assert.Contains(t, report.Probes[0].Errors[0], "certificate")
+}
+
+func TestRun_GRPCTLS(t *testing.T) {
+	app := echoapp.NewTest(t, echoapp.Options{Listeners: []echoapp.Listener{{Protocol: echoapp.ProtocolGRPC, TLS: true}}})
+	grpcPort := port(t, app.Addrs()[echoapp.ProtocolGRPC])
+
+	tests := []struct {
+		name      string
+		grpcTLS   bool
+		insecure  bool
+		wantError string
+	}{
+		{name: "TLS", grpcTLS: true, insecure: true},
+		{name: "TLS with verification", grpcTLS: true, wantError: "certificate"},
+		{name: "plaintext", insecure: true, wantError: "Unavailable"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := Run(context.Background(), Options{
+				Host:               "127.0.0.1",
+				Ports:              map[string]string{ProtocolGRPC: grpcPort},
+				Protocols:          []string{ProtocolGRPC},
+				Requests:           1,
+				Timeout:            time.Second,
+				InsecureSkipVerify: tt.insecure,
+				GRPCTLS:            tt.grpcTLS,
+			})
+			probe := report.Probes[0]
+			if tt.wantError == "" {
+				assert.True(t, probe.OK(), probe.Errors)
+				return
+			}
+			require.Len(t, probe.Errors, 1)
+			assert.Contains(t, probe.Errors[0], tt.wantError)
+		})
+	}
+}
+
+func TestGetHTTP_SchemaValidation(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        int
+		body          string
+		expectedError string
+	}{
+		{
+			name:          "error status",
+			status:        http.StatusServiceUnavailable,
+			expectedError: "unexpected status 503 Service Unavailable",
+		},
+		{
+			name:          "not JSON",
+			status:        http.StatusOK,
+			body:          "hello",
+			expectedError: "invalid JSON response",
+		},
+		{
+			name:          "invalid timestamp",
+			status:        http.StatusOK,
+			body:          `{"timestamp":"yesterday","hostname":"a","listener":"HTTP","source_ip":"127.0.0.1"}`,
+			expectedError: `invalid timestamp "yesterday"`,
+		},
+		{
+			name:          "missing hostname",
+			status:        http.StatusOK,
+			body:          `{"timestamp":"2024-08-06T12:09:46+02:00","listener":"HTTP","source_ip":"127.0.0.1"}`,
+			expectedError: "missing hostname",
+		},
+		{
+			name:          "wrong listener",
+			status:        http.StatusOK,
+			body:          `{"timestamp":"2024-08-06T12:09:46+02:00","hostname":"a","listener":"TLS","source_ip":"127.0.0.1"}`,
+			expectedError: `expected listener HTTP, got "TLS"`,
+		},
+		{
+			name:          "wrong HTTP version",
+			status:        http.StatusOK,
+			body:          `{"timestamp":"2024-08-06T12:09:46+02:00","hostname":"a","listener":"HTTP","source_ip":"127.0.0.1","http_version":"HTTP/2.0"}`,
+			expectedError: `expected http_version HTTP/1.1, got "HTTP/2.0"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			_, err := requestHTTP(context.Background(), srv.Listener.Addr().String(), Options{})
+			assert.ErrorContains(t, err, tt.expectedError)
+		})
+	}
+}
+
+func TestOptions_Validate(t *testing.T) {
+	valid := func() Options {
+		return Options{
+			Host:      "localhost",
+			Ports:     map[string]string{ProtocolHTTP: "8080"},
+			Protocols: []string{ProtocolHTTP},
+			Requests:  1,
+			Timeout:   time.Second,
+		}
+	}
+	tests := []struct {
+		name          string
+		modify        func(*Options)
+		expectedError string
+	}{
+		{name: "valid", modify: func(*Options) {}},
+		{name: "missing host", modify: func(o *Options) { o.Host = "" }, expectedError: "missing target host"},
+		{name: "no protocols", modify: func(o *Options) { o.Protocols = nil }, expectedError: "no protocols selected"},
+		{name: "unknown protocol", modify: func(o *Options) { o.Protocols = []string{"smtp"} }, expectedError: `unsupported protocol "smtp"`},
+		{name: "missing port", modify: func(o *Options) { o.Protocols = []string{ProtocolTCP} }, expectedError: "missing port for protocol tcp"},
+		{name: "zero requests", modify: func(o *Options) { o.Requests = 0 }, expectedError: "requests must be greater than zero"},
+		{name: "zero timeout", modify: func(o *Options) { o.Timeout = 0 }, expectedError: "timeout must be greater than zero"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := valid()
+			tt.modify(&opts)
+			err := opts.Validate()
+			if tt.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.expectedError)
+			}
+		})
+	}
+}
+
+func TestSummarise(t *testing.T) {
+	assert.Nil(t, summarise(nil))
+
+	var latencies []time.Duration
+	for i := 20; i >= 1; i-- {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+	assert.Equal(t, &Latency{MinMs: 1, AvgMs: 10.5, P50Ms: 10, P95Ms: 19, MaxMs: 20}, summarise(latencies))
+}
+
+func TestReport_WriteText(t *testing.T) {
+	report := Report{Probes: []Probe{
+		{
+			Protocol:  ProtocolHTTP,
+			Target:    "svc:8080",
+			Requests:  4,
+			Latency:   &Latency{MinMs: 1, AvgMs: 2, P50Ms: 2, P95Ms: 3, MaxMs: 3},
+			Hostnames: map[string]int{"pod-b": 1, "pod-a": 3},
+		},
+		{
+			Protocol: ProtocolTCP,
+			Target:   "svc:9090",
+			Requests: 1,
+			Failures: 1,
+			Errors:   []string{"connection refused"},
+		},
+	}}
+
+	var buf bytes.Buffer
+	require.NoError(t, report.WriteText(&buf))
+	out := buf.String()
+	assert.Contains(t, out, "PROTOCOL  TARGET    RESULT  OK  FAILED  MIN     AVG     P50     P95     MAX")
+	assert.Contains(t, out, "http      svc:8080  PASS    4   0       1.00ms  2.00ms  2.00ms  3.00ms  3.00ms")
+	assert.Contains(t, out, "tcp       svc:9090  FAIL    0   1       -       -       -       -       -")
+	assert.Contains(t, out, "http hostnames: pod-a 3 (75%), pod-b 1 (25%)")
+	assert.Contains(t, out, "tcp error: connection refused")
+}
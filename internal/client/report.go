@@ -0,0 +1,81 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// WriteText prints a table with one row per protocol, followed by the
+// responding hostnames and errors of each probe
+func (r Report) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "PROTOCOL\tTARGET\tRESULT\tOK\tFAILED\tMIN\tAVG\tP50\tP95\tMAX")
+	for _, probe := range r.Probes {
+		result := "PASS"
+		if !probe.OK() {
+			result = "FAIL"
+		}
+		latency := []string{"-", "-", "-", "-", "-"}
+		if l := probe.Latency; l != nil {
+			latency = []string{formatMs(l.MinMs), formatMs(l.AvgMs), formatMs(l.P50Ms), formatMs(l.P95Ms), formatMs(l.MaxMs)}
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%d\t%s\n", probe.Protocol, probe.Target, result,
+			probe.Requests-probe.Failures, probe.Failures, strings.Join(latency, "\t"))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	for _, probe := range r.Probes {
+		if len(probe.Hostnames) > 0 {
+			if _, err := fmt.Fprintf(w, "\n%s hostnames: %s", probe.Protocol, formatHostnames(probe.Hostnames)); err != nil {
+				return err
+			}
+		}
+		for _, message := range probe.Errors {
+			if _, err := fmt.Fprintf(w, "\n%s error: %s", probe.Protocol, message); err != nil {
+				return err
+			}
+		}
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
+
+// WriteJSON prints the report as indented JSON
+func (r Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// formatHostnames lists hostnames by response count, most frequent first
+func formatHostnames(hostnames map[string]int) string {
+	names := make([]string, 0, len(hostnames))
+	total := 0
+	for name, count := range hostnames {
+		names = append(names, name)
+		total += count
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if hostnames[names[i]] != hostnames[names[j]] {
+			return hostnames[names[i]] > hostnames[names[j]]
+		}
+		return names[i] < names[j]
+	})
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		count := hostnames[name]
+		parts = append(parts, fmt.Sprintf("%s %d (%.0f%%)", name, count, 100*float64(count)/float64(total)))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func formatMs(ms float64) string {
+	return fmt.Sprintf("%.2fms", ms)
+}
@@ -0,0 +1,152 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/PhilipSchmid/echo-app/internal/handlers"
+	"github.com/PhilipSchmid/echo-app/proto"
+	"github.com/quic-go/quic-go/http3"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// Each request uses a fresh transport, and thus a new connection, so load
+// balancers spread requests across instances
+
+func requestHTTP(ctx context.Context, target string, _ Options) (string, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	defer transport.CloseIdleConnections()
+	return getHTTP(ctx, transport, "http://"+target, "HTTP", "HTTP/1.1")
+}
+
+func requestH2C(ctx context.Context, target string, _ Options) (string, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Protocols = new(http.Protocols)
+	transport.Protocols.SetUnencryptedHTTP2(true)
+	defer transport.CloseIdleConnections()
+	return getHTTP(ctx, transport, "http://"+target, "H2C", "HTTP/2.0")
+}
+
+func requestTLS(ctx context.Context, target string, opts Options) (string, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: opts.InsecureSkipVerify} // #nosec G402 -- echo-app serves a self-signed certificate
+	defer transport.CloseIdleConnections()
+	return getHTTP(ctx, transport, "https://"+target, "TLS", "HTTP/2.0")
+}
+
+func requestQUIC(ctx context.Context, target string, opts Options) (string, error) {
+	transport := &http3.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: opts.InsecureSkipVerify}, // #nosec G402 -- echo-app serves a self-signed certificate
+	}
+	defer func() { _ = transport.Close() }()
+	return getHTTP(ctx, transport, "https://"+target, "QUIC", "HTTP/3.0")
+}
+
+// getHTTP requests the JSON echo response and validates it against
+// handlers.HTTPResponse
+func getHTTP(ctx context.Context, transport http.RoundTripper, url, listener, proto string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url+"/", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	var response handlers.HTTPResponse
+	if err := handlers.DecodeResponse(resp.Body, &response); err != nil {
+		return "", err
+	}
+	if err := validateBase(response.BaseResponse, listener); err != nil {
+		return "", err
+	}
+	if response.HTTPVersion != proto {
+		return "", fmt.Errorf("expected http_version %s, got %q", proto, response.HTTPVersion)
+	}
+	if response.HTTPMethod != http.MethodGet || response.HTTPEndpoint != "/" {
+		return "", fmt.Errorf("expected GET /, got %s %s", response.HTTPMethod, response.HTTPEndpoint)
+	}
+	return response.Hostname, nil
+}
+
+// requestTCP reads the response the TCP listener writes on connect and
+// validates it against handlers.TCPResponse
+func requestTCP(ctx context.Context, target string, _ Options) (string, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", target)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = conn.Close() }()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	var response handlers.TCPResponse
+	if err := handlers.DecodeResponse(conn, &response); err != nil {
+		return "", fmt.Errorf("%w (is the TCP listener using --tcp-format json?)", err)
+	}
+	if err := validateBase(response.BaseResponse, "TCP"); err != nil {
+		return "", err
+	}
+	return response.Hostname, nil
+}
+
+// requestGRPC calls EchoService/Echo and validates the EchoResponse
+func requestGRPC(ctx context.Context, target string, opts Options) (string, error) {
+	creds := insecure.NewCredentials()
+	if opts.GRPCTLS {
+		creds = credentials.NewTLS(&tls.Config{InsecureSkipVerify: opts.InsecureSkipVerify}) // #nosec G402 -- echo-app serves a self-signed certificate
+	}
+	conn, err := grpc.NewClient(target, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = conn.Close() }()
+
+	response, err := proto.NewEchoServiceClient(conn).Echo(ctx, &proto.EchoRequest{})
+	if err != nil {
+		return "", err
+	}
+	if err := validateBase(handlers.BaseResponse{
+		Timestamp: response.GetTimestamp(),
+		Hostname:  response.GetHostname(),
+		Listener:  response.GetListener(),
+		SourceIP:  response.GetSourceIp(),
+	}, "gRPC"); err != nil {
+		return "", err
+	}
+	if response.GetGrpcMethod() != proto.EchoService_Echo_FullMethodName {
+		return "", fmt.Errorf("expected grpc_method %s, got %q", proto.EchoService_Echo_FullMethodName, response.GetGrpcMethod())
+	}
+	return response.GetHostname(), nil
+}
+
+// validateBase checks the fields every echo response carries
+func validateBase(base handlers.BaseResponse, listener string) error {
+	if _, err := time.Parse(time.RFC3339, base.Timestamp); err != nil {
+		return fmt.Errorf("invalid timestamp %q", base.Timestamp)
+	}
+	if base.Hostname == "" {
+		return fmt.Errorf("missing hostname")
+	}
+	if base.SourceIP == "" {
+		return fmt.Errorf("missing source_ip")
+	}
+	if base.Listener != listener {
+		return fmt.Errorf("expected listener %s, got %q", listener, base.Listener)
+	}
+	return nil
+}
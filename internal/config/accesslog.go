@@ -0,0 +1,79 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Access log formats
+const (
+	AccessLogFormatJSON     = "json"
+	AccessLogFormatLogfmt   = "logfmt"
+	AccessLogFormatCommon   = "common"
+	AccessLogFormatCombined = "combined"
+)
+
+// Access log outputs
+const (
+	AccessLogOutputStdout = "stdout"
+	AccessLogOutputStderr = "stderr"
+	AccessLogOutputFile   = "file"
+	AccessLogOutputSyslog = "syslog"
+)
+
+// AccessLog configures the structured per-request access log.
+type AccessLog struct {
+	Enabled        bool
+	Format         string
+	Fields         []string // Fields to include in json/logfmt records; empty means the default set
+	IncludeHeaders bool
+	IncludeBody    bool
+	MaxBodySize    int64    // Maximum number of body bytes to log
+	Redact         []string // Header names and JSON body keys whose values are masked
+	Output         string
+	File           string
+	FileMaxSize    int64 // Rotate the log file once it exceeds this many megabytes
+	FileMaxBackups int   // Number of rotated files to keep
+	SyslogSocket   string
+}
+
+// validate checks the access log settings for consistency
+func (a AccessLog) validate() error {
+	if !a.Enabled {
+		return nil
+	}
+	var errs []error
+	switch a.Format {
+	case AccessLogFormatJSON, AccessLogFormatLogfmt, AccessLogFormatCommon, AccessLogFormatCombined:
+	default:
+		errs = append(errs, fmt.Errorf("invalid access log format: %s", a.Format))
+	}
+	switch a.Output {
+	case AccessLogOutputStdout, AccessLogOutputStderr, AccessLogOutputSyslog:
+	case AccessLogOutputFile:
+		if a.File == "" {
+			errs = append(errs, fmt.Errorf("access log file path is required when output is %q", AccessLogOutputFile))
+		}
+		if a.FileMaxSize < 0 || a.FileMaxBackups < 0 {
+			errs = append(errs, fmt.Errorf("access log file rotation settings must not be negative"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("invalid access log output: %s", a.Output))
+	}
+	if a.IncludeBody && a.MaxBodySize <= 0 {
+		errs = append(errs, fmt.Errorf("access log max body size must be greater than zero"))
+	}
+	return errors.Join(errs...)
+}
+
+// splitList splits a comma-separated setting into trimmed, non-empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,123 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/PhilipSchmid/echo-app/internal/utils"
+)
+
+// ListenAddress returns address, or all interfaces on port if it is empty
+func ListenAddress(address, port string) string {
+	if address == "" {
+		return ":" + port
+	}
+	return address
+}
+
+// resolveAddress defaults an empty address to all interfaces on port, and
+// otherwise points port at the address's port so settings such as Alt-Svc
+// find it. Unix sockets have no port.
+func resolveAddress(address, port *string) {
+	if *address == "" {
+		*address = ListenAddress("", *port)
+		return
+	}
+	network, addr, err := utils.ParseAddress(*address)
+	if err != nil {
+		return // Reported by Validate
+	}
+	if network == "unix" {
+		*port = ""
+		return
+	}
+	_, *port, _ = net.SplitHostPort(addr)
+}
+
+// resolveAddresses resolves the per-protocol listen addresses and ports
+func (c *Config) resolveAddresses() {
+	resolveAddress(&c.HTTPAddress, &c.HTTPPort)
+	resolveAddress(&c.TLSAddress, &c.TLSPort)
+	resolveAddress(&c.TCPAddress, &c.TCPPort)
+	resolveAddress(&c.GRPCAddress, &c.GRPCPort)
+	resolveAddress(&c.QUICAddress, &c.QUICPort)
+	resolveAddress(&c.MetricsAddress, &c.MetricsPort)
+}
+
+// parseSocketMode parses octal Unix socket permissions such as 0660. An
+// empty mode keeps the default permissions.
+func parseSocketMode(mode string) (os.FileMode, error) {
+	if mode == "" {
+		return 0, nil
+	}
+	m, err := strconv.ParseUint(mode, 8, 32)
+	if err != nil || m > 0o777 {
+		return 0, fmt.Errorf("invalid socket mode %q: must be octal permissions such as 0660", mode)
+	}
+	return os.FileMode(m), nil
+}
+
+// binding is the socket a listener binds, used to find collisions
+type binding struct {
+	name      string
+	transport string // tcp, udp or unix
+	host      string // Socket path for unix
+	port      string
+}
+
+// newBinding returns the binding of address over transport, or false if the
+// address is invalid
+func newBinding(name, transport, address string) (binding, bool) {
+	network, addr, err := utils.ParseAddress(address)
+	if err != nil {
+		return binding{}, false
+	}
+	if network == "unix" {
+		return binding{name: name, transport: network, host: addr}, true
+	}
+	host, port, _ := net.SplitHostPort(addr)
+	return binding{name: name, transport: transport, host: host, port: port}, true
+}
+
+// collides reports whether a and b cannot be bound at the same time.
+// Ephemeral ports never collide, as each bind picks a different free port.
+func (b binding) collides(other binding) bool {
+	if b.transport != other.transport {
+		return false
+	}
+	if b.transport == "unix" {
+		return b.host == other.host
+	}
+	return b.port == other.port && b.port != utils.EphemeralPort && hostsOverlap(b.host, other.host)
+}
+
+// String describes the bound socket in errors
+func (b binding) String() string {
+	if b.transport == "unix" {
+		return "socket " + b.host
+	}
+	return "port " + b.port + "/" + b.transport
+}
+
+// hostsOverlap reports whether listen hosts a and b share addresses. An empty
+// host and :: bind every address, 0.0.0.0 every IPv4 address.
+func hostsOverlap(a, b string) bool {
+	if strings.EqualFold(a, b) || a == "" || b == "" || a == "::" || b == "::" {
+		return true
+	}
+	return coversIPv4(a, b) || coversIPv4(b, a)
+}
+
+// coversIPv4 reports whether wildcard is 0.0.0.0 and host an IPv4 address or
+// a hostname, which may resolve to one
+func coversIPv4(wildcard, host string) bool {
+	if wildcard != "0.0.0.0" {
+		return false
+	}
+	ip, err := netip.ParseAddr(host)
+	return err != nil || ip.Unmap().Is4()
+}
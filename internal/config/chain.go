@@ -0,0 +1,86 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// MaxChainTimeout caps per-call upstream timeouts below the HTTP listeners'
+// 30s write timeout so a hop can always report its upstream errors
+const MaxChainTimeout = 25 * time.Second
+
+// DefaultChainPropagateHeaders lists the tracing and mesh headers forwarded
+// to upstreams by default, matching what service meshes expect applications
+// to propagate. Credentials are left out so they never reach an upstream
+// unless configured.
+const DefaultChainPropagateHeaders = "X-B3-TraceId,X-B3-SpanId,X-B3-ParentSpanId,X-B3-Sampled,X-B3-Flags,B3,X-Ot-Span-Context,X-Cloud-Trace-Context,X-Amzn-Trace-Id"
+
+// chainSchemes are the upstream URL schemes /chain can call
+var chainSchemes = []string{"http", "https", "grpc", "grpcs", "tcp"}
+
+// Chain configures upstream calls made by the /chain endpoint.
+type Chain struct {
+	Enabled            bool
+	Upstreams          []string      // Upstreams called when a request names none
+	AllowedUpstreams   []string      // Further upstreams requests may name with ?upstream=
+	Timeout            time.Duration // Default per-call timeout
+	MaxUpstreams       int           // Maximum upstreams per request
+	MaxDepth           int           // Maximum number of chained hops
+	PropagateHeaders   []string      // Incoming headers forwarded to upstreams
+	InsecureSkipVerify bool          // Skip certificate verification for https and grpcs upstreams
+}
+
+// Allows reports whether a request may name target as upstream: its scheme
+// and host:port must match a configured or allowed upstream
+func (c Chain) Allows(target string) bool {
+	origin, err := upstreamOrigin(target)
+	if err != nil {
+		return false
+	}
+	return slices.ContainsFunc(slices.Concat(c.Upstreams, c.AllowedUpstreams), func(allowed string) bool {
+		allowedOrigin, err := upstreamOrigin(allowed)
+		return err == nil && allowedOrigin == origin
+	})
+}
+
+// upstreamOrigin returns the scheme and host:port of an upstream URL
+func upstreamOrigin(target string) (string, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return "", fmt.Errorf("invalid chain upstream %q: %w", target, err)
+	}
+	if !slices.Contains(chainSchemes, u.Scheme) {
+		return "", fmt.Errorf("invalid chain upstream %q: scheme must be one of %s", target, strings.Join(chainSchemes, ", "))
+	}
+	if u.Host == "" {
+		return "", fmt.Errorf("invalid chain upstream %q: missing host", target)
+	}
+	return u.Scheme + "://" + strings.ToLower(u.Host), nil
+}
+
+// validate checks the chain settings for consistency
+func (c Chain) validate() error {
+	var errs []error
+	for _, target := range slices.Concat(c.Upstreams, c.AllowedUpstreams) {
+		if _, err := upstreamOrigin(target); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if c.Timeout <= 0 || c.Timeout > MaxChainTimeout {
+		errs = append(errs, fmt.Errorf("chain timeout must be between 0 and %s", MaxChainTimeout))
+	}
+	if c.MaxUpstreams <= 0 {
+		errs = append(errs, fmt.Errorf("chain max upstreams must be greater than zero"))
+	}
+	if c.MaxDepth <= 0 {
+		errs = append(errs, fmt.Errorf("chain max depth must be greater than zero"))
+	}
+	if len(c.Upstreams) > c.MaxUpstreams {
+		errs = append(errs, fmt.Errorf("%d chain upstreams configured, at most %d allowed", len(c.Upstreams), c.MaxUpstreams))
+	}
+	return errors.Join(errs...)
+}
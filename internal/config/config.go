@@ -2,7 +2,11 @@ package config
 
 import (
 	"fmt"
+	"net/http"
+	"os"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
@@ -21,7 +25,9 @@ type Config struct {
 	H2C                    bool
 	TCP                    bool
 	GRPC                   bool
+	GRPCTLS                bool // Serve gRPC over TLS with the self-signed certificate
 	QUIC                   bool
+	WebTransport           bool
 	Metrics                bool
 	HTTPPort               string
 	TLSPort                string
@@ -29,94 +35,351 @@ type Config struct {
 	GRPCPort               string
 	QUICPort               string
 	MetricsPort            string
+	HTTPAddress            string        // Listen address, host:port or unix:///path (defaults to all interfaces on HTTPPort)
+	TLSAddress             string        // Listen address (defaults to all interfaces on TLSPort)
+	TCPAddress             string        // Listen address (defaults to all interfaces on TCPPort)
+	GRPCAddress            string        // Listen address (defaults to all interfaces on GRPCPort)
+	QUICAddress            string        // Listen address, host:port only (defaults to all interfaces on QUICPort)
+	MetricsAddress         string        // Listen address (defaults to all interfaces on MetricsPort)
+	SocketMode             os.FileMode   // Permissions of Unix sockets, or 0 to keep the default
+	PortsFile              string        // JSON file the bound addresses are written to, if set
+	ListenerFailurePolicy  string        // What to do when a listener fails after startup (exit, restart or degrade)
+	AdminToken             string        // Bearer token required to change listeners through the admin API, which is disabled without one
+	DrainDelay             time.Duration // How long to keep serving while not ready before shutting down
+	ShutdownTimeout        time.Duration // How long to wait for in-flight requests before closing connections
 	LogLevel               logrus.Level
-	MaxRequestSize         int64 // Maximum request body size in bytes
-	ExternalReadinessProbe ExternalReadinessProbe
+	LogFormat              string                 // Application log format (text or json)
+	TCPFormat              string                 // Response format for the TCP listener (json, pretty, yaml, text, html, msgpack)
+	MaxRequestSize         int64                  // Maximum request body size in bytes
+	RequestIDHeader        string                 // Header used to accept and return request IDs
+	AltSvc                 bool                   // Advertise the QUIC listener via Alt-Svc on TLS responses
+	AltSvcPort             string                 // Port advertised in Alt-Svc (defaults to QUICPort)
+	AltSvcMaxAge           time.Duration          // Lifetime clients may cache the Alt-Svc advertisement
+	ExternalReadinessProbe ExternalReadinessProbe // Probe configured by the flags
+	ExternalReadiness      ExternalReadiness      // Probes from the config file, or the flags' probe
+	AccessLog              AccessLog
+	Tracing                Tracing
+	Chain                  Chain
+	Diagnostics            Diagnostics
+	Listeners              []Listener // Echo listeners to start, from the config file or the per-protocol flags
+
+	live *atomic.Pointer[Config] // Latest reloaded settings, shared by every copy handed to a listener
+}
+
+// Current returns the latest settings applied by Update, or c itself if it
+// was never updated. Handlers call it once per request or connection so a
+// reload takes effect without restarting the listener.
+func (c *Config) Current() *Config {
+	if c.live == nil {
+		return c
+	}
+	if current := c.live.Load(); current != nil {
+		return current
+	}
+	return c
 }
 
+// Update atomically replaces the settings returned by Current. Only
+// configurations returned by ForListener can be updated.
+func (c *Config) Update(next *Config) {
+	if c.live == nil {
+		return
+	}
+	next.live = c.live
+	c.live.Store(next)
+}
+
+// Load reads the configuration from the flags bound to viper, ECHO_APP_
+// environment variables and the config file, and validates it. It leaves the
+// logging settings to ApplyLogging, so a configuration that is not applied
+// does not change them.
 func Load() (*Config, error) {
 	viper.SetEnvPrefix("ECHO_APP")
 	viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
 	viper.AutomaticEnv()
 
-	// Set default values
-	viper.SetDefault("message", "")
-	viper.SetDefault("node", "")
-	viper.SetDefault("print-http-request-headers", false)
-	viper.SetDefault("tls", false)
-	viper.SetDefault("h2c", false)
-	viper.SetDefault("tcp", false)
-	viper.SetDefault("grpc", false)
-	viper.SetDefault("quic", false)
-	viper.SetDefault("metrics", true)
-	viper.SetDefault("http-port", "8080")
-	viper.SetDefault("tls-port", "8443")
-	viper.SetDefault("tcp-port", "9090")
-	viper.SetDefault("grpc-port", "50051")
-	viper.SetDefault("quic-port", "4433")
-	viper.SetDefault("metrics-port", "3000")
-	viper.SetDefault("log-level", "info")
-	viper.SetDefault("max-request-size", 10485760) // 10 MB default
-	viper.SetDefault("external-readiness-probe-type", "none")
-	viper.SetDefault("external-readiness-probe-target", "")
-	viper.SetDefault("external-readiness-probe-interval", "10s")
-	viper.SetDefault("external-readiness-probe-timeout", "2s")
-	viper.SetDefault("external-readiness-http-method", "GET")
-	viper.SetDefault("external-readiness-http-expected-status", 200)
-
-	// Load configuration from viper
+	// Read the config file, whose settings flags and environment variables override
+	if path := viper.GetString("config"); path != "" {
+		viper.SetConfigFile(path)
+		if err := viper.ReadInConfig(); err != nil {
+			return nil, fmt.Errorf("failed to read config file: %w", err)
+		}
+	}
+
+	setDefaults(viper.GetViper())
+	cfg, errs := parse(viper.GetViper())
+
+	// Validate the settings
+	errs = append(errs, cfg.Validate())
+	if err := joinErrors(errs...); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Default returns the default configuration, ignoring flags, environment
+// variables and config files, for embedding the servers in other programs
+func Default() *Config {
+	v := viper.New()
+	setDefaults(v)
+	cfg, _ := parse(v)
+	return cfg
+}
+
+// setDefaults sets the default value of every setting on v
+func setDefaults(v *viper.Viper) {
+	v.SetDefault("message", "")
+	v.SetDefault("node", "")
+	v.SetDefault("print-http-request-headers", false)
+	v.SetDefault("tls", false)
+	v.SetDefault("h2c", false)
+	v.SetDefault("tcp", false)
+	v.SetDefault("grpc", false)
+	v.SetDefault("grpc-tls", false)
+	v.SetDefault("quic", false)
+	v.SetDefault("webtransport", false)
+	v.SetDefault("metrics", true)
+	v.SetDefault("http-port", "8080")
+	v.SetDefault("tls-port", "8443")
+	v.SetDefault("tcp-port", "9090")
+	v.SetDefault("grpc-port", "50051")
+	v.SetDefault("quic-port", "4433")
+	v.SetDefault("metrics-port", "3000")
+	v.SetDefault("http-address", "")
+	v.SetDefault("tls-address", "")
+	v.SetDefault("tcp-address", "")
+	v.SetDefault("grpc-address", "")
+	v.SetDefault("quic-address", "")
+	v.SetDefault("metrics-address", "")
+	v.SetDefault("socket-mode", "")
+	v.SetDefault("ports-file", "")
+	v.SetDefault("listener-failure-policy", FailurePolicyExit)
+	v.SetDefault("admin-token", "")
+	v.SetDefault("drain-delay", "0s")
+	v.SetDefault("shutdown-timeout", "30s")
+	v.SetDefault("log-level", "info")
+	v.SetDefault("log-format", "text")
+	v.SetDefault("max-request-size", 10485760) // 10 MB default
+	v.SetDefault("tcp-format", "json")
+	v.SetDefault("request-id-header", "X-Request-ID")
+	v.SetDefault("alt-svc", true)
+	v.SetDefault("alt-svc-port", "")
+	v.SetDefault("alt-svc-max-age", "24h")
+	v.SetDefault("external-readiness-probe-type", "none")
+	v.SetDefault("external-readiness-probe-target", "")
+	v.SetDefault("external-readiness-probe-interval", "10s")
+	v.SetDefault("external-readiness-probe-timeout", "2s")
+	v.SetDefault("external-readiness-probe-success-threshold", 1)
+	v.SetDefault("external-readiness-probe-failure-threshold", 3)
+	v.SetDefault("external-readiness-probe-max-interval", "0s")
+	v.SetDefault("external-readiness-http-method", "GET")
+	v.SetDefault("external-readiness-http-expected-status", 200)
+	v.SetDefault("external-readiness-http-headers", "")
+	v.SetDefault("external-readiness-http-body", "")
+	v.SetDefault("external-readiness-http-expected-statuses", "")
+	v.SetDefault("external-readiness-http-body-contains", "")
+	v.SetDefault("external-readiness-http-body-regex", "")
+	v.SetDefault("external-readiness-http-body-json-path", "")
+	v.SetDefault("external-readiness-http-body-json-value", "")
+	v.SetDefault("external-readiness-http-redirect-policy", HTTPRedirectFollow)
+	v.SetDefault("external-readiness-grpc-service", "")
+	v.SetDefault("external-readiness-grpc-tls", false)
+	v.SetDefault("external-readiness-dns-record-type", "IP")
+	v.SetDefault("external-readiness-dns-server", "")
+	v.SetDefault("external-readiness-dns-expected-records", "")
+	v.SetDefault("external-readiness-tls-server-name", "")
+	v.SetDefault("external-readiness-tls-min-validity", "0s")
+	v.SetDefault("external-readiness-tls-insecure-skip-verify", false)
+	v.SetDefault("external-readiness-tls-ca-file", "")
+	v.SetDefault("external-readiness-tls-cert-file", "")
+	v.SetDefault("external-readiness-tls-key-file", "")
+	v.SetDefault("external-readiness-policy", ReadinessPolicyAll)
+	v.SetDefault("external-readiness-quorum", 0)
+	v.SetDefault("access-log", false)
+	v.SetDefault("access-log-format", AccessLogFormatJSON)
+	v.SetDefault("access-log-fields", "")
+	v.SetDefault("access-log-include-headers", false)
+	v.SetDefault("access-log-include-body", false)
+	v.SetDefault("access-log-max-body-size", 4096)
+	v.SetDefault("access-log-redact", "Authorization,Proxy-Authorization,Cookie,Set-Cookie,X-Api-Key,password,token,secret")
+	v.SetDefault("access-log-output", AccessLogOutputStdout)
+	v.SetDefault("access-log-file", "")
+	v.SetDefault("access-log-file-max-size", 100)
+	v.SetDefault("access-log-file-max-backups", 3)
+	v.SetDefault("access-log-syslog-socket", "/dev/log")
+	v.SetDefault("tracing", false)
+	v.SetDefault("tracing-exporter", TracingExporterOTLPGRPC)
+	v.SetDefault("tracing-endpoint", "")
+	v.SetDefault("tracing-insecure", true)
+	v.SetDefault("tracing-sample-ratio", 1.0)
+	v.SetDefault("tracing-service-name", "echo-app")
+	v.SetDefault("chain", false)
+	v.SetDefault("chain-upstreams", "")
+	v.SetDefault("chain-allowed-upstreams", "")
+	v.SetDefault("chain-timeout", "5s")
+	v.SetDefault("chain-max-upstreams", 10)
+	v.SetDefault("chain-max-depth", 10)
+	v.SetDefault("chain-propagate-headers", DefaultChainPropagateHeaders)
+	v.SetDefault("chain-insecure-skip-verify", false)
+	v.SetDefault("diagnostics", false)
+	v.SetDefault("diagnostics-token", "")
+	v.SetDefault("diagnostics-allowed-cidrs", DefaultDiagnosticsAllowedCIDRs)
+	v.SetDefault("diagnostics-timeout", "5s")
+}
+
+// parse builds the configuration from the settings in v, returning the
+// settings that failed to parse so every problem is reported at once
+func parse(v *viper.Viper) (*Config, []error) {
 	cfg := &Config{
-		Message:        viper.GetString("message"),
-		Node:           viper.GetString("node"),
-		PrintHeaders:   viper.GetBool("print-http-request-headers"),
-		TLS:            viper.GetBool("tls"),
-		H2C:            viper.GetBool("h2c"),
-		TCP:            viper.GetBool("tcp"),
-		GRPC:           viper.GetBool("grpc"),
-		QUIC:           viper.GetBool("quic"),
-		Metrics:        viper.GetBool("metrics"),
-		HTTPPort:       viper.GetString("http-port"),
-		TLSPort:        viper.GetString("tls-port"),
-		TCPPort:        viper.GetString("tcp-port"),
-		GRPCPort:       viper.GetString("grpc-port"),
-		QUICPort:       viper.GetString("quic-port"),
-		MetricsPort:    viper.GetString("metrics-port"),
-		MaxRequestSize: viper.GetInt64("max-request-size"),
+		Message:               v.GetString("message"),
+		Node:                  v.GetString("node"),
+		PrintHeaders:          v.GetBool("print-http-request-headers"),
+		TLS:                   v.GetBool("tls"),
+		H2C:                   v.GetBool("h2c"),
+		TCP:                   v.GetBool("tcp"),
+		GRPC:                  v.GetBool("grpc"),
+		GRPCTLS:               v.GetBool("grpc-tls"),
+		QUIC:                  v.GetBool("quic"),
+		WebTransport:          v.GetBool("webtransport"),
+		Metrics:               v.GetBool("metrics"),
+		HTTPPort:              v.GetString("http-port"),
+		TLSPort:               v.GetString("tls-port"),
+		TCPPort:               v.GetString("tcp-port"),
+		GRPCPort:              v.GetString("grpc-port"),
+		QUICPort:              v.GetString("quic-port"),
+		MetricsPort:           v.GetString("metrics-port"),
+		HTTPAddress:           v.GetString("http-address"),
+		TLSAddress:            v.GetString("tls-address"),
+		TCPAddress:            v.GetString("tcp-address"),
+		GRPCAddress:           v.GetString("grpc-address"),
+		QUICAddress:           v.GetString("quic-address"),
+		MetricsAddress:        v.GetString("metrics-address"),
+		PortsFile:             v.GetString("ports-file"),
+		ListenerFailurePolicy: strings.ToLower(v.GetString("listener-failure-policy")),
+		AdminToken:            v.GetString("admin-token"),
+		DrainDelay:            v.GetDuration("drain-delay"),
+		ShutdownTimeout:       v.GetDuration("shutdown-timeout"),
+		TCPFormat:             strings.ToLower(v.GetString("tcp-format")),
+		MaxRequestSize:        v.GetInt64("max-request-size"),
+		RequestIDHeader:       http.CanonicalHeaderKey(strings.TrimSpace(v.GetString("request-id-header"))),
+		AltSvc:                v.GetBool("alt-svc"),
+		AltSvcPort:            v.GetString("alt-svc-port"),
+		AltSvcMaxAge:          v.GetDuration("alt-svc-max-age"),
 		ExternalReadinessProbe: ExternalReadinessProbe{
-			Type:               strings.ToLower(viper.GetString("external-readiness-probe-type")),
-			Target:             viper.GetString("external-readiness-probe-target"),
-			Interval:           viper.GetDuration("external-readiness-probe-interval"),
-			Timeout:            viper.GetDuration("external-readiness-probe-timeout"),
-			HTTPMethod:         strings.ToUpper(viper.GetString("external-readiness-http-method")),
-			HTTPExpectedStatus: viper.GetInt("external-readiness-http-expected-status"),
+			Type:               strings.ToLower(v.GetString("external-readiness-probe-type")),
+			Target:             v.GetString("external-readiness-probe-target"),
+			Interval:           v.GetDuration("external-readiness-probe-interval"),
+			Timeout:            v.GetDuration("external-readiness-probe-timeout"),
+			SuccessThreshold:   v.GetInt("external-readiness-probe-success-threshold"),
+			FailureThreshold:   v.GetInt("external-readiness-probe-failure-threshold"),
+			MaxInterval:        v.GetDuration("external-readiness-probe-max-interval"),
+			HTTPMethod:         strings.ToUpper(v.GetString("external-readiness-http-method")),
+			HTTPExpectedStatus: v.GetInt("external-readiness-http-expected-status"),
+
+			HTTPBody:             v.GetString("external-readiness-http-body"),
+			HTTPExpectedStatuses: v.GetString("external-readiness-http-expected-statuses"),
+			HTTPBodyContains:     v.GetString("external-readiness-http-body-contains"),
+			HTTPBodyRegex:        v.GetString("external-readiness-http-body-regex"),
+			HTTPBodyJSONPath:     v.GetString("external-readiness-http-body-json-path"),
+			HTTPBodyJSONValue:    v.GetString("external-readiness-http-body-json-value"),
+			HTTPRedirectPolicy:   strings.ToLower(v.GetString("external-readiness-http-redirect-policy")),
+
+			GRPCService:           v.GetString("external-readiness-grpc-service"),
+			GRPCTLS:               v.GetBool("external-readiness-grpc-tls"),
+			DNSRecordType:         strings.ToUpper(v.GetString("external-readiness-dns-record-type")),
+			DNSServer:             v.GetString("external-readiness-dns-server"),
+			DNSExpectedRecords:    splitList(v.GetString("external-readiness-dns-expected-records")),
+			TLSServerName:         v.GetString("external-readiness-tls-server-name"),
+			TLSMinValidity:        v.GetDuration("external-readiness-tls-min-validity"),
+			TLSInsecureSkipVerify: v.GetBool("external-readiness-tls-insecure-skip-verify"),
+			TLSCAFile:             v.GetString("external-readiness-tls-ca-file"),
+			TLSCertFile:           v.GetString("external-readiness-tls-cert-file"),
+			TLSKeyFile:            v.GetString("external-readiness-tls-key-file"),
+		},
+		ExternalReadiness: ExternalReadiness{
+			Policy: strings.ToLower(v.GetString("external-readiness-policy")),
+			Quorum: v.GetInt("external-readiness-quorum"),
+		},
+		AccessLog: AccessLog{
+			Enabled:        v.GetBool("access-log"),
+			Format:         strings.ToLower(v.GetString("access-log-format")),
+			Fields:         splitList(v.GetString("access-log-fields")),
+			IncludeHeaders: v.GetBool("access-log-include-headers"),
+			IncludeBody:    v.GetBool("access-log-include-body"),
+			MaxBodySize:    v.GetInt64("access-log-max-body-size"),
+			Redact:         splitList(v.GetString("access-log-redact")),
+			Output:         strings.ToLower(v.GetString("access-log-output")),
+			File:           v.GetString("access-log-file"),
+			FileMaxSize:    v.GetInt64("access-log-file-max-size"),
+			FileMaxBackups: v.GetInt("access-log-file-max-backups"),
+			SyslogSocket:   v.GetString("access-log-syslog-socket"),
+		},
+		Tracing: Tracing{
+			Enabled:     v.GetBool("tracing"),
+			Exporter:    strings.ToLower(v.GetString("tracing-exporter")),
+			Endpoint:    v.GetString("tracing-endpoint"),
+			Insecure:    v.GetBool("tracing-insecure"),
+			SampleRatio: v.GetFloat64("tracing-sample-ratio"),
+			ServiceName: v.GetString("tracing-service-name"),
+		},
+		Chain: Chain{
+			Enabled:            v.GetBool("chain"),
+			Upstreams:          splitList(v.GetString("chain-upstreams")),
+			AllowedUpstreams:   splitList(v.GetString("chain-allowed-upstreams")),
+			Timeout:            v.GetDuration("chain-timeout"),
+			MaxUpstreams:       v.GetInt("chain-max-upstreams"),
+			MaxDepth:           v.GetInt("chain-max-depth"),
+			PropagateHeaders:   splitList(v.GetString("chain-propagate-headers")),
+			InsecureSkipVerify: v.GetBool("chain-insecure-skip-verify"),
+		},
+		Diagnostics: Diagnostics{
+			Enabled: v.GetBool("diagnostics"),
+			Token:   v.GetString("diagnostics-token"),
+			Timeout: v.GetDuration("diagnostics-timeout"),
 		},
 	}
 
-	// Set log level
-	lvl, err := logrus.ParseLevel(viper.GetString("log-level"))
+	// Parse the settings that need it, collecting errors so every problem
+	// is reported at once
+	var errs []error
+	lvl, err := logrus.ParseLevel(v.GetString("log-level"))
 	if err != nil {
-		return nil, err
+		errs = append(errs, err)
 	}
 	cfg.LogLevel = lvl
-	logrus.SetLevel(cfg.LogLevel)
-
-	// Validate external readiness settings
-	if cfg.ExternalReadinessProbe.Enabled() {
-		if cfg.ExternalReadinessProbe.Interval <= 0 {
-			return nil, fmt.Errorf("external readiness probe interval must be greater than zero")
-		}
-		if cfg.ExternalReadinessProbe.Timeout <= 0 {
-			return nil, fmt.Errorf("external readiness probe timeout must be greater than zero")
-		}
-		if cfg.ExternalReadinessProbe.HTTPExpectedStatus < 100 || cfg.ExternalReadinessProbe.HTTPExpectedStatus > 599 {
-			return nil, fmt.Errorf("external readiness HTTP expected status must be between 100 and 599")
-		}
+	cfg.LogFormat = strings.ToLower(v.GetString("log-format"))
+	if cfg.LogFormat != "text" && cfg.LogFormat != "json" {
+		errs = append(errs, fmt.Errorf("invalid log format: %s", cfg.LogFormat))
 	}
-
-	// Validate message length
-	if len(cfg.Message) > MaxMessageLength {
-		return nil, fmt.Errorf("message length (%d) exceeds maximum allowed length (%d)", len(cfg.Message), MaxMessageLength)
+	cfg.SocketMode, err = parseSocketMode(v.GetString("socket-mode"))
+	if err != nil {
+		errs = append(errs, err)
+	}
+	cfg.resolveAddresses()
+	if err := loadListeners(v, cfg); err != nil {
+		errs = append(errs, err)
+	}
+	headers, err := parseHeaders(splitList(v.GetString("external-readiness-http-headers")))
+	if err != nil {
+		errs = append(errs, err)
+	}
+	cfg.ExternalReadinessProbe.HTTPHeaders = headers
+	if err := loadReadinessProbes(v, cfg); err != nil {
+		errs = append(errs, err)
 	}
+	cfg.Diagnostics.AllowedCIDRs, err = parsePrefixes(splitList(v.GetString("diagnostics-allowed-cidrs")))
+	if err != nil {
+		errs = append(errs, err)
+	}
+	return cfg, errs
+}
 
-	return cfg, nil
+// ApplyLogging sets the application log level and format
+func (c *Config) ApplyLogging() {
+	logrus.SetLevel(c.LogLevel)
+	if c.LogFormat == "json" {
+		logrus.SetFormatter(&logrus.JSONFormatter{})
+	} else {
+		logrus.SetFormatter(&logrus.TextFormatter{})
+	}
 }
@@ -1,7 +1,10 @@
 package config
 
 import (
+	"bytes"
+	"net/netip"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -26,8 +29,11 @@ func TestLoad_DefaultValues(t *testing.T) {
 	assert.False(t, cfg.TLS)
 	assert.False(t, cfg.TCP)
 	assert.False(t, cfg.GRPC)
+	assert.False(t, cfg.GRPCTLS)
 	assert.False(t, cfg.QUIC)
+	assert.False(t, cfg.WebTransport)
 	assert.True(t, cfg.Metrics)
+	assert.Equal(t, []Listener{{Name: "HTTP", Protocol: ListenerHTTP, Port: "8080", Address: ":8080"}}, cfg.Listeners)
 	assert.Equal(t, "8080", cfg.HTTPPort)
 	assert.Equal(t, "8443", cfg.TLSPort)
 	assert.Equal(t, "9090", cfg.TCPPort)
@@ -36,6 +42,40 @@ func TestLoad_DefaultValues(t *testing.T) {
 	assert.Equal(t, "3000", cfg.MetricsPort)
 	assert.Equal(t, int64(10485760), cfg.MaxRequestSize) // 10MB
 	assert.Equal(t, logrus.InfoLevel, cfg.LogLevel)
+	assert.Equal(t, "json", cfg.TCPFormat)
+	assert.True(t, cfg.AltSvc)
+	assert.Equal(t, "", cfg.AltSvcPort)
+	assert.Equal(t, 24*time.Hour, cfg.AltSvcMaxAge)
+	assert.Equal(t, "text", cfg.LogFormat)
+	assert.Equal(t, "X-Request-Id", cfg.RequestIDHeader)
+	assert.False(t, cfg.AccessLog.Enabled)
+	assert.Equal(t, AccessLogFormatJSON, cfg.AccessLog.Format)
+	assert.Empty(t, cfg.AccessLog.Fields)
+	assert.Equal(t, int64(4096), cfg.AccessLog.MaxBodySize)
+	assert.Contains(t, cfg.AccessLog.Redact, "Authorization")
+	assert.Equal(t, AccessLogOutputStdout, cfg.AccessLog.Output)
+	assert.Equal(t, int64(100), cfg.AccessLog.FileMaxSize)
+	assert.Equal(t, 3, cfg.AccessLog.FileMaxBackups)
+	assert.Equal(t, "/dev/log", cfg.AccessLog.SyslogSocket)
+	assert.False(t, cfg.Tracing.Enabled)
+	assert.Equal(t, TracingExporterOTLPGRPC, cfg.Tracing.Exporter)
+	assert.Equal(t, "", cfg.Tracing.Endpoint)
+	assert.True(t, cfg.Tracing.Insecure)
+	assert.Equal(t, 1.0, cfg.Tracing.SampleRatio)
+	assert.Equal(t, "echo-app", cfg.Tracing.ServiceName)
+	assert.False(t, cfg.Chain.Enabled)
+	assert.Empty(t, cfg.Chain.Upstreams)
+	assert.Empty(t, cfg.Chain.AllowedUpstreams)
+	assert.Equal(t, 5*time.Second, cfg.Chain.Timeout)
+	assert.Equal(t, 10, cfg.Chain.MaxUpstreams)
+	assert.Equal(t, 10, cfg.Chain.MaxDepth)
+	assert.Contains(t, cfg.Chain.PropagateHeaders, "X-B3-TraceId")
+	assert.NotContains(t, cfg.Chain.PropagateHeaders, "Authorization")
+	assert.False(t, cfg.Chain.InsecureSkipVerify)
+	assert.False(t, cfg.Diagnostics.Enabled)
+	assert.Empty(t, cfg.Diagnostics.Token)
+	assert.Equal(t, []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8"), netip.MustParsePrefix("::1/128")}, cfg.Diagnostics.AllowedCIDRs)
+	assert.Equal(t, 5*time.Second, cfg.Diagnostics.Timeout)
 }
 
 func TestLoad_EnvironmentVariables(t *testing.T) {
@@ -98,6 +138,16 @@ func TestLoad_EnvironmentVariables(t *testing.T) {
 				assert.True(t, cfg.QUIC)
 			},
 		},
+		{
+			name: "enable WebTransport",
+			envVars: map[string]string{
+				"ECHO_APP_QUIC":         "true",
+				"ECHO_APP_WEBTRANSPORT": "true",
+			},
+			validate: func(t *testing.T, cfg *Config) {
+				assert.True(t, cfg.WebTransport)
+			},
+		},
 		{
 			name: "print headers",
 			envVars: map[string]string{
@@ -135,6 +185,123 @@ func TestLoad_EnvironmentVariables(t *testing.T) {
 				assert.Equal(t, int64(5242880), cfg.MaxRequestSize)
 			},
 		},
+		{
+			name: "TCP format",
+			envVars: map[string]string{
+				"ECHO_APP_TCP_FORMAT": "YAML",
+			},
+			validate: func(t *testing.T, cfg *Config) {
+				assert.Equal(t, "yaml", cfg.TCPFormat)
+			},
+		},
+		{
+			name: "alt-svc settings",
+			envVars: map[string]string{
+				"ECHO_APP_ALT_SVC":         "false",
+				"ECHO_APP_ALT_SVC_PORT":    "443",
+				"ECHO_APP_ALT_SVC_MAX_AGE": "1h",
+			},
+			validate: func(t *testing.T, cfg *Config) {
+				assert.False(t, cfg.AltSvc)
+				assert.Equal(t, "443", cfg.AltSvcPort)
+				assert.Equal(t, time.Hour, cfg.AltSvcMaxAge)
+			},
+		},
+		{
+			name: "request ID header",
+			envVars: map[string]string{
+				"ECHO_APP_REQUEST_ID_HEADER": "x-correlation-id",
+			},
+			validate: func(t *testing.T, cfg *Config) {
+				assert.Equal(t, "X-Correlation-Id", cfg.RequestIDHeader)
+			},
+		},
+		{
+			name: "tracing settings",
+			envVars: map[string]string{
+				"ECHO_APP_TRACING":              "true",
+				"ECHO_APP_TRACING_EXPORTER":     "OTLP-HTTP",
+				"ECHO_APP_TRACING_ENDPOINT":     "collector:4318",
+				"ECHO_APP_TRACING_INSECURE":     "false",
+				"ECHO_APP_TRACING_SAMPLE_RATIO": "0.25",
+				"ECHO_APP_TRACING_SERVICE_NAME": "leaf",
+			},
+			validate: func(t *testing.T, cfg *Config) {
+				assert.True(t, cfg.Tracing.Enabled)
+				assert.Equal(t, TracingExporterOTLPHTTP, cfg.Tracing.Exporter)
+				assert.Equal(t, "collector:4318", cfg.Tracing.Endpoint)
+				assert.False(t, cfg.Tracing.Insecure)
+				assert.Equal(t, 0.25, cfg.Tracing.SampleRatio)
+				assert.Equal(t, "leaf", cfg.Tracing.ServiceName)
+			},
+		},
+		{
+			name: "chain settings",
+			envVars: map[string]string{
+				"ECHO_APP_CHAIN":                      "true",
+				"ECHO_APP_CHAIN_UPSTREAMS":            "http://b:8080/chain, grpc://c:50051",
+				"ECHO_APP_CHAIN_ALLOWED_UPSTREAMS":    "https://d:8443",
+				"ECHO_APP_CHAIN_TIMEOUT":              "2s",
+				"ECHO_APP_CHAIN_MAX_UPSTREAMS":        "3",
+				"ECHO_APP_CHAIN_MAX_DEPTH":            "4",
+				"ECHO_APP_CHAIN_PROPAGATE_HEADERS":    "X-Tenant",
+				"ECHO_APP_CHAIN_INSECURE_SKIP_VERIFY": "true",
+			},
+			validate: func(t *testing.T, cfg *Config) {
+				assert.True(t, cfg.Chain.Enabled)
+				assert.Equal(t, []string{"http://b:8080/chain", "grpc://c:50051"}, cfg.Chain.Upstreams)
+				assert.Equal(t, []string{"https://d:8443"}, cfg.Chain.AllowedUpstreams)
+				assert.Equal(t, 2*time.Second, cfg.Chain.Timeout)
+				assert.Equal(t, 3, cfg.Chain.MaxUpstreams)
+				assert.Equal(t, 4, cfg.Chain.MaxDepth)
+				assert.Equal(t, []string{"X-Tenant"}, cfg.Chain.PropagateHeaders)
+				assert.True(t, cfg.Chain.InsecureSkipVerify)
+			},
+		},
+		{
+			name: "diagnostics settings",
+			envVars: map[string]string{
+				"ECHO_APP_DIAGNOSTICS":               "true",
+				"ECHO_APP_DIAGNOSTICS_TOKEN":         "s3cret",
+				"ECHO_APP_DIAGNOSTICS_ALLOWED_CIDRS": "10.0.0.0/8, 192.168.1.7,fd00::1/64",
+				"ECHO_APP_DIAGNOSTICS_TIMEOUT":       "3s",
+			},
+			validate: func(t *testing.T, cfg *Config) {
+				assert.True(t, cfg.Diagnostics.Enabled)
+				assert.Equal(t, "s3cret", cfg.Diagnostics.Token)
+				assert.Equal(t, []netip.Prefix{
+					netip.MustParsePrefix("10.0.0.0/8"),
+					netip.MustParsePrefix("192.168.1.7/32"),
+					netip.MustParsePrefix("fd00::/64"),
+				}, cfg.Diagnostics.AllowedCIDRs)
+				assert.Equal(t, 3*time.Second, cfg.Diagnostics.Timeout)
+			},
+		},
+		{
+			name: "access log settings",
+			envVars: map[string]string{
+				"ECHO_APP_ACCESS_LOG":                  "true",
+				"ECHO_APP_ACCESS_LOG_FORMAT":           "Logfmt",
+				"ECHO_APP_ACCESS_LOG_FIELDS":           "method, path,status",
+				"ECHO_APP_ACCESS_LOG_INCLUDE_HEADERS":  "true",
+				"ECHO_APP_ACCESS_LOG_REDACT":           "X-Secret",
+				"ECHO_APP_ACCESS_LOG_OUTPUT":           "file",
+				"ECHO_APP_ACCESS_LOG_FILE":             "/tmp/access.log",
+				"ECHO_APP_ACCESS_LOG_FILE_MAX_SIZE":    "10",
+				"ECHO_APP_ACCESS_LOG_FILE_MAX_BACKUPS": "5",
+			},
+			validate: func(t *testing.T, cfg *Config) {
+				assert.True(t, cfg.AccessLog.Enabled)
+				assert.Equal(t, AccessLogFormatLogfmt, cfg.AccessLog.Format)
+				assert.Equal(t, []string{"method", "path", "status"}, cfg.AccessLog.Fields)
+				assert.True(t, cfg.AccessLog.IncludeHeaders)
+				assert.Equal(t, []string{"X-Secret"}, cfg.AccessLog.Redact)
+				assert.Equal(t, AccessLogOutputFile, cfg.AccessLog.Output)
+				assert.Equal(t, "/tmp/access.log", cfg.AccessLog.File)
+				assert.Equal(t, int64(10), cfg.AccessLog.FileMaxSize)
+				assert.Equal(t, 5, cfg.AccessLog.FileMaxBackups)
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -287,12 +454,545 @@ func TestLoad_LogLevelParsing(t *testing.T) {
 				assert.NotNil(t, cfg)
 				assert.Equal(t, tt.expected, cfg.LogLevel)
 				// Verify logrus global level is set
+				cfg.ApplyLogging()
 				assert.Equal(t, tt.expected, logrus.GetLevel())
 			}
 		})
 	}
 }
 
+func TestLoad_LogFormat(t *testing.T) {
+	defer logrus.SetFormatter(&logrus.TextFormatter{})
+
+	tests := []struct {
+		name        string
+		logFormat   string
+		expectError bool
+	}{
+		{name: "text", logFormat: "text"},
+		{name: "json", logFormat: "JSON"},
+		{name: "invalid", logFormat: "xml", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Reset()
+
+			_ = os.Setenv("ECHO_APP_LOG_FORMAT", tt.logFormat)
+			defer func() { _ = os.Unsetenv("ECHO_APP_LOG_FORMAT") }()
+
+			cfg, err := Load()
+			if tt.expectError {
+				assert.ErrorContains(t, err, "invalid log format")
+				assert.Nil(t, cfg)
+				return
+			}
+			require.NoError(t, err)
+			cfg.ApplyLogging()
+			if cfg.LogFormat == "json" {
+				assert.IsType(t, &logrus.JSONFormatter{}, logrus.StandardLogger().Formatter)
+			} else {
+				assert.IsType(t, &logrus.TextFormatter{}, logrus.StandardLogger().Formatter)
+			}
+		})
+	}
+}
+
+func TestLoad_InvalidRequestIDHeader(t *testing.T) {
+	for _, header := range []string{" ", "X Request ID", "X-Request-ID:"} {
+		t.Run(header, func(t *testing.T) {
+			viper.Reset()
+
+			_ = os.Setenv("ECHO_APP_REQUEST_ID_HEADER", header)
+			defer func() { _ = os.Unsetenv("ECHO_APP_REQUEST_ID_HEADER") }()
+
+			cfg, err := Load()
+			assert.ErrorContains(t, err, "invalid request ID header")
+			assert.Nil(t, cfg)
+		})
+	}
+}
+
+func TestLoad_AccessLogValidation(t *testing.T) {
+	tests := []struct {
+		name          string
+		envVars       map[string]string
+		expectedError string
+	}{
+		{
+			name:          "invalid format",
+			envVars:       map[string]string{"ECHO_APP_ACCESS_LOG_FORMAT": "xml"},
+			expectedError: "invalid access log format",
+		},
+		{
+			name:          "invalid output",
+			envVars:       map[string]string{"ECHO_APP_ACCESS_LOG_OUTPUT": "kafka"},
+			expectedError: "invalid access log output",
+		},
+		{
+			name:          "file output without path",
+			envVars:       map[string]string{"ECHO_APP_ACCESS_LOG_OUTPUT": "file"},
+			expectedError: "access log file path is required",
+		},
+		{
+			name: "body without size limit",
+			envVars: map[string]string{
+				"ECHO_APP_ACCESS_LOG_INCLUDE_BODY":  "true",
+				"ECHO_APP_ACCESS_LOG_MAX_BODY_SIZE": "0",
+			},
+			expectedError: "max body size must be greater than zero",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Reset()
+
+			_ = os.Setenv("ECHO_APP_ACCESS_LOG", "true")
+			defer func() { _ = os.Unsetenv("ECHO_APP_ACCESS_LOG") }()
+			for key, value := range tt.envVars {
+				_ = os.Setenv(key, value)
+				defer func(k string) { _ = os.Unsetenv(k) }(key)
+			}
+
+			cfg, err := Load()
+			assert.ErrorContains(t, err, tt.expectedError)
+			assert.Nil(t, cfg)
+		})
+	}
+}
+
+func TestLoad_TracingValidation(t *testing.T) {
+	tests := []struct {
+		name          string
+		envVars       map[string]string
+		expectedError string
+	}{
+		{
+			name:          "invalid exporter",
+			envVars:       map[string]string{"ECHO_APP_TRACING_EXPORTER": "zipkin"},
+			expectedError: "invalid tracing exporter",
+		},
+		{
+			name:          "sample ratio above one",
+			envVars:       map[string]string{"ECHO_APP_TRACING_SAMPLE_RATIO": "1.5"},
+			expectedError: "sample ratio must be between 0 and 1",
+		},
+		{
+			name:          "negative sample ratio",
+			envVars:       map[string]string{"ECHO_APP_TRACING_SAMPLE_RATIO": "-0.1"},
+			expectedError: "sample ratio must be between 0 and 1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Reset()
+
+			_ = os.Setenv("ECHO_APP_TRACING", "true")
+			defer func() { _ = os.Unsetenv("ECHO_APP_TRACING") }()
+			for key, value := range tt.envVars {
+				_ = os.Setenv(key, value)
+				defer func(k string) { _ = os.Unsetenv(k) }(key)
+			}
+
+			cfg, err := Load()
+			assert.ErrorContains(t, err, tt.expectedError)
+			assert.Nil(t, cfg)
+		})
+	}
+}
+
+func TestLoad_ChainValidation(t *testing.T) {
+	tests := []struct {
+		name          string
+		envVars       map[string]string
+		expectedError string
+	}{
+		{
+			name:          "zero timeout",
+			envVars:       map[string]string{"ECHO_APP_CHAIN_TIMEOUT": "0s"},
+			expectedError: "chain timeout must be between 0 and 25s",
+		},
+		{
+			name:          "timeout above maximum",
+			envVars:       map[string]string{"ECHO_APP_CHAIN_TIMEOUT": "1m"},
+			expectedError: "chain timeout must be between 0 and 25s",
+		},
+		{
+			name:          "zero max upstreams",
+			envVars:       map[string]string{"ECHO_APP_CHAIN_MAX_UPSTREAMS": "0"},
+			expectedError: "chain max upstreams must be greater than zero",
+		},
+		{
+			name:          "zero max depth",
+			envVars:       map[string]string{"ECHO_APP_CHAIN_MAX_DEPTH": "0"},
+			expectedError: "chain max depth must be greater than zero",
+		},
+		{
+			name: "too many upstreams",
+			envVars: map[string]string{
+				"ECHO_APP_CHAIN_UPSTREAMS":     "http://a,http://b",
+				"ECHO_APP_CHAIN_MAX_UPSTREAMS": "1",
+			},
+			expectedError: "2 chain upstreams configured, at most 1 allowed",
+		},
+		{
+			name:          "invalid upstream scheme",
+			envVars:       map[string]string{"ECHO_APP_CHAIN_UPSTREAMS": "ftp://a"},
+			expectedError: `invalid chain upstream "ftp://a": scheme must be one of http, https, grpc, grpcs, tcp`,
+		},
+		{
+			name:          "allowed upstream without host",
+			envVars:       map[string]string{"ECHO_APP_CHAIN_ALLOWED_UPSTREAMS": "http://"},
+			expectedError: `invalid chain upstream "http://": missing host`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Reset()
+
+			for key, value := range tt.envVars {
+				_ = os.Setenv(key, value)
+				defer func(k string) { _ = os.Unsetenv(k) }(key)
+			}
+
+			cfg, err := Load()
+			assert.ErrorContains(t, err, tt.expectedError)
+			assert.Nil(t, cfg)
+		})
+	}
+}
+
+func TestChain_Allows(t *testing.T) {
+	chain := Chain{
+		Upstreams:        []string{"http://backend:8080/chain"},
+		AllowedUpstreams: []string{"grpc://Database:50051", "tcp://database:9090"},
+	}
+	tests := []struct {
+		target string
+		want   bool
+	}{
+		{target: "http://backend:8080/chain", want: true},
+		{target: "http://backend:8080/chain?upstream=http://other", want: true},
+		{target: "grpc://database:50051", want: true},
+		{target: "tcp://DATABASE:9090", want: true},
+		{target: "https://backend:8080/chain", want: false},
+		{target: "http://backend:8081/chain", want: false},
+		{target: "http://backend/chain", want: false},
+		{target: "http://169.254.169.254/latest/meta-data", want: false},
+		{target: "grpc://database:9090", want: false},
+		{target: "://invalid", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			assert.Equal(t, tt.want, chain.Allows(tt.target))
+		})
+	}
+}
+
+func TestLoad_DiagnosticsValidation(t *testing.T) {
+	tests := []struct {
+		name          string
+		envVars       map[string]string
+		expectedError string
+	}{
+		{
+			name:          "invalid CIDR",
+			envVars:       map[string]string{"ECHO_APP_DIAGNOSTICS_ALLOWED_CIDRS": "10.0.0.0/33"},
+			expectedError: `invalid diagnostics allowed CIDR: "10.0.0.0/33"`,
+		},
+		{
+			name:          "zero timeout",
+			envVars:       map[string]string{"ECHO_APP_DIAGNOSTICS_TIMEOUT": "0s"},
+			expectedError: "diagnostics timeout must be between 0 and 9s",
+		},
+		{
+			name:          "timeout above maximum",
+			envVars:       map[string]string{"ECHO_APP_DIAGNOSTICS_TIMEOUT": "10s"},
+			expectedError: "diagnostics timeout must be between 0 and 9s",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Reset()
+
+			_ = os.Setenv("ECHO_APP_DIAGNOSTICS", "true")
+			defer func() { _ = os.Unsetenv("ECHO_APP_DIAGNOSTICS") }()
+			for key, value := range tt.envVars {
+				_ = os.Setenv(key, value)
+				defer func(k string) { _ = os.Unsetenv(k) }(key)
+			}
+
+			cfg, err := Load()
+			assert.ErrorContains(t, err, tt.expectedError)
+			assert.Nil(t, cfg)
+		})
+	}
+}
+
+func TestLoad_FlagListeners(t *testing.T) {
+	viper.Reset()
+	envVars := map[string]string{
+		"ECHO_APP_H2C":          "true",
+		"ECHO_APP_TLS":          "true",
+		"ECHO_APP_TCP":          "true",
+		"ECHO_APP_GRPC":         "true",
+		"ECHO_APP_GRPC_TLS":     "true",
+		"ECHO_APP_QUIC":         "true",
+		"ECHO_APP_WEBTRANSPORT": "true",
+	}
+	for key, value := range envVars {
+		_ = os.Setenv(key, value)
+		defer func(k string) { _ = os.Unsetenv(k) }(key)
+	}
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, []Listener{
+		{Name: "H2C", Protocol: ListenerHTTP, Port: "8080", Address: ":8080", H2C: true},
+		{Name: "TLS", Protocol: ListenerTLS, Port: "8443", Address: ":8443"},
+		{Name: "TCP", Protocol: ListenerTCP, Port: "9090", Address: ":9090"},
+		{Name: "gRPC", Protocol: ListenerGRPC, Port: "50051", Address: ":50051", TLS: true},
+		{Name: "QUIC", Protocol: ListenerQUIC, Port: "4433", Address: ":4433", WebTransport: true},
+	}, cfg.Listeners)
+}
+
+func TestLoad_ConfigFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{
+			name: "YAML",
+			file: "config.yaml",
+			content: `message: from-file
+tcp-format: text
+listeners:
+  - name: public
+    protocol: http
+    port: 8080
+    h2c: true
+  - name: internal
+    protocol: HTTP
+    port: 8081
+    message: internal
+    print-http-request-headers: true
+  - protocol: grpc
+    port: 50051
+  - name: grpc-tls
+    protocol: grpc
+    port: 50052
+    tls: true
+  - name: raw
+    protocol: tcp
+    port: 9090
+    format: yaml
+  - name: h3
+    protocol: quic
+    port: 4433
+    webtransport: true
+`,
+		},
+		{
+			name: "TOML",
+			file: "config.toml",
+			content: `message = "from-file"
+tcp-format = "text"
+
+[[listeners]]
+name = "public"
+protocol = "http"
+port = 8080
+h2c = true
+
+[[listeners]]
+name = "internal"
+protocol = "HTTP"
+port = "8081"
+message = "internal"
+print-http-request-headers = true
+
+[[listeners]]
+protocol = "grpc"
+port = 50051
+
+[[listeners]]
+name = "grpc-tls"
+protocol = "grpc"
+port = 50052
+tls = true
+
+[[listeners]]
+name = "raw"
+protocol = "tcp"
+port = 9090
+format = "yaml"
+
+[[listeners]]
+name = "h3"
+protocol = "quic"
+port = 4433
+webtransport = true
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Reset()
+			path := filepath.Join(t.TempDir(), tt.file)
+			require.NoError(t, os.WriteFile(path, []byte(tt.content), 0o600))
+			_ = os.Setenv("ECHO_APP_CONFIG", path)
+			defer func() { _ = os.Unsetenv("ECHO_APP_CONFIG") }()
+			// Environment variables override the file
+			_ = os.Setenv("ECHO_APP_NODE", "node-1")
+			defer func() { _ = os.Unsetenv("ECHO_APP_NODE") }()
+
+			cfg, err := Load()
+			require.NoError(t, err)
+			assert.Equal(t, "from-file", cfg.Message)
+			assert.Equal(t, "node-1", cfg.Node)
+			printHeaders := true
+			assert.Equal(t, []Listener{
+				{Name: "public", Protocol: ListenerHTTP, Port: "8080", Address: ":8080", H2C: true},
+				{Name: "internal", Protocol: ListenerHTTP, Port: "8081", Address: ":8081", Message: "internal", PrintHeaders: &printHeaders},
+				{Name: "grpc-50051", Protocol: ListenerGRPC, Port: "50051", Address: ":50051"},
+				{Name: "grpc-tls", Protocol: ListenerGRPC, Port: "50052", Address: ":50052", TLS: true},
+				{Name: "raw", Protocol: ListenerTCP, Port: "9090", Address: ":9090", Format: "yaml"},
+				{Name: "h3", Protocol: ListenerQUIC, Port: "4433", Address: ":4433", WebTransport: true},
+			}, cfg.Listeners)
+
+			// The first listener of each protocol sets the per-protocol fields
+			assert.True(t, cfg.H2C)
+			assert.True(t, cfg.GRPC)
+			assert.False(t, cfg.GRPCTLS)
+			assert.Equal(t, "50051", cfg.GRPCPort)
+			assert.True(t, cfg.QUIC)
+			assert.Equal(t, "4433", cfg.QUICPort)
+			assert.False(t, cfg.TLS)
+
+			internal := cfg.ForListener(cfg.Listeners[1])
+			assert.Equal(t, "internal", internal.Message)
+			assert.True(t, internal.PrintHeaders)
+			assert.Equal(t, "8081", internal.HTTPPort)
+			assert.False(t, internal.H2C)
+			assert.Equal(t, "from-file", cfg.Message)
+
+			raw := cfg.ForListener(cfg.Listeners[4])
+			assert.Equal(t, "yaml", raw.TCPFormat)
+			assert.Equal(t, "from-file", raw.Message)
+			assert.Equal(t, "text", cfg.ForListener(Listener{Protocol: ListenerTCP, Port: "9091"}).TCPFormat)
+		})
+	}
+}
+
+func TestLoad_ListenerValidation(t *testing.T) {
+	tests := []struct {
+		name          string
+		listeners     string
+		expectedError string
+	}{
+		{
+			name:          "empty",
+			listeners:     "listeners: []",
+			expectedError: "at least one listener must be configured",
+		},
+		{
+			name:          "invalid protocol",
+			listeners:     "listeners: [{name: a, protocol: smtp, port: 25}]",
+			expectedError: `listener a: invalid protocol "smtp"`,
+		},
+		{
+			name:          "invalid port",
+			listeners:     "listeners: [{name: a, protocol: http, port: 70000}]",
+			expectedError: "listener a: invalid port: 70000",
+		},
+		{
+			name:          "duplicate name",
+			listeners:     "listeners: [{name: a, protocol: http, port: 8080}, {name: a, protocol: tcp, port: 9090}]",
+			expectedError: "duplicate listener name: a",
+		},
+		{
+			name:          "h2c on TCP",
+			listeners:     "listeners: [{name: a, protocol: tcp, port: 9090, h2c: true}]",
+			expectedError: "listener a: h2c is only supported by http listeners",
+		},
+		{
+			name:          "TLS on HTTP",
+			listeners:     "listeners: [{name: a, protocol: http, port: 8080, tls: true}]",
+			expectedError: "listener a: tls is only supported by grpc listeners",
+		},
+		{
+			name:          "WebTransport on TLS",
+			listeners:     "listeners: [{name: a, protocol: tls, port: 8443, webtransport: true}]",
+			expectedError: "listener a: webtransport is only supported by quic listeners",
+		},
+		{
+			name:          "format on gRPC",
+			listeners:     "listeners: [{name: a, protocol: grpc, port: 50051, format: text}]",
+			expectedError: "listener a: format is only supported by tcp listeners",
+		},
+		{
+			name:          "invalid format",
+			listeners:     "listeners: [{name: a, protocol: tcp, port: 9090, format: xml}]",
+			expectedError: "listener a: invalid format",
+		},
+		{
+			name:          "invalid address",
+			listeners:     "listeners: [{name: a, protocol: http, address: 'localhost'}]",
+			expectedError: `listener a: invalid address "localhost"`,
+		},
+		{
+			name:          "relative unix socket",
+			listeners:     "listeners: [{name: a, protocol: http, address: 'unix://a.sock'}]",
+			expectedError: "listener a: invalid address \"unix://a.sock\": unix socket path must be absolute",
+		},
+		{
+			name:          "unix socket on QUIC",
+			listeners:     "listeners: [{name: a, protocol: quic, address: 'unix:///run/a.sock'}]",
+			expectedError: "listener a: unix sockets are not supported by quic listeners",
+		},
+		{
+			name:          "invalid socket mode",
+			listeners:     "listeners: [{name: a, protocol: http, address: 'unix:///run/a.sock', socket-mode: '0999'}]",
+			expectedError: `listener a: invalid socket mode "0999"`,
+		},
+		{
+			name:          "unknown field type",
+			listeners:     "listeners: [{name: a, protocol: http, port: 8080, h2c: [1]}]",
+			expectedError: "invalid listeners",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Reset()
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			require.NoError(t, os.WriteFile(path, []byte(tt.listeners), 0o600))
+			_ = os.Setenv("ECHO_APP_CONFIG", path)
+			defer func() { _ = os.Unsetenv("ECHO_APP_CONFIG") }()
+
+			cfg, err := Load()
+			assert.ErrorContains(t, err, tt.expectedError)
+			assert.Nil(t, cfg)
+		})
+	}
+}
+
+func TestLoad_MissingConfigFile(t *testing.T) {
+	viper.Reset()
+	_ = os.Setenv("ECHO_APP_CONFIG", filepath.Join(t.TempDir(), "missing.yaml"))
+	defer func() { _ = os.Unsetenv("ECHO_APP_CONFIG") }()
+
+	cfg, err := Load()
+	assert.ErrorContains(t, err, "failed to read config file")
+	assert.Nil(t, cfg)
+}
+
 func TestLoad_MaxMessageLengthConstant(t *testing.T) {
 	// Verify the constant value is as expected
 	assert.Equal(t, 1024, MaxMessageLength)
@@ -367,4 +1067,627 @@ func TestLoad_ExternalReadinessProbeConfiguration(t *testing.T) {
 	assert.Equal(t, 500*time.Millisecond, cfg.ExternalReadinessProbe.Timeout)
 	assert.Equal(t, "HEAD", cfg.ExternalReadinessProbe.HTTPMethod)
 	assert.Equal(t, 204, cfg.ExternalReadinessProbe.HTTPExpectedStatus)
+	require.Len(t, cfg.ExternalReadiness.Probes, 1)
+	assert.Equal(t, FlagProbeName, cfg.ExternalReadiness.Probes[0].Name)
+	assert.Equal(t, "http://upstream/health", cfg.ExternalReadiness.Probes[0].Target)
+	assert.Equal(t, ReadinessPolicyAll, cfg.ExternalReadiness.Policy)
+}
+
+func TestLoad_ExternalReadinessProbes(t *testing.T) {
+	viper.Reset()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+external-readiness-policy: quorum
+external-readiness-quorum: 2
+external-readiness-probes:
+  - name: db
+    type: tcp
+    target: db:5432
+    interval: 5s
+    success-threshold: 2
+    failure-threshold: 5
+    max-interval: 1m
+  - type: HTTP
+    target: http://cache/health
+    http-expected-status: 204
+    http-headers:
+      Authorization: Bearer secret
+    http-expected-statuses: 200-299,304
+    http-body-json-path: $.status
+    http-body-json-value: UP
+    http-redirect-policy: None
+  - type: icmp
+    target: 192.0.2.1
+    timeout: 500ms
+  - name: billing
+    type: grpc
+    target: billing:50051
+    grpc-service: billing.v1.Billing
+    grpc-tls: true
+  - name: discovery
+    type: dns
+    target: _grpc._tcp.billing.example.com
+    dns-record-type: srv
+    dns-expected-records: ["0 100 50051 billing.example.com."]
+  - name: certificate
+    type: tls
+    target: api.example.com:443
+    tls-min-validity: 168h
+`), 0o600))
+	t.Setenv("ECHO_APP_CONFIG", path)
+	t.Setenv("ECHO_APP_EXTERNAL_READINESS_PROBE_TYPE", "tcp")
+	t.Setenv("ECHO_APP_EXTERNAL_READINESS_PROBE_TARGET", "ignored:1")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, ExternalReadiness{
+		Policy: ReadinessPolicyQuorum,
+		Quorum: 2,
+		Probes: []ExternalReadinessProbe{
+			{Name: "db", Type: "tcp", Target: "db:5432", Interval: 5 * time.Second, Timeout: 2 * time.Second, SuccessThreshold: 2, FailureThreshold: 5, MaxInterval: time.Minute, HTTPMethod: "GET", HTTPExpectedStatus: 200, HTTPRedirectPolicy: "follow", DNSRecordType: "IP"},
+			{Name: "http-2", Type: "http", Target: "http://cache/health", Interval: 10 * time.Second, Timeout: 2 * time.Second, SuccessThreshold: 1, FailureThreshold: 3, HTTPMethod: "GET", HTTPExpectedStatus: 204, HTTPRedirectPolicy: "none", HTTPHeaders: map[string]string{"authorization": "Bearer secret"}, HTTPExpectedStatuses: "200-299,304", HTTPBodyJSONPath: "$.status", HTTPBodyJSONValue: "UP", DNSRecordType: "IP"},
+			{Name: "icmp-3", Type: "icmp", Target: "192.0.2.1", Interval: 10 * time.Second, Timeout: 500 * time.Millisecond, SuccessThreshold: 1, FailureThreshold: 3, HTTPMethod: "GET", HTTPExpectedStatus: 200, HTTPRedirectPolicy: "follow", DNSRecordType: "IP"},
+			{Name: "billing", Type: "grpc", Target: "billing:50051", Interval: 10 * time.Second, Timeout: 2 * time.Second, SuccessThreshold: 1, FailureThreshold: 3, HTTPMethod: "GET", HTTPExpectedStatus: 200, HTTPRedirectPolicy: "follow", DNSRecordType: "IP", GRPCService: "billing.v1.Billing", GRPCTLS: true},
+			{Name: "discovery", Type: "dns", Target: "_grpc._tcp.billing.example.com", Interval: 10 * time.Second, Timeout: 2 * time.Second, SuccessThreshold: 1, FailureThreshold: 3, HTTPMethod: "GET", HTTPExpectedStatus: 200, HTTPRedirectPolicy: "follow", DNSRecordType: "SRV", DNSExpectedRecords: []string{"0 100 50051 billing.example.com."}},
+			{Name: "certificate", Type: "tls", Target: "api.example.com:443", Interval: 10 * time.Second, Timeout: 2 * time.Second, SuccessThreshold: 1, FailureThreshold: 3, HTTPMethod: "GET", HTTPExpectedStatus: 200, HTTPRedirectPolicy: "follow", DNSRecordType: "IP", TLSMinValidity: 168 * time.Hour},
+		},
+	}, cfg.ExternalReadiness)
+	assert.Equal(t, 2, cfg.ExternalReadiness.Required())
+	assert.Equal(t, "IP", cfg.ExternalReadinessProbe.DNSRecordType)
+
+	// The effective configuration keeps the resolved probes, with header
+	// values redacted
+	var buf bytes.Buffer
+	require.NoError(t, cfg.WriteEffective(&buf))
+	assert.Contains(t, buf.String(), "  - name: http-2\n    type: http\n")
+	viper.Reset()
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0o600))
+	reloaded, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, "[REDACTED]", reloaded.ExternalReadiness.Probes[1].HTTPHeaders["authorization"])
+	reloaded.ExternalReadiness.Probes[1].HTTPHeaders["authorization"] = "Bearer secret"
+	assert.Equal(t, cfg.ExternalReadiness, reloaded.ExternalReadiness)
+}
+
+func TestLoad_ExternalReadinessHTTPFlags(t *testing.T) {
+	viper.Reset()
+	t.Setenv("ECHO_APP_EXTERNAL_READINESS_PROBE_TYPE", "https")
+	t.Setenv("ECHO_APP_EXTERNAL_READINESS_PROBE_TARGET", "https://api.example.com/health")
+	t.Setenv("ECHO_APP_EXTERNAL_READINESS_HTTP_HEADERS", "Authorization: Bearer secret, X-Probe: echo-app")
+	t.Setenv("ECHO_APP_EXTERNAL_READINESS_HTTP_EXPECTED_STATUSES", "200-204")
+	t.Setenv("ECHO_APP_EXTERNAL_READINESS_HTTP_REDIRECT_POLICY", "same-host")
+	cfg, err := Load()
+	require.NoError(t, err)
+	probe := cfg.ExternalReadiness.Probes[0]
+	assert.Equal(t, map[string]string{"Authorization": "Bearer secret", "X-Probe": "echo-app"}, probe.HTTPHeaders)
+	assert.Equal(t, HTTPRedirectSameHost, probe.HTTPRedirectPolicy)
+	assert.Equal(t, "200-204", probe.ExpectedStatuses())
+
+	viper.Reset()
+	t.Setenv("ECHO_APP_EXTERNAL_READINESS_HTTP_HEADERS", "Authorization")
+	_, err = Load()
+	assert.EqualError(t, err, `invalid external readiness HTTP header "Authorization": must be Name: value`)
+}
+
+func TestExternalReadinessProbe_ExpectedStatusRanges(t *testing.T) {
+	tests := []struct {
+		name     string
+		probe    ExternalReadinessProbe
+		accepted []int
+		rejected []int
+	}{
+		{name: "single status", probe: ExternalReadinessProbe{HTTPExpectedStatus: 204}, accepted: []int{204}, rejected: []int{200, 503}},
+		{name: "range", probe: ExternalReadinessProbe{HTTPExpectedStatus: 204, HTTPExpectedStatuses: "200-299"}, accepted: []int{200, 204, 299}, rejected: []int{199, 300}},
+		{name: "list", probe: ExternalReadinessProbe{HTTPExpectedStatuses: "200, 301-302,404"}, accepted: []int{200, 301, 302, 404}, rejected: []int{201, 303, 500}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ranges, err := tt.probe.ExpectedStatusRanges()
+			require.NoError(t, err)
+			for _, code := range tt.accepted {
+				assert.True(t, ranges.Contains(code), code)
+			}
+			for _, code := range tt.rejected {
+				assert.False(t, ranges.Contains(code), code)
+			}
+		})
+	}
+
+	_, err := ExternalReadinessProbe{HTTPExpectedStatuses: "200-"}.ExpectedStatusRanges()
+	assert.ErrorContains(t, err, "invalid HTTP expected statuses")
+}
+
+func TestJSONPathSegments(t *testing.T) {
+	tests := []struct {
+		path    string
+		want    []any
+		wantErr bool
+	}{
+		{path: "$", want: nil},
+		{path: "$.status", want: []any{"status"}},
+		{path: "$.checks[0].state", want: []any{"checks", 0, "state"}},
+		{path: "$[2][1]", want: []any{2, 1}},
+		{path: "status", wantErr: true},
+		{path: "$.", wantErr: true},
+		{path: "$.checks[x]", wantErr: true},
+		{path: "$.checks[0", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := JSONPathSegments(tt.path)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestLoad_ExternalReadinessValidation(t *testing.T) {
+	tests := []struct {
+		name          string
+		content       string
+		expectedError string
+	}{
+		{
+			name:          "invalid policy",
+			content:       "external-readiness-policy: most",
+			expectedError: `invalid external readiness policy "most": must be all, any or quorum`,
+		},
+		{
+			name:          "quorum above probes",
+			content:       "external-readiness-quorum: 2\nexternal-readiness-probes: [{type: tcp, target: 'db:5432'}]",
+			expectedError: "external readiness quorum 2 must be between 0 and the number of probes (1)",
+		},
+		{
+			name:          "duplicate name",
+			content:       "external-readiness-probes: [{name: a, type: tcp, target: 'db:5432'}, {name: a, type: tcp, target: 'cache:6379'}]",
+			expectedError: "duplicate external readiness probe name: a",
+		},
+		{
+			name:          "invalid type",
+			content:       "external-readiness-probes: [{name: a, type: smtp, target: 'mail:25'}]",
+			expectedError: "external readiness probe a: invalid type: smtp",
+		},
+		{
+			name:          "missing target",
+			content:       "external-readiness-probes: [{name: a, type: tcp}]",
+			expectedError: "external readiness probe a: target is required",
+		},
+		{
+			name:          "zero thresholds",
+			content:       "external-readiness-probes: [{name: a, type: tcp, target: 'db:5432', success-threshold: -1, failure-threshold: -1}]",
+			expectedError: "external readiness probe a: success threshold must be at least 1\nexternal readiness probe a: failure threshold must be at least 1",
+		},
+		{
+			name:          "max interval below interval",
+			content:       "external-readiness-probes: [{name: a, type: tcp, target: 'db:5432', interval: 10s, max-interval: 5s}]",
+			expectedError: "external readiness probe a: max interval must be 0 or at least the interval",
+		},
+		{
+			name:          "invalid expected statuses",
+			content:       "external-readiness-probes: [{name: a, type: http, target: 'http://api', http-expected-statuses: '299-200'}]",
+			expectedError: `external readiness probe a: invalid HTTP expected statuses "299-200"`,
+		},
+		{
+			name:          "invalid body regex",
+			content:       "external-readiness-probes: [{name: a, type: http, target: 'http://api', http-body-regex: '(up'}]",
+			expectedError: "external readiness probe a: invalid HTTP body regex",
+		},
+		{
+			name:          "invalid JSON path",
+			content:       "external-readiness-probes: [{name: a, type: http, target: 'http://api', http-body-json-path: 'status'}]",
+			expectedError: `external readiness probe a: invalid JSON path "status"`,
+		},
+		{
+			name:          "JSON value without path",
+			content:       "external-readiness-probes: [{name: a, type: http, target: 'http://api', http-body-json-value: UP}]",
+			expectedError: "external readiness probe a: HTTP body JSON value requires a JSON path",
+		},
+		{
+			name:          "invalid redirect policy",
+			content:       "external-readiness-probes: [{name: a, type: http, target: 'http://api', http-redirect-policy: never}]",
+			expectedError: `external readiness probe a: invalid HTTP redirect policy "never"`,
+		},
+		{
+			name:          "client certificate without key",
+			content:       "external-readiness-probes: [{name: a, type: https, target: 'https://api', tls-cert-file: /tls/client.crt}]",
+			expectedError: "external readiness probe a: TLS client certificate and key files must be set together",
+		},
+		{
+			name:          "missing CA file",
+			content:       "external-readiness-probes: [{name: a, type: https, target: 'https://api', tls-ca-file: /nonexistent/ca.pem}]",
+			expectedError: "external readiness probe a: read TLS CA file: open /nonexistent/ca.pem",
+		},
+		{
+			name:          "CA file without certificates",
+			content:       "external-readiness-probes: [{name: a, type: tls, target: 'api:443', tls-ca-file: config_test.go}]",
+			expectedError: "external readiness probe a: no PEM certificates in TLS CA file config_test.go",
+		},
+		{
+			name:          "unreadable client certificate",
+			content:       "external-readiness-probes: [{name: a, type: grpc, target: 'api:50051', tls-cert-file: config_test.go, tls-key-file: config_test.go}]",
+			expectedError: "external readiness probe a: load TLS client certificate",
+		},
+		{
+			name:          "invalid header name",
+			content:       "external-readiness-probes: [{name: a, type: http, target: 'http://api', http-headers: {'bad header': x}}]",
+			expectedError: `external readiness probe a: invalid HTTP header name: "bad header"`,
+		},
+		{
+			name:          "gRPC target without port",
+			content:       "external-readiness-probes: [{name: a, type: grpc, target: billing}]",
+			expectedError: `external readiness probe a: grpc target must be host:port: "billing"`,
+		},
+		{
+			name:          "invalid DNS record type",
+			content:       "external-readiness-probes: [{name: a, type: dns, target: example.com, dns-record-type: ptr}]",
+			expectedError: `external readiness probe a: invalid DNS record type "PTR": must be one of IP, A, AAAA, CNAME, SRV, TXT, MX`,
+		},
+		{
+			name:          "negative TLS minimum validity",
+			content:       "external-readiness-probes: [{name: a, type: tls, target: 'example.com:443', tls-min-validity: -1h}]",
+			expectedError: "external readiness probe a: TLS minimum validity must not be negative",
+		},
+		{
+			name:          "unknown field type",
+			content:       "external-readiness-probes: [{name: a, type: tcp, target: 'db:5432', interval: [1]}]",
+			expectedError: "invalid external readiness probes",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Reset()
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			require.NoError(t, os.WriteFile(path, []byte(tt.content), 0o600))
+			t.Setenv("ECHO_APP_CONFIG", path)
+
+			cfg, err := Load()
+			assert.ErrorContains(t, err, tt.expectedError)
+			assert.Nil(t, cfg)
+		})
+	}
+}
+
+func TestLoad_InvalidConfigKeepsLogging(t *testing.T) {
+	defer logrus.SetLevel(logrus.InfoLevel)
+	viper.Reset()
+	logrus.SetLevel(logrus.WarnLevel)
+
+	viper.Set("log-level", "debug")
+	viper.Set("request-id-header", "X Request ID")
+	_, err := Load()
+	require.Error(t, err)
+	assert.Equal(t, logrus.WarnLevel, logrus.GetLevel())
+
+	// Valid settings only apply once the caller applies them
+	viper.Set("request-id-header", "X-Request-ID")
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, logrus.WarnLevel, logrus.GetLevel())
+	cfg.ApplyLogging()
+	assert.Equal(t, logrus.DebugLevel, logrus.GetLevel())
+}
+
+func TestConfig_CurrentAndUpdate(t *testing.T) {
+	cfg := &Config{Message: "v1"}
+	lc := cfg.ForListener(Listener{Name: "HTTP", Protocol: ListenerHTTP, Port: "8080"})
+	assert.Same(t, lc, lc.Current())
+
+	// Updates reach every holder of the listener configuration
+	next := (&Config{Message: "v2"}).ForListener(Listener{Name: "HTTP", Protocol: ListenerHTTP, Port: "8080"})
+	lc.Update(next)
+	assert.Equal(t, "v2", lc.Current().Message)
+	assert.Equal(t, "v2", next.Current().Message)
+	assert.Equal(t, "8080", lc.Current().HTTPPort)
+
+	// Configurations not created by ForListener are not live
+	cfg.Update(&Config{Message: "v3"})
+	assert.Same(t, cfg, cfg.Current())
+	assert.Equal(t, "v1", cfg.Message)
+}
+
+func TestLoad_ReportsAllErrors(t *testing.T) {
+	viper.Reset()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+log-format: xml
+metrics-port: "8080"
+request-id-header: "X Request ID"
+listeners:
+  - {name: a, protocol: http, port: 8080}
+  - {name: b, protocol: smtp, port: 70000}
+`), 0o600))
+	_ = os.Setenv("ECHO_APP_CONFIG", path)
+	defer func() { _ = os.Unsetenv("ECHO_APP_CONFIG") }()
+
+	cfg, err := Load()
+	assert.Nil(t, cfg)
+	var messages []string
+	for _, err := range Errors(err) {
+		messages = append(messages, err.Error())
+	}
+	assert.Equal(t, []string{
+		"invalid log format: xml",
+		`listener b: invalid protocol "smtp"`,
+		"listener b: invalid port: 70000",
+		"port 8080/tcp is used by more than one listener: a, Metrics",
+		`invalid request ID header: "X Request ID"`,
+	}, messages)
+}
+
+func TestValidate_PortCollisions(t *testing.T) {
+	tests := []struct {
+		name          string
+		listeners     []Listener
+		metrics       bool
+		expectedError string
+	}{
+		{
+			name: "distinct ports",
+			listeners: []Listener{
+				{Name: "a", Protocol: ListenerHTTP, Port: "8080"},
+				{Name: "b", Protocol: ListenerTLS, Port: "8443"},
+			},
+			metrics: true,
+		},
+		{
+			name: "QUIC shares a port with TCP listeners",
+			listeners: []Listener{
+				{Name: "https", Protocol: ListenerTLS, Port: "443"},
+				{Name: "h3", Protocol: ListenerQUIC, Port: "443"},
+			},
+		},
+		{
+			name: "two TCP listeners",
+			listeners: []Listener{
+				{Name: "a", Protocol: ListenerHTTP, Port: "8080"},
+				{Name: "b", Protocol: ListenerGRPC, Port: "8080"},
+				{Name: "c", Protocol: ListenerTCP, Port: "8080"},
+			},
+			expectedError: "port 8080/tcp is used by more than one listener: a, b, c",
+		},
+		{
+			name: "two QUIC listeners",
+			listeners: []Listener{
+				{Name: "a", Protocol: ListenerQUIC, Port: "4433"},
+				{Name: "b", Protocol: ListenerQUIC, Port: "4433"},
+			},
+			expectedError: "port 4433/udp is used by more than one listener: a, b",
+		},
+		{
+			name: "distinct hosts",
+			listeners: []Listener{
+				{Name: "a", Protocol: ListenerHTTP, Address: "127.0.0.1:8080"},
+				{Name: "b", Protocol: ListenerHTTP, Address: "10.0.0.1:8080"},
+				{Name: "c", Protocol: ListenerHTTP, Address: "[::1]:8080"},
+			},
+		},
+		{
+			name: "all interfaces overlap a host",
+			listeners: []Listener{
+				{Name: "a", Protocol: ListenerHTTP, Port: "8080"},
+				{Name: "b", Protocol: ListenerTCP, Address: "127.0.0.1:8080"},
+			},
+			expectedError: "port 8080/tcp is used by more than one listener: a, b",
+		},
+		{
+			name: "ephemeral ports",
+			listeners: []Listener{
+				{Name: "a", Protocol: ListenerHTTP, Port: "0"},
+				{Name: "b", Protocol: ListenerTCP, Port: "0"},
+				{Name: "c", Protocol: ListenerGRPC, Address: "127.0.0.1:0"},
+			},
+		},
+		{
+			name: "IPv4 wildcard and IPv6 host",
+			listeners: []Listener{
+				{Name: "a", Protocol: ListenerHTTP, Address: "0.0.0.0:8080"},
+				{Name: "b", Protocol: ListenerHTTP, Address: "[::1]:8080"},
+			},
+		},
+		{
+			name: "unix sockets",
+			listeners: []Listener{
+				{Name: "a", Protocol: ListenerHTTP, Address: "unix:///run/a.sock"},
+				{Name: "b", Protocol: ListenerGRPC, Address: "unix:///run/b.sock"},
+				{Name: "c", Protocol: ListenerTCP, Address: "unix:///run/a.sock"},
+			},
+			expectedError: "socket /run/a.sock is used by more than one listener: a, c",
+		},
+		{
+			name:          "metrics server",
+			listeners:     []Listener{{Name: "a", Protocol: ListenerHTTP, Port: "3000"}},
+			metrics:       true,
+			expectedError: "port 3000/tcp is used by more than one listener: a, Metrics",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{Listeners: tt.listeners, Metrics: tt.metrics, MetricsPort: "3000"}
+			err := cfg.validatePorts()
+			if tt.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.expectedError)
+			}
+		})
+	}
+}
+
+func TestLoad_Addresses(t *testing.T) {
+	viper.Reset()
+	t.Setenv("ECHO_APP_HTTP_ADDRESS", "127.0.0.1:8081")
+	t.Setenv("ECHO_APP_TCP", "true")
+	t.Setenv("ECHO_APP_TCP_ADDRESS", "unix:///run/echo/tcp.sock")
+	t.Setenv("ECHO_APP_QUIC", "true")
+	t.Setenv("ECHO_APP_QUIC_ADDRESS", "[::1]:4434")
+	t.Setenv("ECHO_APP_SOCKET_MODE", "0660")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, "8081", cfg.HTTPPort)
+	assert.Equal(t, "", cfg.TCPPort)
+	assert.Equal(t, "4434", cfg.QUICPort)
+	assert.Equal(t, ":3000", cfg.MetricsAddress)
+	assert.Equal(t, os.FileMode(0o660), cfg.SocketMode)
+	assert.Equal(t, []Listener{
+		{Name: "HTTP", Protocol: ListenerHTTP, Port: "8081", Address: "127.0.0.1:8081"},
+		{Name: "TCP", Protocol: ListenerTCP, Address: "unix:///run/echo/tcp.sock"},
+		{Name: "QUIC", Protocol: ListenerQUIC, Port: "4434", Address: "[::1]:4434"},
+	}, cfg.Listeners)
+}
+
+func TestLoad_InvalidSocketMode(t *testing.T) {
+	viper.Reset()
+	t.Setenv("ECHO_APP_SOCKET_MODE", "rw")
+	_, err := Load()
+	assert.EqualError(t, err, `invalid socket mode "rw": must be octal permissions such as 0660`)
+}
+
+func TestLoad_Drain(t *testing.T) {
+	viper.Reset()
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, time.Duration(0), cfg.DrainDelay)
+	assert.Equal(t, 30*time.Second, cfg.ShutdownTimeout)
+
+	viper.Reset()
+	t.Setenv("ECHO_APP_DRAIN_DELAY", "10s")
+	t.Setenv("ECHO_APP_SHUTDOWN_TIMEOUT", "45s")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, 10*time.Second, cfg.DrainDelay)
+	assert.Equal(t, 45*time.Second, cfg.ShutdownTimeout)
+
+	viper.Reset()
+	t.Setenv("ECHO_APP_DRAIN_DELAY", "-1s")
+	t.Setenv("ECHO_APP_SHUTDOWN_TIMEOUT", "0s")
+	_, err = Load()
+	assert.EqualError(t, err, "drain delay must not be negative\nshutdown timeout must be positive")
+}
+
+func TestLoad_ListenerFailurePolicy(t *testing.T) {
+	viper.Reset()
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, FailurePolicyExit, cfg.ListenerFailurePolicy)
+
+	viper.Reset()
+	t.Setenv("ECHO_APP_LISTENER_FAILURE_POLICY", "Restart")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, FailurePolicyRestart, cfg.ListenerFailurePolicy)
+
+	viper.Reset()
+	t.Setenv("ECHO_APP_LISTENER_FAILURE_POLICY", "ignore")
+	_, err = Load()
+	assert.EqualError(t, err, `invalid listener failure policy "ignore": must be exit, restart or degrade`)
+}
+
+func TestConfig_WriteEffectiveRedactsProbeHeaders(t *testing.T) {
+	viper.Reset()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+external-readiness-probes:
+  - name: api
+    type: https
+    target: https://api.example.com/health
+    http-headers:
+      Authorization: Bearer hunter2
+      X-Api-Key: swordfish
+`), 0o600))
+	_ = os.Setenv("ECHO_APP_CONFIG", path)
+	_ = os.Setenv("ECHO_APP_EXTERNAL_READINESS_HTTP_HEADERS", "Authorization: Bearer flag-secret, X-Tenant: acme")
+	defer func() {
+		_ = os.Unsetenv("ECHO_APP_CONFIG")
+		_ = os.Unsetenv("ECHO_APP_EXTERNAL_READINESS_HTTP_HEADERS")
+	}()
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	require.NoError(t, cfg.WriteEffective(&buf))
+	out := buf.String()
+
+	for _, secret := range []string{"hunter2", "swordfish", "flag-secret", "acme"} {
+		assert.NotContains(t, out, secret)
+	}
+	assert.Contains(t, out, "external-readiness-http-headers: 'Authorization: [REDACTED],X-Tenant: [REDACTED]'\n")
+	assert.Contains(t, out, `    http-headers:
+      authorization: '[REDACTED]'
+      x-api-key: '[REDACTED]'
+`)
+	// The loaded configuration keeps the values
+	assert.Equal(t, "Bearer hunter2", cfg.ExternalReadiness.Probes[0].HTTPHeaders["authorization"])
+}
+
+func TestConfig_Hash(t *testing.T) {
+	base := Default()
+	same := Default()
+	assert.Len(t, base.Hash(), 64)
+	assert.Equal(t, base.Hash(), same.Hash())
+
+	// Secrets do not affect the hash
+	same.AdminToken = "swordfish"
+	same.Diagnostics.Token = "hunter2"
+	assert.Equal(t, base.Hash(), same.Hash())
+
+	changed := Default()
+	changed.Message = "hello"
+	assert.NotEqual(t, base.Hash(), changed.Hash())
+}
+
+func TestConfig_WriteEffective(t *testing.T) {
+	viper.Reset()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+diagnostics-token: hunter2
+admin-token: swordfish
+log-level: DEBUG
+listeners:
+  - {name: web, protocol: http, port: 8080, message: web}
+  - {protocol: quic, port: 4433}
+`), 0o600))
+	_ = os.Setenv("ECHO_APP_CONFIG", path)
+	defer func() { _ = os.Unsetenv("ECHO_APP_CONFIG") }()
+	defer logrus.SetLevel(logrus.InfoLevel)
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	require.NoError(t, cfg.WriteEffective(&buf))
+	out := buf.String()
+
+	assert.Contains(t, out, "diagnostics-token: '[REDACTED]'\n")
+	assert.NotContains(t, out, "hunter2")
+	assert.Contains(t, out, "admin-token: '[REDACTED]'\n")
+	assert.NotContains(t, out, "swordfish")
+	assert.Contains(t, out, "log-level: debug\n")
+	assert.Contains(t, out, "chain-timeout: 5s\n")
+	assert.Contains(t, out, "quic: true\n")
+	assert.Contains(t, out, "http-address: :8080\n")
+	assert.Contains(t, out, `listeners:
+  - name: web
+    protocol: http
+    port: "8080"
+    address: :8080
+    message: web
+  - name: quic-4433
+    protocol: quic
+    port: "4433"
+    address: :4433
+`)
+
+	// The output loads as a config file with the same settings
+	viper.Reset()
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0o600))
+	reloaded, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, cfg.Listeners, reloaded.Listeners)
+	assert.Equal(t, cfg.Chain, reloaded.Chain)
 }
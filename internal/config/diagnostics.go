@@ -0,0 +1,51 @@
+package config
+
+import (
+	"fmt"
+	"net/netip"
+	"time"
+)
+
+// MaxDiagnosticsTimeout keeps diagnostics below the metrics server's 10s
+// write timeout so slow targets are reported instead of cut off
+const MaxDiagnosticsTimeout = 9 * time.Second
+
+// DefaultDiagnosticsAllowedCIDRs only admits loopback callers, such as
+// kubectl port-forward sessions
+const DefaultDiagnosticsAllowedCIDRs = "127.0.0.0/8,::1/128"
+
+// Diagnostics configures the network debugging endpoints on the metrics server
+type Diagnostics struct {
+	Enabled      bool
+	Token        string         // Bearer token required when set
+	AllowedCIDRs []netip.Prefix // Source networks allowed to call the endpoints; empty allows all
+	Timeout      time.Duration  // Timeout for each lookup, dial or request
+}
+
+// validate checks the diagnostics settings for consistency
+func (d Diagnostics) validate() error {
+	if !d.Enabled {
+		return nil
+	}
+	if d.Timeout <= 0 || d.Timeout > MaxDiagnosticsTimeout {
+		return fmt.Errorf("diagnostics timeout must be between 0 and %s", MaxDiagnosticsTimeout)
+	}
+	return nil
+}
+
+// parsePrefixes parses CIDRs and plain IP addresses, which match only themselves
+func parsePrefixes(values []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(values))
+	for _, value := range values {
+		if addr, err := netip.ParseAddr(value); err == nil {
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid diagnostics allowed CIDR: %q", value)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
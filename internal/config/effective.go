@@ -0,0 +1,119 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+	"go.yaml.in/yaml/v3"
+)
+
+// redacted replaces secrets in the effective configuration
+const redacted = "[REDACTED]"
+
+// secretSettings are the settings redacted by WriteEffective
+var secretSettings = []string{"diagnostics-token", "admin-token"}
+
+// WriteEffective writes the fully resolved configuration as a YAML config
+// file, with the resolved listeners and secrets redacted
+func (c *Config) WriteEffective(w io.Writer) error {
+	settings := viper.AllSettings()
+	delete(settings, "validate-only")
+	for key, value := range settings {
+		// Durations would otherwise be written in nanoseconds
+		if d, ok := value.(time.Duration); ok {
+			settings[key] = d.String()
+		}
+	}
+	for _, key := range secretSettings {
+		if value, ok := settings[key]; ok && value != "" {
+			settings[key] = redacted
+		}
+	}
+
+	// Probe headers carry credentials, so only their names are written
+	if value, ok := settings["external-readiness-http-headers"]; ok && value != "" {
+		names := slices.Sorted(maps.Keys(c.ExternalReadinessProbe.HTTPHeaders))
+		for i, name := range names {
+			names[i] = name + ": " + redacted
+		}
+		settings["external-readiness-http-headers"] = strings.Join(names, ",")
+	}
+
+	// Settings Load derives or normalizes are written as resolved
+	settings["listeners"] = c.Listeners
+	if _, ok := settings["external-readiness-probes"]; ok {
+		probes := slices.Clone(c.ExternalReadiness.Probes)
+		for i := range probes {
+			probes[i].HTTPHeaders = redactValues(probes[i].HTTPHeaders)
+		}
+		settings["external-readiness-probes"] = probes
+	}
+	for key, value := range map[string]any{
+		"tls":                       c.TLS,
+		"h2c":                       c.H2C,
+		"tcp":                       c.TCP,
+		"grpc":                      c.GRPC,
+		"grpc-tls":                  c.GRPCTLS,
+		"quic":                      c.QUIC,
+		"webtransport":              c.WebTransport,
+		"http-port":                 c.HTTPPort,
+		"tls-port":                  c.TLSPort,
+		"tcp-port":                  c.TCPPort,
+		"grpc-port":                 c.GRPCPort,
+		"quic-port":                 c.QUICPort,
+		"http-address":              c.HTTPAddress,
+		"tls-address":               c.TLSAddress,
+		"tcp-address":               c.TCPAddress,
+		"grpc-address":              c.GRPCAddress,
+		"quic-address":              c.QUICAddress,
+		"metrics-port":              c.MetricsPort,
+		"metrics-address":           c.MetricsAddress,
+		"log-level":                 c.LogLevel.String(),
+		"log-format":                c.LogFormat,
+		"tcp-format":                c.TCPFormat,
+		"listener-failure-policy":   c.ListenerFailurePolicy,
+		"external-readiness-policy": c.ExternalReadiness.Policy,
+	} {
+		settings[key] = value
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(settings); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// redactValues returns a copy of headers with every value redacted
+func redactValues(headers map[string]string) map[string]string {
+	if headers == nil {
+		return nil
+	}
+	redactedHeaders := make(map[string]string, len(headers))
+	for name := range headers {
+		redactedHeaders[name] = redacted
+	}
+	return redactedHeaders
+}
+
+// Hash identifies the settings of c, leaving out secrets, so deployments can
+// tell which configuration a running instance applied
+func (c *Config) Hash() string {
+	settings := *c
+	settings.Diagnostics.Token = ""
+	settings.AdminToken = ""
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -1,19 +1,398 @@
 package config
 
-import "time"
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+	"golang.org/x/net/http/httpguts"
+)
+
+// External readiness policies, combining the results of several probes
+const (
+	ReadinessPolicyAll    = "all"    // Ready while every probe passes
+	ReadinessPolicyAny    = "any"    // Ready while at least one probe passes
+	ReadinessPolicyQuorum = "quorum" // Ready while at least Quorum probes pass
+)
+
+// FlagProbeName names the probe configured by the external-readiness-probe
+// flags
+const FlagProbeName = "external"
 
 // ExternalReadinessProbe configures an optional background dependency check that
 // contributes to this application's readiness state.
 type ExternalReadinessProbe struct {
-	Type               string
-	Target             string
-	Interval           time.Duration
-	Timeout            time.Duration
-	HTTPMethod         string
-	HTTPExpectedStatus int
+	Name               string        `mapstructure:"name" yaml:"name"`
+	Type               string        `mapstructure:"type" yaml:"type"`
+	Target             string        `mapstructure:"target" yaml:"target"`
+	Interval           time.Duration `mapstructure:"interval" yaml:"interval"`
+	Timeout            time.Duration `mapstructure:"timeout" yaml:"timeout"`
+	SuccessThreshold   int           `mapstructure:"success-threshold" yaml:"success-threshold"` // Consecutive passing checks to become ready
+	FailureThreshold   int           `mapstructure:"failure-threshold" yaml:"failure-threshold"` // Consecutive failing checks to become not ready
+	MaxInterval        time.Duration `mapstructure:"max-interval" yaml:"max-interval,omitempty"` // Cap of the interval backing off while not ready, 0 disables backoff
+	HTTPMethod         string        `mapstructure:"http-method" yaml:"http-method,omitempty"`
+	HTTPExpectedStatus int           `mapstructure:"http-expected-status" yaml:"http-expected-status,omitempty"`
+
+	HTTPHeaders          map[string]string `mapstructure:"http-headers" yaml:"http-headers,omitempty"`                     // Request headers
+	HTTPBody             string            `mapstructure:"http-body" yaml:"http-body,omitempty"`                           // Request body
+	HTTPExpectedStatuses string            `mapstructure:"http-expected-statuses" yaml:"http-expected-statuses,omitempty"` // Accepted codes and ranges such as 200-299,304, replacing HTTPExpectedStatus
+	HTTPBodyContains     string            `mapstructure:"http-body-contains" yaml:"http-body-contains,omitempty"`         // Substring the response body must contain
+	HTTPBodyRegex        string            `mapstructure:"http-body-regex" yaml:"http-body-regex,omitempty"`               // Regular expression the response body must match
+	HTTPBodyJSONPath     string            `mapstructure:"http-body-json-path" yaml:"http-body-json-path,omitempty"`       // Value the JSON response body must have, such as $.status
+	HTTPBodyJSONValue    string            `mapstructure:"http-body-json-value" yaml:"http-body-json-value,omitempty"`     // Expected value at HTTPBodyJSONPath, any if empty
+	HTTPRedirectPolicy   string            `mapstructure:"http-redirect-policy" yaml:"http-redirect-policy,omitempty"`     // One of the HTTPRedirect constants
+
+	GRPCService           string        `mapstructure:"grpc-service" yaml:"grpc-service,omitempty"`                         // Service asked by gRPC probes, empty for the whole server
+	GRPCTLS               bool          `mapstructure:"grpc-tls" yaml:"grpc-tls,omitempty"`                                 // Whether gRPC probes connect with TLS
+	DNSRecordType         string        `mapstructure:"dns-record-type" yaml:"dns-record-type,omitempty"`                   // One of the DNSRecordTypes, IP if empty
+	DNSServer             string        `mapstructure:"dns-server" yaml:"dns-server,omitempty"`                             // host[:port] of the DNS server, empty for the system resolver
+	DNSExpectedRecords    []string      `mapstructure:"dns-expected-records" yaml:"dns-expected-records,omitempty"`         // Records the DNS answer must contain
+	TLSServerName         string        `mapstructure:"tls-server-name" yaml:"tls-server-name,omitempty"`                   // SNI and verified name, defaults to the target host
+	TLSMinValidity        time.Duration `mapstructure:"tls-min-validity" yaml:"tls-min-validity,omitempty"`                 // How long certificates must remain valid
+	TLSInsecureSkipVerify bool          `mapstructure:"tls-insecure-skip-verify" yaml:"tls-insecure-skip-verify,omitempty"` // Skip verifying the certificate chain and name
+	TLSCAFile             string        `mapstructure:"tls-ca-file" yaml:"tls-ca-file,omitempty"`                           // PEM CA certificates verifying the server, instead of the system roots
+	TLSCertFile           string        `mapstructure:"tls-cert-file" yaml:"tls-cert-file,omitempty"`                       // PEM client certificate
+	TLSKeyFile            string        `mapstructure:"tls-key-file" yaml:"tls-key-file,omitempty"`                         // PEM key of the client certificate
+}
+
+// Redirect policies of HTTP probes
+const (
+	HTTPRedirectFollow   = "follow"    // Follow up to 10 redirects
+	HTTPRedirectNone     = "none"      // Assert on the redirect response itself
+	HTTPRedirectSameHost = "same-host" // Follow redirects to the target's host only
+)
+
+// DNSRecordTypes lists the record types DNS probes can look up; IP queries
+// both A and AAAA
+var DNSRecordTypes = []string{"IP", "A", "AAAA", "CNAME", "SRV", "TXT", "MX"}
+
+// ExternalReadiness combines the external readiness probes into the
+// application's readiness
+type ExternalReadiness struct {
+	Probes []ExternalReadinessProbe
+	Policy string // One of the ReadinessPolicy constants
+	Quorum int    // Passing probes required by the quorum policy, 0 for a majority
 }
 
 // Enabled reports whether an external readiness probe is configured.
 func (p ExternalReadinessProbe) Enabled() bool {
 	return p.Type != "" && p.Type != "none" && p.Target != ""
 }
+
+// Enabled reports whether any external readiness probe is configured
+func (r ExternalReadiness) Enabled() bool {
+	return len(r.Probes) > 0
+}
+
+// Equal reports whether r and other configure the same probes and policy
+func (r ExternalReadiness) Equal(other ExternalReadiness) bool {
+	return reflect.DeepEqual(r, other)
+}
+
+// Equal reports whether p and other configure the same probe
+func (p ExternalReadinessProbe) Equal(other ExternalReadinessProbe) bool {
+	return reflect.DeepEqual(p, other)
+}
+
+// Required returns the number of passing probes readiness requires under the
+// policy
+func (r ExternalReadiness) Required() int {
+	switch r.Policy {
+	case ReadinessPolicyAny:
+		return min(1, len(r.Probes))
+	case ReadinessPolicyQuorum:
+		if r.Quorum > 0 {
+			return r.Quorum
+		}
+		return len(r.Probes)/2 + 1
+	default:
+		return len(r.Probes)
+	}
+}
+
+// loadReadinessProbes reads the external readiness probes of the config file
+// in v, if any, or uses the probe configured by the flags. Probes in the file
+// default to the flags' interval, timeout, thresholds and HTTP and DNS
+// settings.
+func loadReadinessProbes(v *viper.Viper, cfg *Config) error {
+	flagProbe := cfg.ExternalReadinessProbe
+	if !v.IsSet("external-readiness-probes") {
+		if flagProbe.Enabled() {
+			flagProbe.Name = FlagProbeName
+			cfg.ExternalReadiness.Probes = []ExternalReadinessProbe{flagProbe}
+		}
+		return nil
+	}
+	var probes []ExternalReadinessProbe
+	if err := v.UnmarshalKey("external-readiness-probes", &probes); err != nil {
+		return fmt.Errorf("invalid external readiness probes: %w", err)
+	}
+	for i := range probes {
+		p := &probes[i]
+		p.Type, p.HTTPMethod, p.DNSRecordType = strings.ToLower(p.Type), strings.ToUpper(p.HTTPMethod), strings.ToUpper(p.DNSRecordType)
+		p.HTTPRedirectPolicy = strings.ToLower(p.HTTPRedirectPolicy)
+		if p.Name == "" {
+			p.Name = p.Type + "-" + strconv.Itoa(i+1)
+		}
+		if p.Interval == 0 {
+			p.Interval = flagProbe.Interval
+		}
+		if p.Timeout == 0 {
+			p.Timeout = flagProbe.Timeout
+		}
+		if p.SuccessThreshold == 0 {
+			p.SuccessThreshold = flagProbe.SuccessThreshold
+		}
+		if p.FailureThreshold == 0 {
+			p.FailureThreshold = flagProbe.FailureThreshold
+		}
+		if p.MaxInterval == 0 {
+			p.MaxInterval = flagProbe.MaxInterval
+		}
+		if p.HTTPMethod == "" {
+			p.HTTPMethod = flagProbe.HTTPMethod
+		}
+		if p.HTTPExpectedStatus == 0 {
+			p.HTTPExpectedStatus = flagProbe.HTTPExpectedStatus
+		}
+		if p.DNSRecordType == "" {
+			p.DNSRecordType = flagProbe.DNSRecordType
+		}
+		if p.HTTPRedirectPolicy == "" {
+			p.HTTPRedirectPolicy = flagProbe.HTTPRedirectPolicy
+		}
+	}
+	cfg.ExternalReadiness.Probes = probes
+	return nil
+}
+
+// validate checks the probe settings for consistency
+func (p ExternalReadinessProbe) validate() error {
+	var errs []error
+	// Keep "ping" as a compatibility alias for the in-process ICMP probe.
+	switch p.Type {
+	case "http", "https", "tcp", "ping", "icmp":
+	case "dns":
+		if p.DNSRecordType != "" && !slices.Contains(DNSRecordTypes, p.DNSRecordType) {
+			errs = append(errs, fmt.Errorf("invalid DNS record type %q: must be one of %s", p.DNSRecordType, strings.Join(DNSRecordTypes, ", ")))
+		}
+	case "grpc", "tls":
+		if _, _, err := net.SplitHostPort(p.Target); p.Target != "" && err != nil {
+			errs = append(errs, fmt.Errorf("%s target must be host:port: %q", p.Type, p.Target))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("invalid type: %s", p.Type))
+	}
+	if p.Target == "" {
+		errs = append(errs, fmt.Errorf("target is required"))
+	}
+	if p.TLSMinValidity < 0 {
+		errs = append(errs, fmt.Errorf("TLS minimum validity must not be negative"))
+	}
+	if p.Interval <= 0 {
+		errs = append(errs, fmt.Errorf("interval must be greater than zero"))
+	}
+	if p.Timeout <= 0 {
+		errs = append(errs, fmt.Errorf("timeout must be greater than zero"))
+	}
+	if p.SuccessThreshold < 1 {
+		errs = append(errs, fmt.Errorf("success threshold must be at least 1"))
+	}
+	if p.FailureThreshold < 1 {
+		errs = append(errs, fmt.Errorf("failure threshold must be at least 1"))
+	}
+	if p.MaxInterval != 0 && p.MaxInterval < p.Interval {
+		errs = append(errs, fmt.Errorf("max interval must be 0 or at least the interval"))
+	}
+	if p.HTTPExpectedStatus < 100 || p.HTTPExpectedStatus > 599 {
+		errs = append(errs, fmt.Errorf("HTTP expected status must be between 100 and 599"))
+	}
+	if _, err := parseStatusRanges(p.HTTPExpectedStatuses); err != nil {
+		errs = append(errs, err)
+	}
+	for name := range p.HTTPHeaders {
+		if !httpguts.ValidHeaderFieldName(name) {
+			errs = append(errs, fmt.Errorf("invalid HTTP header name: %q", name))
+		}
+	}
+	if _, err := regexp.Compile(p.HTTPBodyRegex); err != nil {
+		errs = append(errs, fmt.Errorf("invalid HTTP body regex: %w", err))
+	}
+	if p.HTTPBodyJSONPath != "" {
+		if _, err := JSONPathSegments(p.HTTPBodyJSONPath); err != nil {
+			errs = append(errs, err)
+		}
+	} else if p.HTTPBodyJSONValue != "" {
+		errs = append(errs, fmt.Errorf("HTTP body JSON value requires a JSON path"))
+	}
+	switch p.HTTPRedirectPolicy {
+	case "", HTTPRedirectFollow, HTTPRedirectNone, HTTPRedirectSameHost:
+	default:
+		errs = append(errs, fmt.Errorf("invalid HTTP redirect policy %q: must be follow, none or same-host", p.HTTPRedirectPolicy))
+	}
+	if (p.TLSCertFile == "") != (p.TLSKeyFile == "") {
+		errs = append(errs, fmt.Errorf("TLS client certificate and key files must be set together"))
+	} else if _, err := p.TLSConfig(); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// TLSConfig returns the TLS settings of the probe: the server name, the CA
+// verifying the server or skipping verification, and the client certificate.
+// It reads the certificate files.
+func (p ExternalReadinessProbe) TLSConfig() (*tls.Config, error) {
+	cfg := &tls.Config{ServerName: p.TLSServerName, InsecureSkipVerify: p.TLSInsecureSkipVerify} // #nosec G402 -- opt-in for self-signed dependencies
+	if p.TLSCAFile != "" {
+		pem, err := os.ReadFile(p.TLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read TLS CA file: %w", err)
+		}
+		cfg.RootCAs = x509.NewCertPool()
+		if !cfg.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no PEM certificates in TLS CA file %s", p.TLSCAFile)
+		}
+	}
+	if p.TLSCertFile != "" {
+		cert, err := tls.LoadX509KeyPair(p.TLSCertFile, p.TLSKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load TLS client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+// parseHeaders parses "Name: value" headers, as curl -H takes them
+func parseHeaders(values []string) (map[string]string, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	headers := make(map[string]string, len(values))
+	for _, value := range values {
+		name, content, ok := strings.Cut(value, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid external readiness HTTP header %q: must be Name: value", value)
+		}
+		headers[strings.TrimSpace(name)] = strings.TrimSpace(content)
+	}
+	return headers, nil
+}
+
+// StatusRanges are inclusive ranges of HTTP status codes
+type StatusRanges [][2]int
+
+// Contains reports whether code is in one of the ranges
+func (r StatusRanges) Contains(code int) bool {
+	return slices.ContainsFunc(r, func(r [2]int) bool { return code >= r[0] && code <= r[1] })
+}
+
+// ExpectedStatusRanges parses the expected statuses of HTTP probes:
+// HTTPExpectedStatuses if set, HTTPExpectedStatus otherwise
+func (p ExternalReadinessProbe) ExpectedStatusRanges() (StatusRanges, error) {
+	ranges, err := parseStatusRanges(p.HTTPExpectedStatuses)
+	if err != nil || len(ranges) > 0 {
+		return ranges, err
+	}
+	return StatusRanges{{p.HTTPExpectedStatus, p.HTTPExpectedStatus}}, nil
+}
+
+// ExpectedStatuses describes the expected statuses of HTTP probes
+func (p ExternalReadinessProbe) ExpectedStatuses() string {
+	if p.HTTPExpectedStatuses != "" {
+		return p.HTTPExpectedStatuses
+	}
+	return strconv.Itoa(p.HTTPExpectedStatus)
+}
+
+// parseStatusRanges parses a comma-separated list of HTTP status codes and
+// ranges such as 200-299,304 into inclusive ranges
+func parseStatusRanges(value string) (StatusRanges, error) {
+	var ranges StatusRanges
+	for _, item := range splitList(value) {
+		low, high, isRange := strings.Cut(item, "-")
+		from, errFrom := strconv.Atoi(strings.TrimSpace(low))
+		to, errTo := from, error(nil)
+		if isRange {
+			to, errTo = strconv.Atoi(strings.TrimSpace(high))
+		}
+		if errFrom != nil || errTo != nil || from < 100 || to > 599 || from > to {
+			return nil, fmt.Errorf("invalid HTTP expected statuses %q: must be codes or ranges between 100 and 599 such as 200-299,304", value)
+		}
+		ranges = append(ranges, [2]int{from, to})
+	}
+	return ranges, nil
+}
+
+// JSONPathSegments splits a JSONPath of object keys and array indexes, such
+// as $.status or $.checks[0].state, into string keys and int indexes
+func JSONPathSegments(path string) ([]any, error) {
+	invalid := fmt.Errorf("invalid JSON path %q: must be $ followed by .key and [index] segments", path)
+	rest, ok := strings.CutPrefix(path, "$")
+	if !ok {
+		return nil, invalid
+	}
+	var segments []any
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			end := strings.IndexAny(rest[1:], ".[") + 1
+			if end == 0 {
+				end = len(rest)
+			}
+			if end == 1 {
+				return nil, invalid
+			}
+			segments = append(segments, rest[1:end])
+			rest = rest[end:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, invalid
+			}
+			index, err := strconv.Atoi(rest[1:end])
+			if err != nil || index < 0 {
+				return nil, invalid
+			}
+			segments = append(segments, index)
+			rest = rest[end+1:]
+		default:
+			return nil, invalid
+		}
+	}
+	return segments, nil
+}
+
+// validate checks the probes and the policy combining them
+func (r ExternalReadiness) validate() error {
+	var errs []error
+	switch r.Policy {
+	case ReadinessPolicyAll, ReadinessPolicyAny, ReadinessPolicyQuorum:
+	default:
+		errs = append(errs, fmt.Errorf("invalid external readiness policy %q: must be all, any or quorum", r.Policy))
+	}
+	if r.Quorum < 0 || r.Quorum > len(r.Probes) {
+		errs = append(errs, fmt.Errorf("external readiness quorum %d must be between 0 and the number of probes (%d)", r.Quorum, len(r.Probes)))
+	}
+	seen := make(map[string]bool)
+	for _, p := range r.Probes {
+		if seen[p.Name] {
+			errs = append(errs, fmt.Errorf("duplicate external readiness probe name: %s", p.Name))
+		}
+		seen[p.Name] = true
+		for _, err := range Errors(p.validate()) {
+			errs = append(errs, fmt.Errorf("external readiness probe %s: %w", p.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,224 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/PhilipSchmid/echo-app/internal/format"
+	"github.com/PhilipSchmid/echo-app/internal/utils"
+	"github.com/spf13/viper"
+)
+
+// Listener protocols
+const (
+	ListenerHTTP = "http"
+	ListenerTLS  = "tls"
+	ListenerTCP  = "tcp"
+	ListenerGRPC = "grpc"
+	ListenerQUIC = "quic"
+)
+
+// Listener failure policies, applied when a listener stops after startup
+const (
+	FailurePolicyExit    = "exit"    // Shut every server down and exit with an error
+	FailurePolicyRestart = "restart" // Restart the listener with exponential backoff
+	FailurePolicyDegrade = "degrade" // Keep serving on the other listeners, reporting not ready
+)
+
+// Listener declares one echo listener. Listeners come from the "listeners"
+// list of the config file or, without one, from the per-protocol flags.
+type Listener struct {
+	Name         string `mapstructure:"name" yaml:"name"`
+	Protocol     string `mapstructure:"protocol" yaml:"protocol"`
+	Port         string `mapstructure:"port" yaml:"port,omitempty"`
+	Address      string `mapstructure:"address" yaml:"address"`                                                 // host:port or unix:///path, defaults to all interfaces on the port
+	SocketMode   string `mapstructure:"socket-mode" yaml:"socket-mode,omitempty"`                               // Unix socket permissions, overrides the global setting
+	Message      string `mapstructure:"message" yaml:"message,omitempty"`                                       // Overrides the global message
+	PrintHeaders *bool  `mapstructure:"print-http-request-headers" yaml:"print-http-request-headers,omitempty"` // Overrides the global setting
+	H2C          bool   `mapstructure:"h2c" yaml:"h2c,omitempty"`                                               // HTTP only
+	TLS          bool   `mapstructure:"tls" yaml:"tls,omitempty"`                                               // gRPC only
+	Format       string `mapstructure:"format" yaml:"format,omitempty"`                                         // TCP only, overrides the global TCP format
+	WebTransport bool   `mapstructure:"webtransport" yaml:"webtransport,omitempty"`                             // QUIC only
+}
+
+// flagListeners builds the listeners enabled by the per-protocol flags,
+// named like the servers they replace
+func flagListeners(cfg *Config) []Listener {
+	name := "HTTP"
+	if cfg.H2C {
+		name = "H2C"
+	}
+	listeners := []Listener{{Name: name, Protocol: ListenerHTTP, Port: cfg.HTTPPort, Address: cfg.HTTPAddress, H2C: cfg.H2C}}
+	if cfg.TLS {
+		listeners = append(listeners, Listener{Name: "TLS", Protocol: ListenerTLS, Port: cfg.TLSPort, Address: cfg.TLSAddress})
+	}
+	if cfg.TCP {
+		listeners = append(listeners, Listener{Name: "TCP", Protocol: ListenerTCP, Port: cfg.TCPPort, Address: cfg.TCPAddress})
+	}
+	if cfg.GRPC {
+		listeners = append(listeners, Listener{Name: "gRPC", Protocol: ListenerGRPC, Port: cfg.GRPCPort, Address: cfg.GRPCAddress, TLS: cfg.GRPCTLS})
+	}
+	if cfg.QUIC {
+		listeners = append(listeners, Listener{Name: "QUIC", Protocol: ListenerQUIC, Port: cfg.QUICPort, Address: cfg.QUICAddress, WebTransport: cfg.WebTransport})
+	}
+	return listeners
+}
+
+// loadListeners reads the listeners of the config file in v, if any, or
+// builds them from the per-protocol flags
+func loadListeners(v *viper.Viper, cfg *Config) error {
+	if !v.IsSet("listeners") {
+		cfg.Listeners = flagListeners(cfg)
+		return nil
+	}
+	var listeners []Listener
+	if err := v.UnmarshalKey("listeners", &listeners); err != nil {
+		return fmt.Errorf("invalid listeners: %w", err)
+	}
+	return cfg.SetListeners(listeners)
+}
+
+// SetListeners replaces the listeners, defaulting their names and resolving
+// their addresses, and points the per-protocol fields at the first listener
+// of each protocol so settings such as Alt-Svc find them
+func (c *Config) SetListeners(listeners []Listener) error {
+	if len(listeners) == 0 {
+		return fmt.Errorf("at least one listener must be configured")
+	}
+
+	c.Listeners = listeners
+	c.TLS, c.TCP, c.GRPC, c.QUIC = false, false, false, false
+	seen := make(map[string]bool)
+	for i := range c.Listeners {
+		l := &c.Listeners[i]
+		l.Protocol, l.Format = strings.ToLower(l.Protocol), strings.ToLower(l.Format)
+		resolveAddress(&l.Address, &l.Port)
+		if l.Name == "" {
+			l.Name = l.Protocol + "-" + l.Port
+			if l.Port == "" {
+				l.Name = l.Protocol + "-" + strings.TrimPrefix(l.Address, utils.UnixScheme)
+			}
+		}
+		if seen[l.Protocol] {
+			continue
+		}
+		seen[l.Protocol] = true
+		switch l.Protocol {
+		case ListenerHTTP:
+			c.HTTPPort, c.HTTPAddress, c.H2C = l.Port, l.Address, l.H2C
+		case ListenerTLS:
+			c.TLS, c.TLSPort, c.TLSAddress = true, l.Port, l.Address
+		case ListenerTCP:
+			c.TCP, c.TCPPort, c.TCPAddress = true, l.Port, l.Address
+		case ListenerGRPC:
+			c.GRPC, c.GRPCPort, c.GRPCAddress, c.GRPCTLS = true, l.Port, l.Address, l.TLS
+		case ListenerQUIC:
+			c.QUIC, c.QUICPort, c.QUICAddress, c.WebTransport = true, l.Port, l.Address, l.WebTransport
+		}
+	}
+	return nil
+}
+
+// validate checks the listener settings for consistency
+func (l Listener) validate() error {
+	var errs []error
+	switch l.Protocol {
+	case ListenerHTTP, ListenerTLS, ListenerTCP, ListenerGRPC, ListenerQUIC:
+	default:
+		errs = append(errs, fmt.Errorf("listener %s: invalid protocol %q", l.Name, l.Protocol))
+	}
+	network, _, err := utils.ParseAddress(l.ListenAddress())
+	if err != nil {
+		errs = append(errs, fmt.Errorf("listener %s: %w", l.Name, err))
+	}
+	if network == "unix" && l.Protocol == ListenerQUIC {
+		errs = append(errs, fmt.Errorf("listener %s: unix sockets are not supported by quic listeners", l.Name))
+	}
+	if _, err := parseSocketMode(l.SocketMode); err != nil {
+		errs = append(errs, fmt.Errorf("listener %s: %w", l.Name, err))
+	}
+	if len(l.Message) > MaxMessageLength {
+		errs = append(errs, fmt.Errorf("listener %s: message length (%d) exceeds maximum allowed length (%d)", l.Name, len(l.Message), MaxMessageLength))
+	}
+	if l.H2C && l.Protocol != ListenerHTTP {
+		errs = append(errs, fmt.Errorf("listener %s: h2c is only supported by http listeners", l.Name))
+	}
+	if l.TLS && l.Protocol != ListenerGRPC {
+		errs = append(errs, fmt.Errorf("listener %s: tls is only supported by grpc listeners", l.Name))
+	}
+	if l.WebTransport && l.Protocol != ListenerQUIC {
+		errs = append(errs, fmt.Errorf("listener %s: webtransport is only supported by quic listeners", l.Name))
+	}
+	if l.Format != "" {
+		if l.Protocol != ListenerTCP {
+			errs = append(errs, fmt.Errorf("listener %s: format is only supported by tcp listeners", l.Name))
+		}
+		if _, err := format.Parse(l.Format); err != nil {
+			errs = append(errs, fmt.Errorf("listener %s: invalid format: %w", l.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// validateListeners checks each listener and that names are unique
+func validateListeners(listeners []Listener) error {
+	var errs []error
+	names := make(map[string]bool, len(listeners))
+	for _, l := range listeners {
+		errs = append(errs, l.validate())
+		if names[l.Name] {
+			errs = append(errs, fmt.Errorf("duplicate listener name: %s", l.Name))
+		}
+		names[l.Name] = true
+	}
+	return errors.Join(errs...)
+}
+
+// ListenAddress returns the address the listener binds
+func (l Listener) ListenAddress() string {
+	return ListenAddress(l.Address, l.Port)
+}
+
+// transport returns the transport protocol the listener binds for host:port
+// addresses
+func (l Listener) transport() string {
+	if l.Protocol == ListenerQUIC {
+		return "udp"
+	}
+	return "tcp"
+}
+
+// ForListener returns a copy of the configuration with the settings of l
+// applied, as servers and handlers read their settings from the Config
+func (c *Config) ForListener(l Listener) *Config {
+	lc := *c
+	if l.Message != "" {
+		lc.Message = l.Message
+	}
+	if l.PrintHeaders != nil {
+		lc.PrintHeaders = *l.PrintHeaders
+	}
+	if mode, err := parseSocketMode(l.SocketMode); err == nil && l.SocketMode != "" {
+		lc.SocketMode = mode
+	}
+	switch l.Protocol {
+	case ListenerHTTP:
+		lc.HTTPPort, lc.HTTPAddress, lc.H2C = l.Port, l.Address, l.H2C
+	case ListenerTLS:
+		lc.TLSPort, lc.TLSAddress = l.Port, l.Address
+	case ListenerTCP:
+		lc.TCPPort, lc.TCPAddress = l.Port, l.Address
+		if l.Format != "" {
+			lc.TCPFormat = l.Format
+		}
+	case ListenerGRPC:
+		lc.GRPCPort, lc.GRPCAddress, lc.GRPCTLS = l.Port, l.Address, l.TLS
+	case ListenerQUIC:
+		lc.QUICPort, lc.QUICAddress, lc.WebTransport = l.Port, l.Address, l.WebTransport
+	}
+	lc.live = new(atomic.Pointer[Config])
+	lc.live.Store(&lc)
+	return &lc
+}
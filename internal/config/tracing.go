@@ -0,0 +1,43 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Tracing exporters
+const (
+	TracingExporterOTLPGRPC = "otlp-grpc"
+	TracingExporterOTLPHTTP = "otlp-http"
+)
+
+// Tracing configures OpenTelemetry span export. W3C trace context is
+// propagated and echoed even when export is disabled.
+type Tracing struct {
+	Enabled     bool
+	Exporter    string
+	Endpoint    string  // Collector host:port; empty uses the exporter default or OTEL_EXPORTER_OTLP_ENDPOINT
+	Insecure    bool    // Export without TLS
+	SampleRatio float64 // Fraction of new root traces to sample
+	ServiceName string
+}
+
+// validate checks the tracing settings for consistency
+func (t Tracing) validate() error {
+	if !t.Enabled {
+		return nil
+	}
+	var errs []error
+	switch t.Exporter {
+	case TracingExporterOTLPGRPC, TracingExporterOTLPHTTP:
+	default:
+		errs = append(errs, fmt.Errorf("invalid tracing exporter: %s", t.Exporter))
+	}
+	if t.SampleRatio < 0 || t.SampleRatio > 1 {
+		errs = append(errs, fmt.Errorf("tracing sample ratio must be between 0 and 1"))
+	}
+	if t.ServiceName == "" {
+		errs = append(errs, fmt.Errorf("tracing service name must not be empty"))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,133 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/PhilipSchmid/echo-app/internal/format"
+	"github.com/PhilipSchmid/echo-app/internal/utils"
+	"golang.org/x/net/http/httpguts"
+)
+
+// Validate checks the whole configuration and reports every problem found
+// rather than only the first, joined into one error. Errors splits it.
+func (c *Config) Validate() error {
+	errs := []error{
+		validateListeners(c.Listeners),
+		c.validatePorts(),
+		c.AccessLog.validate(),
+		c.Tracing.validate(),
+		c.Chain.validate(),
+		c.Diagnostics.validate(),
+		c.ExternalReadiness.validate(),
+	}
+
+	if c.Metrics {
+		if _, _, err := utils.ParseAddress(ListenAddress(c.MetricsAddress, c.MetricsPort)); err != nil {
+			errs = append(errs, fmt.Errorf("metrics server: %w", err))
+		}
+	}
+	if c.TCP {
+		if _, err := format.Parse(c.TCPFormat); err != nil {
+			errs = append(errs, fmt.Errorf("invalid TCP format: %w", err))
+		}
+	}
+	switch c.ListenerFailurePolicy {
+	case FailurePolicyExit, FailurePolicyRestart, FailurePolicyDegrade:
+	default:
+		errs = append(errs, fmt.Errorf("invalid listener failure policy %q: must be exit, restart or degrade", c.ListenerFailurePolicy))
+	}
+	if c.DrainDelay < 0 {
+		errs = append(errs, fmt.Errorf("drain delay must not be negative"))
+	}
+	if c.ShutdownTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("shutdown timeout must be positive"))
+	}
+	if c.WebTransport && !c.QUIC {
+		errs = append(errs, fmt.Errorf("WebTransport requires the QUIC listener to be enabled"))
+	}
+	if !httpguts.ValidHeaderFieldName(c.RequestIDHeader) {
+		errs = append(errs, fmt.Errorf("invalid request ID header: %q", c.RequestIDHeader))
+	}
+	if c.AltSvcPort != "" && utils.ValidatePort(c.AltSvcPort) != nil {
+		errs = append(errs, fmt.Errorf("invalid Alt-Svc port: %s", c.AltSvcPort))
+	}
+	if c.AltSvcMaxAge < 0 {
+		errs = append(errs, fmt.Errorf("alt-svc max age must not be negative"))
+	}
+	if len(c.Message) > MaxMessageLength {
+		errs = append(errs, fmt.Errorf("message length (%d) exceeds maximum allowed length (%d)", len(c.Message), MaxMessageLength))
+	}
+	return joinErrors(errs...)
+}
+
+// validatePorts reports listeners, including the metrics server, that would
+// bind the same socket. QUIC binds UDP and so may share a port with TCP.
+func (c *Config) validatePorts() error {
+	var bindings []binding
+	for _, l := range c.Listeners {
+		if b, ok := newBinding(l.Name, l.transport(), l.ListenAddress()); ok {
+			bindings = append(bindings, b)
+		}
+	}
+	if c.Metrics {
+		if b, ok := newBinding("Metrics", "tcp", ListenAddress(c.MetricsAddress, c.MetricsPort)); ok {
+			bindings = append(bindings, b)
+		}
+	}
+
+	// Group each binding with the first earlier one it collides with
+	var groups [][]binding
+	for _, b := range bindings {
+		i := slices.IndexFunc(groups, func(group []binding) bool {
+			return slices.ContainsFunc(group, b.collides)
+		})
+		if i < 0 {
+			groups = append(groups, []binding{b})
+			continue
+		}
+		groups[i] = append(groups[i], b)
+	}
+
+	var errs []error
+	for _, group := range groups {
+		if len(group) < 2 {
+			continue
+		}
+		names := make([]string, len(group))
+		for i, b := range group {
+			names[i] = b.name
+		}
+		errs = append(errs, fmt.Errorf("%s is used by more than one listener: %s", group[0], strings.Join(names, ", ")))
+	}
+	return errors.Join(errs...)
+}
+
+// Errors splits an error returned by Load or Validate into the individual
+// problems found
+func Errors(err error) []error {
+	if err == nil {
+		return nil
+	}
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		return []error{err}
+	}
+	var errs []error
+	for _, e := range joined.Unwrap() {
+		errs = append(errs, Errors(e)...)
+	}
+	return errs
+}
+
+// joinErrors joins errs like errors.Join, flattening joined errors so each
+// problem is reported on its own line
+func joinErrors(errs ...error) error {
+	var flat []error
+	for _, err := range errs {
+		flat = append(flat, Errors(err)...)
+	}
+	return errors.Join(flat...)
+}
@@ -0,0 +1,112 @@
+// Package diagnostics serves network debugging endpoints on the metrics
+// server, so DNS, connectivity and TLS can be checked from inside a pod whose
+// scratch image offers no shell to exec into
+package diagnostics
+
+import (
+	"context"
+	"net/http"
+	"net/netip"
+	"time"
+
+	"github.com/PhilipSchmid/echo-app/internal/config"
+	"github.com/PhilipSchmid/echo-app/internal/format"
+	"github.com/PhilipSchmid/echo-app/internal/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// Handler returns the /debug/resolve, /debug/dial and /debug/http endpoints
+// behind the configured source and token checks
+func Handler(cfg config.Diagnostics) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/resolve", withTimeout(cfg, handleResolve))
+	mux.HandleFunc("/debug/dial", withTimeout(cfg, handleDial))
+	mux.HandleFunc("/debug/http", withTimeout(cfg, handleHTTP))
+	return authorize(cfg, mux)
+}
+
+// checkFunc runs a diagnostic bounded by ctx and returns its result, the
+// status code to send and an error for invalid parameters
+type checkFunc func(ctx context.Context, r *http.Request) (any, int, error)
+
+// withTimeout bounds a check by the configured timeout and encodes its
+// result in the negotiated format
+func withTimeout(cfg config.Diagnostics, check checkFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		responseFormat, err := format.Negotiate(r)
+		if err != nil {
+			http.Error(w, "Bad Request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), cfg.Timeout)
+		defer cancel()
+		logrus.Infof("[Diagnostics] %s %s from %s", r.URL.Path, r.URL.RawQuery, r.RemoteAddr)
+		result, status, err := check(ctx, r)
+		if err != nil {
+			http.Error(w, "Bad Request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		data, err := format.Encode(responseFormat, result)
+		if err != nil {
+			logrus.Errorf("[Diagnostics] Failed to marshal %s: %v", responseFormat, err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", responseFormat.ContentType())
+		w.WriteHeader(status)
+		if _, err := w.Write(data); err != nil {
+			logrus.Errorf("[Diagnostics] Failed to write response: %v", err)
+		}
+	}
+}
+
+// statusOf maps a failed diagnostic to 502 so scripts can rely on curl -f
+func statusOf(errMsg string) int {
+	if errMsg != "" {
+		return http.StatusBadGateway
+	}
+	return http.StatusOK
+}
+
+// authorize admits requests from the allowed networks carrying the bearer
+// token, if one is configured
+func authorize(cfg config.Diagnostics, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !allowedSource(cfg.AllowedCIDRs, r.RemoteAddr) {
+			logrus.Warnf("[Diagnostics] Rejected request from %s: source not allowed", r.RemoteAddr)
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		if cfg.Token != "" && !utils.RequireBearerToken(w, r, cfg.Token, "echo-app diagnostics") {
+			logrus.Warnf("[Diagnostics] Rejected request from %s: invalid token", r.RemoteAddr)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// allowedSource reports whether remoteAddr lies in one of prefixes. No
+// prefixes allow every source.
+func allowedSource(prefixes []netip.Prefix, remoteAddr string) bool {
+	if len(prefixes) == 0 {
+		return true
+	}
+	addrPort, err := netip.ParseAddrPort(remoteAddr)
+	if err != nil {
+		return false
+	}
+	addr := addrPort.Addr().Unmap()
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// milliseconds converts d to fractional milliseconds
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
@@ -0,0 +1,257 @@
+package diagnostics
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/PhilipSchmid/echo-app/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testConfig() config.Diagnostics {
+	return config.Diagnostics{
+		Enabled:      true,
+		AllowedCIDRs: []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8"), netip.MustParsePrefix("::1/128")},
+		Timeout:      2 * time.Second,
+	}
+}
+
+// serve sends a request for target from the loopback address
+func serve(t *testing.T, cfg config.Diagnostics, target string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	req.RemoteAddr = "127.0.0.1:40000"
+	w := httptest.NewRecorder()
+	Handler(cfg).ServeHTTP(w, req)
+	return w
+}
+
+func decode[T any](t *testing.T, w *httptest.ResponseRecorder) T {
+	t.Helper()
+	var v T
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &v), w.Body.String())
+	return v
+}
+
+func TestAuthorize(t *testing.T) {
+	tests := []struct {
+		name           string
+		cidrs          []netip.Prefix
+		token          string
+		remoteAddr     string
+		authorization  string
+		expectedStatus int
+	}{
+		{name: "loopback allowed", cidrs: testConfig().AllowedCIDRs, remoteAddr: "127.0.0.1:1234", expectedStatus: http.StatusBadRequest},
+		{name: "IPv6 loopback allowed", cidrs: testConfig().AllowedCIDRs, remoteAddr: "[::1]:1234", expectedStatus: http.StatusBadRequest},
+		{name: "IPv4-mapped address allowed", cidrs: testConfig().AllowedCIDRs, remoteAddr: "[::ffff:127.0.0.1]:1234", expectedStatus: http.StatusBadRequest},
+		{name: "other source rejected", cidrs: testConfig().AllowedCIDRs, remoteAddr: "10.0.0.1:1234", expectedStatus: http.StatusForbidden},
+		{name: "no CIDRs allow all", remoteAddr: "10.0.0.1:1234", expectedStatus: http.StatusBadRequest},
+		{name: "missing token", token: "s3cret", remoteAddr: "10.0.0.1:1234", expectedStatus: http.StatusUnauthorized},
+		{name: "wrong token", token: "s3cret", remoteAddr: "10.0.0.1:1234", authorization: "Bearer wrong", expectedStatus: http.StatusUnauthorized},
+		{name: "valid token", token: "s3cret", remoteAddr: "10.0.0.1:1234", authorization: "Bearer s3cret", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig()
+			cfg.AllowedCIDRs = tt.cidrs
+			cfg.Token = tt.token
+
+			// Authorized requests reach the handler, which rejects the missing name
+			req := httptest.NewRequest(http.MethodGet, "/debug/resolve", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			Handler(cfg).ServeHTTP(w, req)
+			assert.Equal(t, tt.expectedStatus, w.Code, w.Body.String())
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	dir := t.TempDir()
+	resolvConf := filepath.Join(dir, "resolv.conf")
+	require.NoError(t, os.WriteFile(resolvConf, []byte(
+		"# generated by kubelet\nsearch default.svc.cluster.local svc.cluster.local\nnameserver 10.96.0.10\noptions ndots:5\n"), 0o600))
+	original := resolvConfPath
+	resolvConfPath = resolvConf
+	t.Cleanup(func() { resolvConfPath = original })
+
+	// localhost is answered from /etc/hosts
+	w := serve(t, testConfig(), "/debug/resolve?name=localhost&type=a")
+	assert.Equal(t, http.StatusOK, w.Code)
+	result := decode[ResolveResult](t, w)
+	assert.Equal(t, "A", result.Type)
+	assert.Contains(t, result.Records, "127.0.0.1")
+	assert.Equal(t, Resolver{
+		Servers: []string{"10.96.0.10:53"},
+		Search:  []string{"default.svc.cluster.local", "svc.cluster.local"},
+		Ndots:   5,
+		Source:  resolvConf,
+	}, result.Resolver)
+
+	// Failed lookups against an explicit server are reported with 502
+	lis, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := lis.LocalAddr().String()
+	require.NoError(t, lis.Close())
+	w = serve(t, testConfig(), "/debug/resolve?name=echo-app.invalid&server="+server)
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+	result = decode[ResolveResult](t, w)
+	assert.Equal(t, "IP", result.Type)
+	assert.Equal(t, Resolver{Servers: []string{server}, Source: "query"}, result.Resolver)
+	assert.NotEmpty(t, result.Error)
+}
+
+func TestResolve_InvalidParameters(t *testing.T) {
+	w := serve(t, testConfig(), "/debug/resolve")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "missing name parameter")
+
+	w = serve(t, testConfig(), "/debug/resolve?name=example.com&type=PTR")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `unsupported record type "PTR"`)
+}
+
+func TestReadResolvConf_Missing(t *testing.T) {
+	assert.Equal(t, Resolver{Source: "/nonexistent"}, readResolvConf("/nonexistent"))
+}
+
+func TestDial_TCP(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() { _ = lis.Close() }()
+
+	w := serve(t, testConfig(), "/debug/dial?target="+lis.Addr().String())
+	assert.Equal(t, http.StatusOK, w.Code)
+	result := decode[DialResult](t, w)
+	assert.Equal(t, "tcp", result.Proto)
+	assert.Equal(t, lis.Addr().String(), result.RemoteAddr)
+	assert.NotEmpty(t, result.LocalAddr)
+	assert.Empty(t, result.Error)
+
+	addr := lis.Addr().String()
+	require.NoError(t, lis.Close())
+	w = serve(t, testConfig(), "/debug/dial?target="+addr)
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+	assert.Contains(t, decode[DialResult](t, w).Error, "connection refused")
+}
+
+func TestDial_TLS(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.NotFoundHandler())
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	defer srv.Close()
+
+	target := srv.Listener.Addr().String()
+	w := serve(t, testConfig(), "/debug/dial?proto=tls&alpn=h2,http/1.1&sni=example.com&target="+target)
+	assert.Equal(t, http.StatusOK, w.Code)
+	result := decode[DialResult](t, w)
+	require.NotNil(t, result.TLS)
+	assert.Equal(t, "TLS 1.3", result.TLS.Version)
+	assert.Equal(t, "h2", result.TLS.ALPN)
+	assert.Equal(t, "example.com", result.TLS.ServerName)
+	// The test certificate is valid for example.com but not publicly trusted
+	assert.False(t, result.TLS.Verified)
+	assert.Contains(t, result.TLS.VerifyError, "certificate signed by unknown authority")
+	require.NotEmpty(t, result.TLS.Certificates)
+	assert.Contains(t, result.TLS.Certificates[0].DNSNames, "example.com")
+}
+
+func TestDial_UDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() { _ = conn.Close() }()
+	go func() {
+		buf := make([]byte, 1024)
+		n, addr, err := conn.ReadFrom(buf)
+		if err == nil {
+			_, _ = conn.WriteTo(buf[:n], addr)
+		}
+	}()
+
+	w := serve(t, testConfig(), "/debug/dial?proto=udp&payload=ping&target="+conn.LocalAddr().String())
+	assert.Equal(t, http.StatusOK, w.Code)
+	result := decode[DialResult](t, w)
+	assert.Equal(t, 4, result.ReplyBytes)
+	assert.Greater(t, result.RoundTripMs, 0.0)
+}
+
+func TestDial_InvalidParameters(t *testing.T) {
+	w := serve(t, testConfig(), "/debug/dial?target=localhost")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "target must be host:port")
+
+	w = serve(t, testConfig(), "/debug/dial?target=localhost:80&proto=sctp")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `unsupported proto "sctp"`)
+}
+
+func TestHTTP(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "/", http.StatusFound)
+			return
+		}
+		w.Header().Set("X-Upstream", "yes")
+		_, _ = w.Write([]byte("hello"))
+	}))
+	defer srv.Close()
+
+	w := serve(t, testConfig(), "/debug/http?url="+url.QueryEscape(srv.URL+"/"))
+	assert.Equal(t, http.StatusOK, w.Code)
+	result := decode[HTTPResult](t, w)
+	assert.Equal(t, http.StatusOK, result.Status)
+	assert.Equal(t, "HTTP/1.1", result.Proto)
+	assert.Equal(t, "yes", result.Headers.Get("X-Upstream"))
+	assert.Equal(t, int64(5), result.BodyBytes)
+	assert.Greater(t, result.Timing.ConnectMs, 0.0)
+	assert.Greater(t, result.Timing.FirstByteMs, 0.0)
+	assert.GreaterOrEqual(t, result.Timing.TotalMs, result.Timing.FirstByteMs)
+	assert.Nil(t, result.TLS)
+
+	// Redirects are reported, not followed
+	w = serve(t, testConfig(), "/debug/http?method=head&url="+url.QueryEscape(srv.URL+"/redirect"))
+	result = decode[HTTPResult](t, w)
+	assert.Equal(t, http.MethodHead, result.Method)
+	assert.Equal(t, http.StatusFound, result.Status)
+}
+
+func TestHTTP_TLS(t *testing.T) {
+	srv := httptest.NewTLSServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	w := serve(t, testConfig(), "/debug/http?url="+url.QueryEscape(srv.URL))
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+	assert.Contains(t, decode[HTTPResult](t, w).Error, "certificate")
+
+	w = serve(t, testConfig(), "/debug/http?insecure=true&url="+url.QueryEscape(srv.URL))
+	assert.Equal(t, http.StatusOK, w.Code)
+	result := decode[HTTPResult](t, w)
+	assert.Equal(t, http.StatusNotFound, result.Status)
+	require.NotNil(t, result.TLS)
+	assert.Greater(t, result.Timing.TLSMs, 0.0)
+	assert.False(t, result.TLS.Verified)
+}
+
+func TestHTTP_InvalidParameters(t *testing.T) {
+	w := serve(t, testConfig(), "/debug/http?url=ftp://example.com")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "url must be an absolute http or https URL")
+
+	w = serve(t, testConfig(), "/debug/http?method=POST&url=http://example.com")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `unsupported method "POST"`)
+}
@@ -0,0 +1,165 @@
+package diagnostics
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/PhilipSchmid/echo-app/internal/health"
+)
+
+// maxUDPReply limits how much of a UDP reply is read
+const maxUDPReply = 64 * 1024
+
+// DialResult reports a connection attempt
+type DialResult struct {
+	Target      string   `json:"target"`
+	Proto       string   `json:"proto"`
+	RemoteAddr  string   `json:"remote_addr,omitempty"`
+	LocalAddr   string   `json:"local_addr,omitempty"`
+	ConnectMs   float64  `json:"connect_ms"`
+	TLS         *TLSInfo `json:"tls,omitempty"`
+	ReplyBytes  int      `json:"reply_bytes,omitempty"`
+	RoundTripMs float64  `json:"round_trip_ms,omitempty"`
+	Error       string   `json:"error,omitempty"`
+}
+
+// TLSInfo describes a completed TLS handshake and the peer's certificates.
+// Certificates are verified separately so untrusted chains are still shown.
+type TLSInfo struct {
+	Version      string        `json:"version"`
+	CipherSuite  string        `json:"cipher_suite"`
+	ALPN         string        `json:"alpn,omitempty"`
+	ServerName   string        `json:"server_name,omitempty"`
+	HandshakeMs  float64       `json:"handshake_ms"`
+	Verified     bool          `json:"verified"`
+	VerifyError  string        `json:"verify_error,omitempty"`
+	Certificates []Certificate `json:"certificates"`
+}
+
+// Certificate summarises a peer certificate
+type Certificate struct {
+	Subject   string    `json:"subject"`
+	Issuer    string    `json:"issuer"`
+	DNSNames  []string  `json:"dns_names,omitempty"`
+	NotBefore time.Time `json:"not_before"`
+	NotAfter  time.Time `json:"not_after"`
+}
+
+// handleDial connects to ?target=host:port over ?proto=tcp (default), udp
+// or tls. TLS takes optional ?sni= and ?alpn=; UDP sends an optional
+// ?payload= and waits for a reply.
+func handleDial(ctx context.Context, r *http.Request) (any, int, error) {
+	query := r.URL.Query()
+	target := query.Get("target")
+	if _, _, err := net.SplitHostPort(target); err != nil {
+		return nil, 0, fmt.Errorf("target must be host:port: %q", target)
+	}
+	proto := strings.ToLower(query.Get("proto"))
+	if proto == "" {
+		proto = "tcp"
+	}
+	network := proto
+	switch proto {
+	case "tcp", "udp":
+	case "tls":
+		network = "tcp"
+	default:
+		return nil, 0, fmt.Errorf("unsupported proto %q: must be one of tcp, udp or tls", proto)
+	}
+
+	result := DialResult{Target: target, Proto: proto}
+	start := time.Now()
+	conn, err := health.Dial(ctx, network, target)
+	result.ConnectMs = milliseconds(time.Since(start))
+	if err != nil {
+		result.Error = err.Error()
+		return result, statusOf(result.Error), nil
+	}
+	defer func() { _ = conn.Close() }()
+	result.RemoteAddr = conn.RemoteAddr().String()
+	result.LocalAddr = conn.LocalAddr().String()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	switch proto {
+	case "tls":
+		serverName := query.Get("sni")
+		if serverName == "" {
+			serverName, _, _ = net.SplitHostPort(target)
+		}
+		var alpn []string
+		if value := query.Get("alpn"); value != "" {
+			alpn = strings.Split(value, ",")
+		}
+		// Verification happens in tlsInfo so failing chains are still reported
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: serverName, NextProtos: alpn, InsecureSkipVerify: true}) // #nosec G402 -- verified manually below
+		start = time.Now()
+		err = tlsConn.HandshakeContext(ctx)
+		handshake := time.Since(start)
+		if err != nil {
+			result.Error = err.Error()
+			break
+		}
+		result.TLS = tlsInfo(tlsConn.ConnectionState(), serverName, handshake)
+	case "udp":
+		payload := query.Get("payload")
+		if payload == "" {
+			break
+		}
+		start = time.Now()
+		if _, err = conn.Write([]byte(payload)); err == nil {
+			buf := make([]byte, maxUDPReply)
+			result.ReplyBytes, err = conn.Read(buf)
+		}
+		result.RoundTripMs = milliseconds(time.Since(start))
+		if err != nil {
+			result.Error = err.Error()
+		}
+	}
+	return result, statusOf(result.Error), nil
+}
+
+// tlsInfo summarises state and verifies the peer chain against the system roots
+func tlsInfo(state tls.ConnectionState, serverName string, handshake time.Duration) *TLSInfo {
+	info := &TLSInfo{
+		Version:     tls.VersionName(state.Version),
+		CipherSuite: tls.CipherSuiteName(state.CipherSuite),
+		ALPN:        state.NegotiatedProtocol,
+		ServerName:  serverName,
+		HandshakeMs: milliseconds(handshake),
+	}
+	for _, cert := range state.PeerCertificates {
+		info.Certificates = append(info.Certificates, Certificate{
+			Subject:   cert.Subject.String(),
+			Issuer:    cert.Issuer.String(),
+			DNSNames:  cert.DNSNames,
+			NotBefore: cert.NotBefore,
+			NotAfter:  cert.NotAfter,
+		})
+	}
+
+	if len(state.PeerCertificates) == 0 {
+		info.VerifyError = "no peer certificates"
+		return info
+	}
+	intermediates := x509.NewCertPool()
+	for _, cert := range state.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err := state.PeerCertificates[0].Verify(x509.VerifyOptions{
+		DNSName:       serverName,
+		Intermediates: intermediates,
+	})
+	info.Verified = err == nil
+	if err != nil {
+		info.VerifyError = err.Error()
+	}
+	return info
+}
@@ -0,0 +1,133 @@
+package diagnostics
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PhilipSchmid/echo-app/internal/health"
+)
+
+// maxHTTPBody limits how much of a response body is read and counted
+const maxHTTPBody = 1 << 20
+
+// HTTPResult reports an HTTP request and its timing breakdown
+type HTTPResult struct {
+	URL        string      `json:"url"`
+	Method     string      `json:"method"`
+	Status     int         `json:"status,omitempty"`
+	Proto      string      `json:"proto,omitempty"`
+	RemoteAddr string      `json:"remote_addr,omitempty"`
+	Headers    http.Header `json:"headers,omitempty"`
+	BodyBytes  int64       `json:"body_bytes"`
+	Timing     HTTPTiming  `json:"timing"`
+	TLS        *TLSInfo    `json:"tls,omitempty"`
+	Error      string      `json:"error,omitempty"`
+}
+
+// HTTPTiming breaks an HTTP request down into its phases. Phases that did
+// not happen, such as DNS for IP targets, are omitted.
+type HTTPTiming struct {
+	DNSMs       float64 `json:"dns_ms,omitempty"`
+	ConnectMs   float64 `json:"connect_ms,omitempty"`
+	TLSMs       float64 `json:"tls_ms,omitempty"`
+	FirstByteMs float64 `json:"first_byte_ms,omitempty"`
+	TotalMs     float64 `json:"total_ms"`
+}
+
+// handleHTTP requests ?url= with ?method= GET (default), HEAD or OPTIONS on
+// a fresh connection. Redirects are reported, not followed; ?insecure=true
+// skips certificate verification.
+func handleHTTP(ctx context.Context, r *http.Request) (any, int, error) {
+	query := r.URL.Query()
+	target, err := url.Parse(query.Get("url"))
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return nil, 0, fmt.Errorf("url must be an absolute http or https URL: %q", query.Get("url"))
+	}
+	method := strings.ToUpper(query.Get("method"))
+	if method == "" {
+		method = http.MethodGet
+	}
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+	default:
+		return nil, 0, fmt.Errorf("unsupported method %q: must be one of GET, HEAD or OPTIONS", method)
+	}
+
+	result := HTTPResult{URL: target.String(), Method: method}
+	// Trace hooks may run concurrently, e.g. for parallel IPv4/IPv6 dials
+	var mu sync.Mutex
+	var dnsStart, connectStart, tlsStart time.Time
+	var handshake time.Duration
+	record := func(update func()) {
+		mu.Lock()
+		defer mu.Unlock()
+		update()
+	}
+	start := time.Now()
+	trace := &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) { record(func() { dnsStart = time.Now() }) },
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			record(func() { result.Timing.DNSMs = milliseconds(time.Since(dnsStart)) })
+		},
+		ConnectStart: func(string, string) { record(func() { connectStart = time.Now() }) },
+		ConnectDone: func(string, string, error) {
+			record(func() { result.Timing.ConnectMs = milliseconds(time.Since(connectStart)) })
+		},
+		TLSHandshakeStart: func() { record(func() { tlsStart = time.Now() }) },
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			record(func() {
+				handshake = time.Since(tlsStart)
+				result.Timing.TLSMs = milliseconds(handshake)
+			})
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			record(func() { result.RemoteAddr = info.Conn.RemoteAddr().String() })
+		},
+		GotFirstResponseByte: func() {
+			record(func() { result.Timing.FirstByteMs = milliseconds(time.Since(start)) })
+		},
+	}
+
+	// A fresh transport per request so every phase is measured
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DisableKeepAlives = true
+	transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: query.Get("insecure") == "true"} // #nosec G402 -- opt-in per request
+	client := &http.Client{
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	defer transport.CloseIdleConnections()
+
+	resp, err := health.DoHTTP(httptrace.WithClientTrace(ctx, trace), client, method, target.String())
+	mu.Lock()
+	defer mu.Unlock()
+	if err != nil {
+		result.Timing.TotalMs = milliseconds(time.Since(start))
+		result.Error = err.Error()
+		return result, statusOf(result.Error), nil
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	result.Status = resp.StatusCode
+	result.Proto = resp.Proto
+	result.Headers = resp.Header
+	result.BodyBytes, err = io.Copy(io.Discard, io.LimitReader(resp.Body, maxHTTPBody))
+	result.Timing.TotalMs = milliseconds(time.Since(start))
+	if err != nil {
+		result.Error = err.Error()
+	}
+	if resp.TLS != nil {
+		result.TLS = tlsInfo(*resp.TLS, target.Hostname(), handshake)
+	}
+	return result, statusOf(result.Error), nil
+}
@@ -0,0 +1,107 @@
+package diagnostics
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PhilipSchmid/echo-app/internal/config"
+	"github.com/PhilipSchmid/echo-app/internal/health"
+)
+
+// resolvConfPath is the resolver configuration reported by /debug/resolve
+var resolvConfPath = "/etc/resolv.conf"
+
+// ResolveResult reports a DNS lookup
+type ResolveResult struct {
+	Name       string   `json:"name"`
+	Type       string   `json:"type"`
+	Resolver   Resolver `json:"resolver"`
+	Records    []string `json:"records,omitempty"`
+	DurationMs float64  `json:"duration_ms"`
+	Error      string   `json:"error,omitempty"`
+}
+
+// Resolver describes the DNS servers and search path used for a lookup
+type Resolver struct {
+	Servers []string `json:"servers,omitempty"`
+	Search  []string `json:"search,omitempty"`
+	Ndots   int      `json:"ndots,omitempty"`
+	Source  string   `json:"source"`
+}
+
+// handleResolve looks up ?name= as A and AAAA records, or as ?type= A, AAAA,
+// CNAME, SRV, TXT or MX, optionally against ?server=
+func handleResolve(ctx context.Context, r *http.Request) (any, int, error) {
+	query := r.URL.Query()
+	name := query.Get("name")
+	if name == "" {
+		return nil, 0, fmt.Errorf("missing name parameter")
+	}
+	recordType := strings.ToUpper(query.Get("type"))
+	if recordType == "" {
+		recordType = "IP"
+	}
+	if !slices.Contains(config.DNSRecordTypes, recordType) {
+		return nil, 0, fmt.Errorf("unsupported record type %q: must be one of A, AAAA, CNAME, SRV, TXT or MX", recordType)
+	}
+
+	resolver := net.DefaultResolver
+	info := readResolvConf(resolvConfPath)
+	if server := query.Get("server"); server != "" {
+		if _, _, err := net.SplitHostPort(server); err != nil {
+			server = net.JoinHostPort(server, "53")
+		}
+		resolver = health.Resolver(server)
+		info = Resolver{Servers: []string{server}, Source: "query"}
+	}
+
+	result := ResolveResult{Name: name, Type: recordType, Resolver: info}
+	start := time.Now()
+	records, err := health.Lookup(ctx, resolver, recordType, name)
+	result.DurationMs = milliseconds(time.Since(start))
+	if err != nil {
+		result.Error = err.Error()
+	}
+	result.Records = records
+	return result, statusOf(result.Error), nil
+}
+
+// readResolvConf reports the nameservers, search domains and ndots option
+// from path. A missing file yields an empty configuration.
+func readResolvConf(path string) Resolver {
+	resolver := Resolver{Source: path}
+	file, err := os.Open(path)
+	if err != nil {
+		return resolver
+	}
+	defer func() { _ = file.Close() }()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") || strings.HasPrefix(fields[0], ";") {
+			continue
+		}
+		switch fields[0] {
+		case "nameserver":
+			resolver.Servers = append(resolver.Servers, net.JoinHostPort(fields[1], "53"))
+		case "search", "domain":
+			resolver.Search = fields[1:]
+		case "options":
+			for _, option := range fields[1:] {
+				if value, ok := strings.CutPrefix(option, "ndots:"); ok {
+					resolver.Ndots, _ = strconv.Atoi(value)
+				}
+			}
+		}
+	}
+	return resolver
+}
@@ -0,0 +1,427 @@
+package format
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"go.yaml.in/yaml/v3"
+)
+
+// Format identifies an encoding for echo responses
+type Format string
+
+const (
+	JSON       Format = "json"
+	PrettyJSON Format = "pretty"
+	YAML       Format = "yaml"
+	Text       Format = "text"
+	HTML       Format = "html"
+	MsgPack    Format = "msgpack"
+)
+
+// mediaTypes maps Accept header media types to formats
+var mediaTypes = map[string]Format{
+	"application/json":        JSON,
+	"application/yaml":        YAML,
+	"application/x-yaml":      YAML,
+	"text/yaml":               YAML,
+	"text/plain":              Text,
+	"text/html":               HTML,
+	"application/msgpack":     MsgPack,
+	"application/x-msgpack":   MsgPack,
+	"application/vnd.msgpack": MsgPack,
+}
+
+// Parse converts a format name to a Format
+func Parse(name string) (Format, error) {
+	switch f := Format(strings.ToLower(strings.TrimSpace(name))); f {
+	case JSON, PrettyJSON, YAML, Text, HTML, MsgPack:
+		return f, nil
+	case "json-pretty":
+		return PrettyJSON, nil
+	case "yml":
+		return YAML, nil
+	case "txt", "plain":
+		return Text, nil
+	case "messagepack":
+		return MsgPack, nil
+	default:
+		return "", fmt.Errorf("unsupported format %q", name)
+	}
+}
+
+// ContentType returns the MIME type for the format
+func (f Format) ContentType() string {
+	switch f {
+	case YAML:
+		return "application/yaml"
+	case Text:
+		return "text/plain; charset=utf-8"
+	case HTML:
+		return "text/html; charset=utf-8"
+	case MsgPack:
+		return "application/msgpack"
+	default:
+		return "application/json"
+	}
+}
+
+// Negotiate selects the response format for an HTTP request. A ?format= query
+// parameter takes precedence over the Accept header. Requests that accept
+// nothing we can produce fall back to JSON rather than failing.
+func Negotiate(r *http.Request) (Format, error) {
+	if name := r.URL.Query().Get("format"); name != "" {
+		return Parse(name)
+	}
+	return fromAccept(r.Header.Get("Accept")), nil
+}
+
+// fromAccept picks the supported media type with the highest quality value,
+// keeping the client's order for equal weights
+func fromAccept(accept string) Format {
+	type candidate struct {
+		format Format
+		q      float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		mediaType := strings.ToLower(strings.TrimSpace(params[0]))
+		f, ok := mediaTypes[mediaType]
+		if !ok {
+			continue
+		}
+		q := 1.0
+		for _, param := range params[1:] {
+			key, value, found := strings.Cut(strings.TrimSpace(param), "=")
+			if found && strings.TrimSpace(key) == "q" {
+				if parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+		if q > 0 {
+			candidates = append(candidates, candidate{format: f, q: q})
+		}
+	}
+	if len(candidates) == 0 {
+		return JSON
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+	return candidates[0].format
+}
+
+// Encode renders v in the requested format. Every format is derived from the
+// JSON representation of v, so field names and omitted fields stay identical
+// across encodings.
+func Encode(f Format, v any) ([]byte, error) {
+	switch f {
+	case JSON, "":
+		return json.Marshal(v)
+	case PrettyJSON:
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(data, '\n'), nil
+	}
+
+	fields, err := toFields(v)
+	if err != nil {
+		return nil, err
+	}
+
+	switch f {
+	case YAML:
+		return encodeYAML(fields)
+	case Text:
+		return encodeText(fields), nil
+	case HTML:
+		return encodeHTML(fields)
+	case MsgPack:
+		return encodeMsgPack(fields)
+	default:
+		return nil, fmt.Errorf("unsupported format %q", f)
+	}
+}
+
+// field is a single key/value pair of an ordered object
+type field struct {
+	Key   string
+	Value any
+}
+
+// object is a JSON object whose keys keep their encoding order
+type object []field
+
+// toFields converts v to its JSON representation, preserving key order.
+// Values are object, []any, string, json.Number, bool or nil.
+func toFields(v any) (object, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	value, err := decodeValue(dec)
+	if err != nil {
+		return nil, err
+	}
+	obj, ok := value.(object)
+	if !ok {
+		return nil, fmt.Errorf("expected a JSON object, got %T", value)
+	}
+	return obj, nil
+}
+
+func decodeValue(dec *json.Decoder) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch t := tok.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			obj := object{}
+			for dec.More() {
+				keyTok, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				value, err := decodeValue(dec)
+				if err != nil {
+					return nil, err
+				}
+				obj = append(obj, field{Key: keyTok.(string), Value: value})
+			}
+			_, err := dec.Token() // closing brace
+			return obj, err
+		case '[':
+			list := []any{}
+			for dec.More() {
+				value, err := decodeValue(dec)
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, value)
+			}
+			_, err := dec.Token() // closing bracket
+			return list, err
+		}
+		return nil, fmt.Errorf("unexpected delimiter %v", t)
+	default:
+		return t, nil
+	}
+}
+
+// scalarString formats a scalar value for the text and HTML encodings
+func scalarString(v any) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case json.Number:
+		return t.String()
+	case bool:
+		return strconv.FormatBool(t)
+	default:
+		return fmt.Sprint(t)
+	}
+}
+
+// isScalarList reports whether a list only holds scalar values
+func isScalarList(list []any) bool {
+	for _, item := range list {
+		switch item.(type) {
+		case object, []any:
+			return false
+		}
+	}
+	return true
+}
+
+func joinScalars(list []any) string {
+	parts := make([]string, len(list))
+	for i, item := range list {
+		parts[i] = scalarString(item)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func encodeText(fields object) []byte {
+	var buf bytes.Buffer
+	writeText(&buf, fields, "")
+	return buf.Bytes()
+}
+
+func writeText(buf *bytes.Buffer, fields object, indent string) {
+	for _, f := range fields {
+		switch value := f.Value.(type) {
+		case object:
+			fmt.Fprintf(buf, "%s%s:\n", indent, f.Key)
+			writeText(buf, value, indent+"  ")
+		case []any:
+			if isScalarList(value) {
+				fmt.Fprintf(buf, "%s%s: %s\n", indent, f.Key, joinScalars(value))
+				continue
+			}
+			fmt.Fprintf(buf, "%s%s:\n", indent, f.Key)
+			for i, item := range value {
+				if obj, ok := item.(object); ok {
+					fmt.Fprintf(buf, "%s  [%d]:\n", indent, i)
+					writeText(buf, obj, indent+"    ")
+					continue
+				}
+				fmt.Fprintf(buf, "%s  [%d]: %s\n", indent, i, scalarString(item))
+			}
+		default:
+			fmt.Fprintf(buf, "%s%s: %s\n", indent, f.Key, scalarString(value))
+		}
+	}
+}
+
+func encodeYAML(fields object) ([]byte, error) {
+	return yaml.Marshal(yamlNode(fields))
+}
+
+func yamlNode(v any) *yaml.Node {
+	switch t := v.(type) {
+	case object:
+		node := &yaml.Node{Kind: yaml.MappingNode}
+		for _, f := range t {
+			node.Content = append(node.Content,
+				&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: f.Key},
+				yamlNode(f.Value))
+		}
+		return node
+	case []any:
+		node := &yaml.Node{Kind: yaml.SequenceNode}
+		for _, item := range t {
+			node.Content = append(node.Content, yamlNode(item))
+		}
+		return node
+	case nil:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}
+	case bool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(t)}
+	case json.Number:
+		tag := "!!float"
+		if _, err := t.Int64(); err == nil {
+			tag = "!!int"
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: t.String()}
+	default:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: scalarString(t)}
+	}
+}
+
+func encodeMsgPack(fields object) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	if err := writeMsgPack(enc, fields); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeMsgPack(enc *msgpack.Encoder, v any) error {
+	switch t := v.(type) {
+	case object:
+		if err := enc.EncodeMapLen(len(t)); err != nil {
+			return err
+		}
+		for _, f := range t {
+			if err := enc.EncodeString(f.Key); err != nil {
+				return err
+			}
+			if err := writeMsgPack(enc, f.Value); err != nil {
+				return err
+			}
+		}
+		return nil
+	case []any:
+		if err := enc.EncodeArrayLen(len(t)); err != nil {
+			return err
+		}
+		for _, item := range t {
+			if err := writeMsgPack(enc, item); err != nil {
+				return err
+			}
+		}
+		return nil
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return enc.EncodeInt(i)
+		}
+		f, err := t.Float64()
+		if err != nil {
+			return err
+		}
+		return enc.EncodeFloat64(f)
+	default:
+		return enc.Encode(t)
+	}
+}
+
+// htmlTemplate renders responses as a small page for browsers
+var htmlTemplate = template.Must(template.New("page").Funcs(template.FuncMap{
+	"kind": func(v any) string {
+		switch t := v.(type) {
+		case object:
+			return "object"
+		case []any:
+			if isScalarList(t) {
+				return "scalar"
+			}
+			return "list"
+		default:
+			return "scalar"
+		}
+	},
+	"scalar": func(v any) string {
+		if list, ok := v.([]any); ok {
+			return joinScalars(list)
+		}
+		return scalarString(v)
+	},
+}).Parse(`{{define "fields"}}<table>
+{{- range .}}
+<tr><th>{{.Key}}</th><td>{{template "value" .Value}}</td></tr>
+{{- end}}
+</table>{{end}}
+{{- define "value"}}{{$kind := kind .}}{{if eq $kind "object"}}{{template "fields" .}}{{else if eq $kind "list"}}<ol>{{range .}}<li>{{template "value" .}}</li>{{end}}</ol>{{else}}{{scalar .}}{{end}}{{end -}}
+<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>echo-app</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; vertical-align: top; }
+th { background: #f4f4f4; }
+</style>
+</head>
+<body>
+<h1>echo-app</h1>
+{{template "fields" .}}
+</body>
+</html>
+`))
+
+func encodeHTML(fields object) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := htmlTemplate.Execute(&buf, fields); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,200 @@
+package format
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v5"
+	"go.yaml.in/yaml/v3"
+)
+
+type embedded struct {
+	Hostname string `json:"hostname"`
+	Node     string `json:"node,omitempty"`
+}
+
+type sample struct {
+	embedded
+	Count   int                 `json:"count"`
+	Ratio   float64             `json:"ratio"`
+	Enabled bool                `json:"enabled"`
+	Headers map[string][]string `json:"headers,omitempty"`
+}
+
+func newSample() sample {
+	return sample{
+		embedded: embedded{Hostname: "echo-host"},
+		Count:    3,
+		Ratio:    0.5,
+		Enabled:  true,
+		Headers:  map[string][]string{"Accept": {"text/html", "*/*"}},
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected Format
+		wantErr  bool
+	}{
+		{name: "json", expected: JSON},
+		{name: "JSON", expected: JSON},
+		{name: "pretty", expected: PrettyJSON},
+		{name: "json-pretty", expected: PrettyJSON},
+		{name: "yaml", expected: YAML},
+		{name: "yml", expected: YAML},
+		{name: "text", expected: Text},
+		{name: "txt", expected: Text},
+		{name: "html", expected: HTML},
+		{name: "msgpack", expected: MsgPack},
+		{name: "xml", wantErr: true},
+		{name: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := Parse(tt.name)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, f)
+		})
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		name     string
+		url      string
+		accept   string
+		expected Format
+		wantErr  bool
+	}{
+		{name: "no accept header", url: "/", expected: JSON},
+		{name: "wildcard", url: "/", accept: "*/*", expected: JSON},
+		{name: "browser", url: "/", accept: "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", expected: HTML},
+		{name: "yaml", url: "/", accept: "application/yaml", expected: YAML},
+		{name: "plain text", url: "/", accept: "text/plain", expected: Text},
+		{name: "msgpack", url: "/", accept: "application/msgpack", expected: MsgPack},
+		{name: "quality ordering", url: "/", accept: "text/plain;q=0.5, application/yaml;q=0.9", expected: YAML},
+		{name: "zero quality ignored", url: "/", accept: "text/html;q=0, text/plain", expected: Text},
+		{name: "unsupported falls back to JSON", url: "/", accept: "application/xml", expected: JSON},
+		{name: "query overrides accept", url: "/?format=pretty", accept: "text/html", expected: PrettyJSON},
+		{name: "invalid query", url: "/?format=xml", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			f, err := Negotiate(req)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, f)
+		})
+	}
+}
+
+func TestEncode_JSON(t *testing.T) {
+	data, err := Encode(JSON, newSample())
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"hostname":"echo-host","count":3,"ratio":0.5,"enabled":true,"headers":{"Accept":["text/html","*/*"]}}`, string(data))
+
+	pretty, err := Encode(PrettyJSON, newSample())
+	require.NoError(t, err)
+	assert.JSONEq(t, string(data), string(pretty))
+	assert.Contains(t, string(pretty), "\n  \"hostname\"")
+}
+
+func TestEncode_YAML(t *testing.T) {
+	data, err := Encode(YAML, newSample())
+	require.NoError(t, err)
+
+	var decoded map[string]any
+	require.NoError(t, yaml.Unmarshal(data, &decoded))
+	assert.Equal(t, "echo-host", decoded["hostname"])
+	assert.Equal(t, 3, decoded["count"])
+	assert.Equal(t, 0.5, decoded["ratio"])
+	assert.Equal(t, true, decoded["enabled"])
+	assert.NotContains(t, decoded, "node")
+
+	// Keys keep the JSON field order
+	assert.True(t, strings.HasPrefix(string(data), "hostname: echo-host\ncount: 3\n"))
+}
+
+func TestEncode_Text(t *testing.T) {
+	data, err := Encode(Text, newSample())
+	require.NoError(t, err)
+	assert.Equal(t, "hostname: echo-host\ncount: 3\nratio: 0.5\nenabled: true\nheaders:\n  Accept: text/html, */*\n", string(data))
+}
+
+func TestEncode_HTML(t *testing.T) {
+	s := newSample()
+	s.Hostname = "<script>alert(1)</script>"
+	data, err := Encode(HTML, s)
+	require.NoError(t, err)
+
+	page := string(data)
+	assert.Contains(t, page, "<!DOCTYPE html>")
+	assert.Contains(t, page, "<th>hostname</th>")
+	assert.Contains(t, page, "&lt;script&gt;")
+	assert.NotContains(t, page, "<script>")
+	assert.Contains(t, page, "text/html, */*")
+}
+
+func TestEncode_MsgPack(t *testing.T) {
+	data, err := Encode(MsgPack, newSample())
+	require.NoError(t, err)
+
+	var decoded map[string]any
+	require.NoError(t, msgpack.Unmarshal(data, &decoded))
+	assert.Equal(t, "echo-host", decoded["hostname"])
+	assert.EqualValues(t, 3, decoded["count"])
+	assert.Equal(t, 0.5, decoded["ratio"])
+	assert.Equal(t, true, decoded["enabled"])
+}
+
+func TestEncode_FieldsMatchAcrossFormats(t *testing.T) {
+	jsonData, err := Encode(JSON, newSample())
+	require.NoError(t, err)
+	var fromJSON map[string]any
+	require.NoError(t, json.Unmarshal(jsonData, &fromJSON))
+
+	yamlData, err := Encode(YAML, newSample())
+	require.NoError(t, err)
+	var fromYAML map[string]any
+	require.NoError(t, yaml.Unmarshal(yamlData, &fromYAML))
+
+	msgpackData, err := Encode(MsgPack, newSample())
+	require.NoError(t, err)
+	var fromMsgPack map[string]any
+	require.NoError(t, msgpack.Unmarshal(msgpackData, &fromMsgPack))
+
+	for key := range fromJSON {
+		assert.Contains(t, fromYAML, key)
+		assert.Contains(t, fromMsgPack, key)
+	}
+	assert.Len(t, fromYAML, len(fromJSON))
+	assert.Len(t, fromMsgPack, len(fromJSON))
+}
+
+func TestContentType(t *testing.T) {
+	assert.Equal(t, "application/json", JSON.ContentType())
+	assert.Equal(t, "application/json", PrettyJSON.ContentType())
+	assert.Equal(t, "application/yaml", YAML.ContentType())
+	assert.Equal(t, "text/plain; charset=utf-8", Text.ContentType())
+	assert.Equal(t, "text/html; charset=utf-8", HTML.ContentType())
+	assert.Equal(t, "application/msgpack", MsgPack.ContentType())
+}
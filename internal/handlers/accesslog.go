@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/PhilipSchmid/echo-app/internal/accesslog"
+)
+
+// statusWriter records the status code and size of an HTTP response for the
+// access log
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+// WriteHeader records the status code
+func (w *statusWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write records the number of bytes written
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// captureBody reads up to limit bytes of the request body for the access log
+// and restores them so the body can still be consumed downstream
+func captureBody(r *http.Request, limit int64) []byte {
+	if r.Body == nil || limit <= 0 {
+		return nil
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, limit))
+	r.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(body), r.Body), Closer: r.Body}
+	if err != nil {
+		return nil
+	}
+	return body
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// logHTTPAccess writes the access log record for an HTTP request
+func logHTTPAccess(r *http.Request, listener, id string, w *statusWriter, start time.Time, body []byte) {
+	logger := accesslog.Default()
+	if logger == nil {
+		return
+	}
+
+	rec := accesslog.Record{
+		Time:       start,
+		RequestID:  id,
+		Listener:   listener,
+		Protocol:   r.Proto,
+		RemoteAddr: r.RemoteAddr,
+		Method:     r.Method,
+		Path:       r.URL.Path,
+		Query:      r.URL.RawQuery,
+		Host:       r.Host,
+		Status:     w.status,
+		BytesOut:   w.bytes,
+		Duration:   time.Since(start),
+		UserAgent:  r.UserAgent(),
+		Referer:    r.Referer(),
+		Body:       body,
+	}
+	if r.ContentLength > 0 {
+		rec.BytesIn = r.ContentLength
+	}
+	if logger.IncludeHeaders() {
+		rec.Headers = r.Header
+	}
+	logger.Log(rec)
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PhilipSchmid/echo-app/internal/accesslog"
+	"github.com/PhilipSchmid/echo-app/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+)
+
+// useAccessLog installs a JSON access logger for the duration of the test
+func useAccessLog(t *testing.T, cfg config.AccessLog) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	cfg.Format = config.AccessLogFormatJSON
+	logger, err := accesslog.NewWriter(&buf, cfg)
+	require.NoError(t, err)
+	accesslog.SetDefault(logger)
+	t.Cleanup(func() { accesslog.SetDefault(nil) })
+	return &buf
+}
+
+func decodeAccessRecord(t *testing.T, buf *bytes.Buffer) map[string]any {
+	t.Helper()
+	var rec map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &rec), buf.String())
+	return rec
+}
+
+func TestHTTPHandler_AccessLog(t *testing.T) {
+	buf := useAccessLog(t, config.AccessLog{
+		IncludeHeaders: true,
+		IncludeBody:    true,
+		MaxBodySize:    1024,
+		Redact:         []string{"Authorization", "password"},
+	})
+
+	cfg := &config.Config{Message: "Test Message", MaxRequestSize: 1024}
+	handler := HTTPHandler(cfg, "HTTP")
+
+	body := `{"user":"alice","password":"hunter2"}`
+	req := httptest.NewRequest("POST", "http://localhost:8080/login?debug=1", strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("User-Agent", "test-agent")
+	w := httptest.NewRecorder()
+
+	handler(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	rec := decodeAccessRecord(t, buf)
+	assert.Equal(t, "HTTP", rec["listener"])
+	assert.Equal(t, "POST", rec["method"])
+	assert.Equal(t, "/login", rec["path"])
+	assert.Equal(t, "debug=1", rec["query"])
+	assert.Equal(t, float64(http.StatusOK), rec["status"])
+	assert.Equal(t, float64(w.Body.Len()), rec["bytes_out"])
+	assert.Equal(t, float64(len(body)), rec["bytes_in"])
+	assert.Equal(t, "test-agent", rec["user_agent"])
+	assert.Equal(t, map[string]any{
+		"Authorization": []any{"[REDACTED]"},
+		"User-Agent":    []any{"test-agent"},
+	}, rec["headers"])
+	assert.JSONEq(t, `{"user":"alice","password":"[REDACTED]"}`, rec["body"].(string))
+}
+
+func TestCaptureBody(t *testing.T) {
+	body := `{"user":"alice"}`
+	req := httptest.NewRequest("POST", "/", strings.NewReader(body))
+
+	assert.Equal(t, `{"user"`, string(captureBody(req, 7)))
+
+	// The captured prefix is restored so the body can still be read downstream
+	remaining, err := io.ReadAll(req.Body)
+	require.NoError(t, err)
+	assert.Equal(t, body, string(remaining))
+}
+
+func TestHTTPHandler_AccessLogErrorStatus(t *testing.T) {
+	buf := useAccessLog(t, config.AccessLog{Fields: []string{"status"}})
+
+	handler := HTTPHandler(&config.Config{MaxRequestSize: 1024}, "HTTP")
+	req := httptest.NewRequest("GET", "http://localhost:8080/?format=bogus", nil)
+	w := httptest.NewRecorder()
+
+	handler(w, req)
+	assert.Equal(t, `{"status":400}`+"\n", buf.String())
+}
+
+func TestTCPHandler_AccessLog(t *testing.T) {
+	buf := useAccessLog(t, config.AccessLog{})
+
+	mockConn := new(MockTCPConn)
+	mockConn.On("Write", mock.Anything).Return(42, nil)
+	mockConn.On("Close").Return(nil)
+
+	TCPHandler(context.Background(), mockConn, &config.Config{Message: "Test TCP"})
+
+	rec := decodeAccessRecord(t, buf)
+	assert.Equal(t, "TCP", rec["listener"])
+	assert.Equal(t, "127.0.0.1", rec["source_ip"])
+	assert.Equal(t, float64(42), rec["bytes_out"])
+	assert.NotContains(t, rec, "method")
+}
+
+func TestLogGRPCAccess(t *testing.T) {
+	buf := useAccessLog(t, config.AccessLog{Fields: []string{"listener", "path", "grpc_code", "user_agent", "host"}})
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+		"user-agent", "grpc-go/1.0",
+		":authority", "localhost:50051",
+	))
+	logGRPCAccess(ctx, "/echo.EchoService/Echo", "127.0.0.1:1234", codes.OK, time.Now())
+
+	assert.Equal(t, `{"listener":"gRPC","path":"/echo.EchoService/Echo","grpc_code":"OK","user_agent":"grpc-go/1.0","host":"localhost:50051"}`+"\n", buf.String())
+}
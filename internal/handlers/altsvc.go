@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/PhilipSchmid/echo-app/internal/config"
+	"github.com/PhilipSchmid/echo-app/internal/utils"
+)
+
+// AltSvcInfo describes the HTTP/3 advertisement for a request
+type AltSvcInfo struct {
+	Advertised string `json:"advertised"`
+	Upgraded   bool   `json:"upgraded"`
+}
+
+// altSvcHeaderValue returns the Alt-Svc header advertising the QUIC listener,
+// or an empty string when HTTP/3 should not be advertised. Browsers only honour
+// Alt-Svc on secure origins, so this requires both the TLS and QUIC listeners.
+// An ephemeral QUIC port is only advertised through AltSvcPort.
+func altSvcHeaderValue(cfg *config.Config) string {
+	if !cfg.AltSvc || !cfg.TLS || !cfg.QUIC {
+		return ""
+	}
+
+	port := cfg.AltSvcPort
+	if port == "" {
+		port = cfg.QUICPort
+	}
+	if port == utils.EphemeralPort {
+		return ""
+	}
+
+	return fmt.Sprintf(`h3=":%s"; ma=%d`, port, int64(cfg.AltSvcMaxAge.Seconds()))
+}
+
+// setAltSvcHeader advertises HTTP/3 on the secure listeners and reports the
+// advertisement for inclusion in the echo response
+func setAltSvcHeader(w http.ResponseWriter, r *http.Request, cfg *config.Config, listener string) *AltSvcInfo {
+	if listener != "TLS" && listener != "QUIC" {
+		return nil
+	}
+
+	value := altSvcHeaderValue(cfg)
+	if value == "" {
+		return nil
+	}
+
+	w.Header().Set("Alt-Svc", value)
+	return &AltSvcInfo{
+		Advertised: value,
+		Upgraded:   r.ProtoMajor == 3,
+	}
+}
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/PhilipSchmid/echo-app/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func altSvcConfig() *config.Config {
+	return &config.Config{
+		TLS:          true,
+		QUIC:         true,
+		QUICPort:     "4433",
+		AltSvc:       true,
+		AltSvcMaxAge: 24 * time.Hour,
+	}
+}
+
+func TestAltSvcHeaderValue(t *testing.T) {
+	tests := []struct {
+		name     string
+		modify   func(cfg *config.Config)
+		expected string
+	}{
+		{
+			name:     "advertises QUIC port",
+			modify:   func(cfg *config.Config) {},
+			expected: `h3=":4433"; ma=86400`,
+		},
+		{
+			name:     "external port override",
+			modify:   func(cfg *config.Config) { cfg.AltSvcPort = "443" },
+			expected: `h3=":443"; ma=86400`,
+		},
+		{
+			name:     "custom max age",
+			modify:   func(cfg *config.Config) { cfg.AltSvcMaxAge = time.Minute },
+			expected: `h3=":4433"; ma=60`,
+		},
+		{
+			name:     "disabled",
+			modify:   func(cfg *config.Config) { cfg.AltSvc = false },
+			expected: "",
+		},
+		{
+			name:     "QUIC listener not enabled",
+			modify:   func(cfg *config.Config) { cfg.QUIC = false },
+			expected: "",
+		},
+		{
+			name:     "ephemeral QUIC port",
+			modify:   func(cfg *config.Config) { cfg.QUICPort = "0" },
+			expected: "",
+		},
+		{
+			name: "ephemeral QUIC port with external port",
+			modify: func(cfg *config.Config) {
+				cfg.QUICPort = "0"
+				cfg.AltSvcPort = "443"
+			},
+			expected: `h3=":443"; ma=86400`,
+		},
+		{
+			name:     "TLS listener not enabled",
+			modify:   func(cfg *config.Config) { cfg.TLS = false },
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := altSvcConfig()
+			tt.modify(cfg)
+			assert.Equal(t, tt.expected, altSvcHeaderValue(cfg))
+		})
+	}
+}
+
+func TestHTTPHandler_AltSvc(t *testing.T) {
+	tests := []struct {
+		name       string
+		listener   string
+		protoMajor int
+		advertised bool
+		upgraded   bool
+	}{
+		{name: "TLS listener advertises HTTP/3", listener: "TLS", protoMajor: 2, advertised: true},
+		{name: "QUIC listener reports upgrade", listener: "QUIC", protoMajor: 3, advertised: true, upgraded: true},
+		{name: "plaintext HTTP listener", listener: "HTTP", protoMajor: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := HTTPHandler(altSvcConfig(), tt.listener)
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.ProtoMajor = tt.protoMajor
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			var response HTTPResponse
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+
+			if !tt.advertised {
+				assert.Empty(t, w.Header().Get("Alt-Svc"))
+				assert.Nil(t, response.AltSvc)
+				return
+			}
+
+			assert.Equal(t, `h3=":4433"; ma=86400`, w.Header().Get("Alt-Svc"))
+			require.NotNil(t, response.AltSvc)
+			assert.Equal(t, `h3=":4433"; ma=86400`, response.AltSvc.Advertised)
+			assert.Equal(t, tt.upgraded, response.AltSvc.Upgraded)
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/PhilipSchmid/echo-app/internal/config"
+	"github.com/PhilipSchmid/echo-app/internal/requestid"
+	"github.com/PhilipSchmid/echo-app/internal/upstream"
+	"github.com/sirupsen/logrus"
+)
+
+// ChainDepthHeader carries the number of hops a chained request has passed
+const ChainDepthHeader = "X-Echo-Chain-Depth"
+
+// ChainResponse is this hop's echo response with the responses of all
+// upstreams it called embedded, producing a nested hop-by-hop trace
+type ChainResponse struct {
+	HTTPResponse
+	Depth     int               `json:"depth"`
+	Upstreams []upstream.Result `json:"upstreams"`
+}
+
+// ChainHandler returns an HTTP handler that calls the upstreams named by
+// ?upstream= (or the configured ones) in parallel and embeds their responses.
+// Requests may only name configured or allowed upstreams, so the endpoint
+// cannot be used to reach arbitrary hosts.
+func ChainHandler(cfg *config.Config, listener string) http.HandlerFunc {
+	return newHTTPHandler(cfg, listener, func(r *http.Request, cfg *config.Config, response HTTPResponse, log *logrus.Entry) (any, int, error) {
+		if !cfg.Chain.Enabled {
+			return nil, http.StatusNotFound, errors.New("upstream chaining is disabled")
+		}
+		depth, err := chainDepth(r)
+		if err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+		if depth >= cfg.Chain.MaxDepth {
+			return nil, http.StatusLoopDetected, fmt.Errorf("chain depth %d reached the maximum of %d", depth, cfg.Chain.MaxDepth)
+		}
+		targets, err := chainTargets(r, cfg)
+		if err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+		for _, target := range targets {
+			if !cfg.Chain.Allows(target) {
+				return nil, http.StatusForbidden, fmt.Errorf("upstream %q is not a chain upstream or allowed upstream", target)
+			}
+		}
+		timeout, err := chainTimeout(r, cfg)
+		if err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+
+		if len(targets) > 0 {
+			log.Infof("[%s] Chaining to %d upstream(s) at depth %d", listener, len(targets), depth)
+		}
+		results := upstream.CallAll(r.Context(), targets, upstream.Options{
+			Timeout:            timeout,
+			Headers:            chainHeaders(r, cfg, depth+1),
+			InsecureSkipVerify: cfg.Chain.InsecureSkipVerify,
+		})
+
+		// Headers are always echoed so propagation can be verified per hop
+		response.Headers = r.Header
+		statusCode := http.StatusOK
+		for _, result := range results {
+			if !result.OK() {
+				log.Warnf("[%s] Upstream %s failed: %s", listener, result.Target, result.Error)
+				statusCode = http.StatusBadGateway
+			}
+		}
+		return ChainResponse{HTTPResponse: response, Depth: depth, Upstreams: results}, statusCode, nil
+	})
+}
+
+// chainDepth returns the number of hops before this one
+func chainDepth(r *http.Request) (int, error) {
+	value := r.Header.Get(ChainDepthHeader)
+	if value == "" {
+		return 0, nil
+	}
+	depth, err := strconv.Atoi(value)
+	if err != nil || depth < 0 {
+		return 0, fmt.Errorf("invalid %s header: %q", ChainDepthHeader, value)
+	}
+	return depth, nil
+}
+
+// chainTargets returns the validated upstreams for a request. Without any
+// upstreams this hop is the end of the chain.
+func chainTargets(r *http.Request, cfg *config.Config) ([]string, error) {
+	targets := r.URL.Query()["upstream"]
+	if len(targets) == 0 {
+		targets = cfg.Chain.Upstreams
+	}
+	if len(targets) > cfg.Chain.MaxUpstreams {
+		return nil, fmt.Errorf("%d upstreams requested, at most %d allowed", len(targets), cfg.Chain.MaxUpstreams)
+	}
+	for _, target := range targets {
+		if _, err := upstream.Parse(target); err != nil {
+			return nil, err
+		}
+	}
+	return targets, nil
+}
+
+// chainTimeout returns the per-call timeout, honouring ?timeout= up to
+// config.MaxChainTimeout
+func chainTimeout(r *http.Request, cfg *config.Config) (time.Duration, error) {
+	value := r.URL.Query().Get("timeout")
+	if value == "" {
+		return cfg.Chain.Timeout, nil
+	}
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout <= 0 || timeout > config.MaxChainTimeout {
+		return 0, fmt.Errorf("invalid timeout %q: must be a duration between 0 and %s", value, config.MaxChainTimeout)
+	}
+	return timeout, nil
+}
+
+// chainHeaders returns the headers forwarded to upstreams: the configured
+// propagation headers, the request ID and the next chain depth. Trace
+// context is injected per call.
+func chainHeaders(r *http.Request, cfg *config.Config, depth int) http.Header {
+	headers := make(http.Header)
+	for _, name := range cfg.Chain.PropagateHeaders {
+		if values := r.Header.Values(name); len(values) > 0 {
+			headers[http.CanonicalHeaderKey(name)] = values
+		}
+	}
+	if id := requestid.FromContext(r.Context()); id != "" {
+		headers.Set(requestIDHeader(cfg), id)
+	}
+	headers.Set(ChainDepthHeader, strconv.Itoa(depth))
+	return headers
+}
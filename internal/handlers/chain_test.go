@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/PhilipSchmid/echo-app/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// chainConfig returns a config with chaining enabled and the default chain
+// settings
+func chainConfig(upstreams ...string) *config.Config {
+	return &config.Config{
+		MaxRequestSize: 1024,
+		Chain: config.Chain{
+			Enabled:          true,
+			Upstreams:        upstreams,
+			Timeout:          time.Second,
+			MaxUpstreams:     3,
+			MaxDepth:         3,
+			PropagateHeaders: []string{"X-Tenant"},
+		},
+	}
+}
+
+// chainResponse mirrors ChainResponse with nested upstream responses decoded
+type chainResponse struct {
+	HTTPResponse
+	Depth     int `json:"depth"`
+	Upstreams []struct {
+		Target   string         `json:"target"`
+		Status   int            `json:"status"`
+		Error    string         `json:"error"`
+		Response *chainResponse `json:"response"`
+	} `json:"upstreams"`
+}
+
+func TestChainHandler_NestedHops(t *testing.T) {
+	// leaf <- middle <- entry, each hop configured with its upstream
+	leaf := httptest.NewServer(ChainHandler(chainConfig(), "HTTP"))
+	defer leaf.Close()
+	middle := httptest.NewServer(ChainHandler(chainConfig(leaf.URL+"/chain"), "HTTP"))
+	defer middle.Close()
+
+	req := httptest.NewRequest("GET", "/chain?upstream="+url.QueryEscape(middle.URL+"/chain"), nil)
+	req.Header.Set("X-Request-ID", "chain-test")
+	req.Header.Set("X-Tenant", "acme")
+	req.Header.Set("X-Private", "secret")
+	req.Header.Set("traceparent", testTraceparent)
+	w := httptest.NewRecorder()
+	entryConfig := chainConfig("http://ignored")
+	entryConfig.Chain.AllowedUpstreams = []string{middle.URL}
+	ChainHandler(entryConfig, "HTTP")(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var entry chainResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &entry))
+	assert.Equal(t, 0, entry.Depth)
+	assert.Equal(t, "chain-test", entry.RequestID)
+	assert.Equal(t, []string{"acme"}, entry.Headers["X-Tenant"])
+	require.Len(t, entry.Upstreams, 1)
+	assert.Equal(t, middle.URL+"/chain", entry.Upstreams[0].Target)
+	assert.Equal(t, http.StatusOK, entry.Upstreams[0].Status)
+
+	hop := entry.Upstreams[0].Response
+	require.NotNil(t, hop)
+	assert.Equal(t, 1, hop.Depth)
+	assert.Equal(t, "chain-test", hop.RequestID)
+	assert.Equal(t, []string{"acme"}, hop.Headers["X-Tenant"])
+	assert.NotContains(t, hop.Headers, "X-Private")
+	require.NotNil(t, hop.Trace)
+	assert.Equal(t, testTraceID, hop.Trace.TraceID)
+	require.Len(t, hop.Upstreams, 1)
+
+	last := hop.Upstreams[0].Response
+	require.NotNil(t, last)
+	assert.Equal(t, 2, last.Depth)
+	assert.Equal(t, "chain-test", last.RequestID)
+	assert.Empty(t, last.Upstreams)
+}
+
+func TestChainHandler_FanOut(t *testing.T) {
+	ok := httptest.NewServer(HTTPHandler(&config.Config{MaxRequestSize: 1024}, "HTTP"))
+	defer ok.Close()
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+
+	w := httptest.NewRecorder()
+	ChainHandler(chainConfig(ok.URL, failing.URL), "HTTP")(w, httptest.NewRequest("GET", "/chain", nil))
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+
+	var response ChainResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.Upstreams, 2)
+	assert.Equal(t, ok.URL, response.Upstreams[0].Target)
+	assert.True(t, response.Upstreams[0].OK())
+	assert.Contains(t, string(response.Upstreams[0].Response), `"listener":"HTTP"`)
+	assert.Equal(t, failing.URL, response.Upstreams[1].Target)
+	assert.Equal(t, "upstream returned 503 Service Unavailable", response.Upstreams[1].Error)
+	assert.JSONEq(t, `"unavailable\n"`, string(response.Upstreams[1].Response))
+}
+
+func TestChainHandler_Timeout(t *testing.T) {
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer slow.Close()
+
+	w := httptest.NewRecorder()
+	ChainHandler(chainConfig(slow.URL), "HTTP")(w, httptest.NewRequest("GET", "/chain?timeout=50ms", nil))
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+	assert.Contains(t, w.Body.String(), "context deadline exceeded")
+}
+
+func TestChainHandler_InvalidRequests(t *testing.T) {
+	tests := []struct {
+		name           string
+		target         string
+		depth          string
+		disabled       bool
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "chaining disabled",
+			target:         "/chain",
+			disabled:       true,
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "upstream chaining is disabled",
+		},
+		{
+			name:           "upstream not allowed",
+			target:         "/chain?upstream=" + url.QueryEscape("http://10.0.0.1:8080/admin"),
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   `upstream "http://10.0.0.1:8080/admin" is not a chain upstream or allowed upstream`,
+		},
+		{
+			name:           "allowed upstream on another port",
+			target:         "/chain?upstream=" + url.QueryEscape("http://allowed:9090/chain"),
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   "is not a chain upstream or allowed upstream",
+		},
+		{
+			name:           "invalid upstream",
+			target:         "/chain?upstream=ftp://svc",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "scheme must be one of",
+		},
+		{
+			name:           "too many upstreams",
+			target:         "/chain?upstream=http://a&upstream=http://b&upstream=http://c&upstream=http://d",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "4 upstreams requested, at most 3 allowed",
+		},
+		{
+			name:           "invalid timeout",
+			target:         "/chain?timeout=1h",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `invalid timeout "1h"`,
+		},
+		{
+			name:           "invalid depth",
+			target:         "/chain",
+			depth:          "-1",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "invalid X-Echo-Chain-Depth header",
+		},
+		{
+			name:           "maximum depth reached",
+			target:         "/chain",
+			depth:          "3",
+			expectedStatus: http.StatusLoopDetected,
+			expectedBody:   "chain depth 3 reached the maximum of 3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.target, nil)
+			if tt.depth != "" {
+				req.Header.Set(ChainDepthHeader, tt.depth)
+			}
+			cfg := chainConfig()
+			cfg.Chain.Enabled = !tt.disabled
+			cfg.Chain.AllowedUpstreams = []string{"http://allowed:8080"}
+			w := httptest.NewRecorder()
+			ChainHandler(cfg, "HTTP")(w, req)
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			assert.NotEmpty(t, w.Header().Get("X-Request-ID"))
+		})
+	}
+}
+
+func TestChainHeaders(t *testing.T) {
+	req := httptest.NewRequest("GET", "/chain", nil)
+	req.Header.Add("x-tenant", "a")
+	req.Header.Add("x-tenant", "b")
+	req.Header.Set("Cookie", "secret")
+
+	headers := chainHeaders(req, chainConfig(), 2)
+	assert.Equal(t, []string{"a", "b"}, headers.Values("X-Tenant"))
+	assert.Equal(t, "2", headers.Get(ChainDepthHeader))
+	assert.Empty(t, headers.Get("Cookie"))
+	assert.Empty(t, headers.Get("X-Request-ID"))
+}
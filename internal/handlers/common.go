@@ -1,12 +1,19 @@
 package handlers
 
 import (
+	"encoding/json"
+	"fmt"
+	"io"
 	"net"
+	"net/http"
 	"os"
 	"sync"
 	"time"
 
 	"github.com/PhilipSchmid/echo-app/internal/config"
+	"github.com/PhilipSchmid/echo-app/internal/requestid"
+	"github.com/PhilipSchmid/echo-app/internal/tracing"
+	"github.com/PhilipSchmid/echo-app/internal/utils"
 	"github.com/sirupsen/logrus"
 )
 
@@ -30,12 +37,31 @@ func getHostname() string {
 
 // BaseResponse contains common fields for all responses
 type BaseResponse struct {
-	Timestamp string `json:"timestamp"`
-	Message   string `json:"message,omitempty"`
-	Hostname  string `json:"hostname"`
-	Listener  string `json:"listener"`
-	Node      string `json:"node,omitempty"`
-	SourceIP  string `json:"source_ip"`
+	Timestamp string        `json:"timestamp"`
+	Message   string        `json:"message,omitempty"`
+	Hostname  string        `json:"hostname"`
+	Listener  string        `json:"listener"`
+	Node      string        `json:"node,omitempty"`
+	SourceIP  string        `json:"source_ip"`
+	LocalAddr string        `json:"local_address,omitempty"`
+	RequestID string        `json:"request_id,omitempty"`
+	Trace     *tracing.Info `json:"trace,omitempty"`
+}
+
+// MaxResponseSize caps the echo responses clients read
+const MaxResponseSize = 1 << 20
+
+// DecodeResponse reads a JSON echo response of at most MaxResponseSize bytes
+// into v
+func DecodeResponse(r io.Reader, v any) error {
+	data, err := io.ReadAll(io.LimitReader(r, MaxResponseSize))
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("invalid JSON response: %w", err)
+	}
+	return nil
 }
 
 // NewBaseResponse creates a base response with common fields
@@ -52,6 +78,20 @@ func NewBaseResponse(cfg *config.Config, listener string, remoteAddr string) Bas
 	}
 }
 
+// requestIDHeader returns the header used to accept and return request IDs
+func requestIDHeader(cfg *config.Config) string {
+	if cfg.RequestIDHeader == "" {
+		return requestid.DefaultHeader
+	}
+	return cfg.RequestIDHeader
+}
+
+// requestLogger returns a log entry tagged with the request ID so every log
+// line of a request can be correlated
+func requestLogger(id string) *logrus.Entry {
+	return logrus.WithField(requestid.LogField, id)
+}
+
 // extractIP extracts the IP address from a remote address string
 func extractIP(remoteAddr string) string {
 	if remoteAddr == "" {
@@ -71,15 +111,24 @@ func extractIP(remoteAddr string) string {
 	return ip
 }
 
+// requestLocalAddress returns the local address an HTTP request was received
+// on, as set by the HTTP and HTTP/3 servers
+func requestLocalAddress(r *http.Request) string {
+	addr, _ := r.Context().Value(http.LocalAddrContextKey).(net.Addr)
+	return utils.FormatAddr(addr)
+}
+
 // normalizeEndpoint normalizes HTTP endpoints to prevent high cardinality in metrics
 // Known paths are preserved, all others are grouped as "other"
 func normalizeEndpoint(path string) string {
 	// List of known paths to track individually
 	knownPaths := map[string]bool{
-		"/":        true,
-		"/health":  true,
-		"/ready":   true,
-		"/metrics": true,
+		"/":             true,
+		"/chain":        true,
+		"/health":       true,
+		"/ready":        true,
+		"/metrics":      true,
+		"/webtransport": true,
 	}
 
 	if knownPaths[path] {
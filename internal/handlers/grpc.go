@@ -2,12 +2,19 @@ package handlers
 
 import (
 	"context"
+	"strings"
 	"time"
 
+	"github.com/PhilipSchmid/echo-app/internal/accesslog"
 	"github.com/PhilipSchmid/echo-app/internal/config"
 	"github.com/PhilipSchmid/echo-app/internal/metrics"
+	"github.com/PhilipSchmid/echo-app/internal/requestid"
+	"github.com/PhilipSchmid/echo-app/internal/tracing"
+	"github.com/PhilipSchmid/echo-app/internal/utils"
 	"github.com/PhilipSchmid/echo-app/proto"
 	"github.com/sirupsen/logrus"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.43.0"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
@@ -29,15 +36,39 @@ func NewEchoServer(cfg *config.Config) *EchoServer {
 // Echo handles the Echo request
 func (s *EchoServer) Echo(ctx context.Context, req *proto.EchoRequest) (*proto.EchoResponse, error) {
 	start := time.Now()
+	cfg := s.cfg.Current()
 	method, ok := grpc.Method(ctx)
 	if !ok {
 		method = "unknown"
 	}
 
+	// Accept the caller's request ID from metadata or generate one, and
+	// return it in the response header metadata
+	header := requestIDHeader(cfg)
+	var incomingID string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(header); len(values) > 0 {
+			incomingID = values[0]
+		}
+	}
+	id := requestid.FromHeader(incomingID)
+	ctx = requestid.NewContext(ctx, id)
+	log := requestLogger(id)
+	if err := grpc.SetHeader(ctx, metadata.Pairs(header, id)); err != nil {
+		log.Debugf("[gRPC] Failed to set request ID header: %v", err)
+	}
+
+	// Continue the caller's W3C trace, if any, with a server span
+	md, _ := metadata.FromIncomingContext(ctx)
+	ctx, span := tracing.StartServerSpan(ctx, tracing.MetadataCarrier(md), strings.TrimPrefix(method, "/"),
+		semconv.RPCSystemNameGRPC,
+		semconv.RPCMethod(method),
+	)
+
 	// Panic recovery to prevent handler crashes
 	defer func() {
 		if rec := recover(); rec != nil {
-			logrus.Errorf("[gRPC] Recovered from panic: %v", rec)
+			log.Errorf("[gRPC] Recovered from panic: %v", rec)
 			metrics.RecordError("gRPC", "panic")
 		}
 	}()
@@ -57,53 +88,112 @@ func (s *EchoServer) Echo(ctx context.Context, req *proto.EchoRequest) (*proto.E
 			userAgent = ua[0]
 		}
 		// Log the gRPC request with key information
-		logrus.Infof("[gRPC] Request: %s from %s (User-Agent: %s)", method, sourceIP, userAgent)
+		log.Infof("[gRPC] Request: %s from %s (User-Agent: %s)", method, sourceIP, userAgent)
 
 		// Additional metadata information for troubleshooting
 		if contentType := md.Get("content-type"); len(contentType) > 0 {
-			logrus.Infof("[gRPC] Content-Type: %s", contentType[0])
+			log.Infof("[gRPC] Content-Type: %s", contentType[0])
 		}
 	} else {
-		logrus.Infof("[gRPC] Request: %s from %s (User-Agent: %s)", method, sourceIP, userAgent)
+		log.Infof("[gRPC] Request: %s from %s (User-Agent: %s)", method, sourceIP, userAgent)
 	}
 
 	// Debug logging (keep existing for detailed debugging)
-	logrus.Debugf("[gRPC] Incoming request: %s from %s", method, remoteAddr)
+	log.Debugf("[gRPC] Incoming request: %s from %s", method, remoteAddr)
 	if md, ok := metadata.FromIncomingContext(ctx); ok && logrus.GetLevel() >= logrus.DebugLevel {
-		logrus.Debugf("[gRPC] Request metadata: %+v", md)
+		log.Debugf("[gRPC] Request metadata: %+v", md)
 	}
 
+	code := codes.OK
 	defer func() {
 		duration := time.Since(start).Seconds()
-		metrics.RecordRequest("gRPC", method, "", duration)
-		logrus.Debugf("[gRPC] Response sent to %s in %.3fms", remoteAddr, duration*1000)
+		metrics.RecordRequestContext(ctx, "gRPC", method, "", duration)
+		log.Debugf("[gRPC] Response sent to %s in %.3fms", remoteAddr, duration*1000)
+		span.SetAttributes(semconv.RPCResponseStatusCode(code.String()))
+		if code != codes.OK {
+			span.SetStatus(otelcodes.Error, code.String())
+		}
+		span.End()
+		logGRPCAccess(ctx, method, remoteAddr, code, start)
 	}()
 
 	if req == nil {
 		metrics.RecordError("gRPC", "nil_request")
-		logrus.Debugf("[gRPC] Nil request from %s", remoteAddr)
-		return nil, status.Error(codes.InvalidArgument, "request is nil")
+		log.Debugf("[gRPC] Nil request from %s", remoteAddr)
+		code = codes.InvalidArgument
+		return nil, status.Error(code, "request is nil")
 	}
-	response := buildGRPCResponse(ctx, s.cfg, method)
+	response := buildGRPCResponse(ctx, cfg, method)
 	return response, nil
 }
 
 // buildGRPCResponse constructs the response struct for gRPC
 func buildGRPCResponse(ctx context.Context, cfg *config.Config, method string) *proto.EchoResponse {
-	remoteAddr := ""
+	remoteAddr, local := "", ""
 	if p, ok := peer.FromContext(ctx); ok {
 		remoteAddr = p.Addr.String()
+		local = utils.FormatAddr(p.LocalAddr)
 	}
 
 	base := NewBaseResponse(cfg, "gRPC", remoteAddr)
 
 	return &proto.EchoResponse{
-		Timestamp:  base.Timestamp,
-		Message:    base.Message,
-		Hostname:   base.Hostname,
-		Listener:   base.Listener,
-		Node:       base.Node,
-		SourceIp:   base.SourceIP,
-		GrpcMethod: method,
+		Timestamp:    base.Timestamp,
+		Message:      base.Message,
+		Hostname:     base.Hostname,
+		Listener:     base.Listener,
+		Node:         base.Node,
+		SourceIp:     base.SourceIP,
+		GrpcMethod:   method,
+		RequestId:    requestid.FromContext(ctx),
+		Trace:        protoTraceInfo(tracing.InfoFromContext(ctx)),
+		LocalAddress: local,
+	}
+}
+
+// protoTraceInfo converts the echoed trace context to its protobuf form
+func protoTraceInfo(info *tracing.Info) *proto.TraceInfo {
+	if info == nil {
+		return nil
+	}
+	return &proto.TraceInfo{
+		TraceId:      info.TraceID,
+		SpanId:       info.SpanID,
+		ParentSpanId: info.ParentSpanID,
+		Sampled:      info.Sampled,
+		Baggage:      info.Baggage,
+	}
+}
+
+// logGRPCAccess writes the access log record for a gRPC call
+func logGRPCAccess(ctx context.Context, method, remoteAddr string, code codes.Code, start time.Time) {
+	logger := accesslog.Default()
+	if logger == nil {
+		return
+	}
+
+	rec := accesslog.Record{
+		Time:       start,
+		RequestID:  requestid.FromContext(ctx),
+		Listener:   "gRPC",
+		Protocol:   "HTTP/2.0",
+		RemoteAddr: remoteAddr,
+		Method:     "POST",
+		Path:       method,
+		Status:     200,
+		GRPCCode:   code.String(),
+		Duration:   time.Since(start),
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if ua := md.Get("user-agent"); len(ua) > 0 {
+			rec.UserAgent = ua[0]
+		}
+		if authority := md.Get(":authority"); len(authority) > 0 {
+			rec.Host = authority[0]
+		}
+		if logger.IncludeHeaders() {
+			rec.Headers = md
+		}
 	}
+	logger.Log(rec)
 }
@@ -50,6 +50,7 @@ func TestEchoServer_WithPeerInfo(t *testing.T) {
 			IP:   net.ParseIP("192.168.1.1"),
 			Port: 50051,
 		},
+		LocalAddr: &net.UnixAddr{Name: "/run/echo/grpc.sock", Net: "unix"},
 	}
 	ctx := peer.NewContext(context.Background(), p)
 
@@ -64,6 +65,7 @@ func TestEchoServer_WithPeerInfo(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotNil(t, resp)
 	assert.Equal(t, "192.168.1.1", resp.SourceIp) // extractIP removes the port
+	assert.Equal(t, "unix:///run/echo/grpc.sock", resp.LocalAddress)
 	assert.Equal(t, "/echo.EchoService/Echo", resp.GrpcMethod)
 }
 
@@ -1,13 +1,20 @@
 package handlers
 
 import (
-	"encoding/json"
+	"fmt"
 	"net/http"
 	"time"
 
+	"github.com/PhilipSchmid/echo-app/internal/accesslog"
 	"github.com/PhilipSchmid/echo-app/internal/config"
+	"github.com/PhilipSchmid/echo-app/internal/format"
 	"github.com/PhilipSchmid/echo-app/internal/metrics"
+	"github.com/PhilipSchmid/echo-app/internal/requestid"
+	"github.com/PhilipSchmid/echo-app/internal/tracing"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.43.0"
 )
 
 // HTTPResponse defines the structure of the HTTP echo response
@@ -17,21 +24,68 @@ type HTTPResponse struct {
 	HTTPMethod   string              `json:"http_method,omitempty"`
 	HTTPEndpoint string              `json:"http_endpoint,omitempty"`
 	Headers      map[string][]string `json:"headers,omitempty"`
+	AltSvc       *AltSvcInfo         `json:"alt_svc,omitempty"`
 }
 
+// responder turns the echo response for a request into the payload and
+// status code to send, given the settings current for the request. A non-nil
+// error is sent to the client with the status.
+type responder func(r *http.Request, cfg *config.Config, response HTTPResponse, log *logrus.Entry) (any, int, error)
+
 // HTTPHandler returns an HTTP handler function
 func HTTPHandler(cfg *config.Config, listener string) http.HandlerFunc {
+	return newHTTPHandler(cfg, listener, nil)
+}
+
+// newHTTPHandler returns an HTTP handler sharing request IDs, tracing,
+// logging, metrics and content negotiation across endpoints. A nil respond
+// sends the echo response as is.
+func newHTTPHandler(cfg *config.Config, listener string, respond responder) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
+		cfg := cfg.Current() // Settings stay consistent for the request across reloads
+
+		// Accept the client's request ID or generate one, and return it
+		// before anything is written so error responses carry it too
+		header := requestIDHeader(cfg)
+		id := requestid.FromHeader(r.Header.Get(header))
+		w.Header().Set(header, id)
+		r = r.WithContext(requestid.NewContext(r.Context(), id))
+		log := requestLogger(id)
+
+		// Continue the caller's W3C trace, if any, with a server span
+		ctx, span := tracing.StartServerSpan(r.Context(), propagation.HeaderCarrier(r.Header),
+			r.Method+" "+normalizeEndpoint(r.URL.Path),
+			semconv.HTTPRequestMethodKey.String(r.Method),
+			semconv.URLPath(r.URL.Path),
+			semconv.NetworkProtocolVersion(fmt.Sprintf("%d.%d", r.ProtoMajor, r.ProtoMinor)),
+			semconv.ClientAddress(extractIP(r.RemoteAddr)),
+			semconv.UserAgentOriginal(r.UserAgent()),
+		)
+		r = r.WithContext(ctx)
+
+		// Record status and size for the access log and span. Deferred
+		// before panic recovery so both reflect the recovered 500 response.
+		sw := &statusWriter{ResponseWriter: w}
+		w = sw
+		var body []byte
+		defer func() {
+			span.SetAttributes(semconv.HTTPResponseStatusCode(sw.status))
+			if sw.status >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(sw.status))
+			}
+			span.End()
+			logHTTPAccess(r, effectiveListener(r, listener), id, sw, start, body)
+		}()
 
 		// Panic recovery to prevent handler crashes
 		defer func() {
 			if rec := recover(); rec != nil {
-				logrus.Errorf("[%s] Recovered from panic: %v", listener, rec)
+				log.Errorf("[%s] Recovered from panic: %v", listener, rec)
 				metrics.RecordError(listener, "panic")
 				w.WriteHeader(http.StatusInternalServerError)
 				if _, writeErr := w.Write([]byte("Internal Server Error")); writeErr != nil {
-					logrus.Errorf("Failed to write panic response: %v", writeErr)
+					log.Errorf("Failed to write panic response: %v", writeErr)
 				}
 			}
 		}()
@@ -43,15 +97,18 @@ func HTTPHandler(cfg *config.Config, listener string) http.HandlerFunc {
 			userAgent = "unknown"
 		}
 
-		logrus.Infof("[%s] Request: %s %s from %s (User-Agent: %s)",
+		log.Infof("[%s] Request: %s %s from %s (User-Agent: %s)",
 			listener, r.Method, r.URL.Path, sourceIP, userAgent)
 
 		// Limit request body size to prevent resource exhaustion
 		r.Body = http.MaxBytesReader(w, r.Body, cfg.MaxRequestSize)
+		if logger := accesslog.Default(); logger.IncludeBody() {
+			body = captureBody(r, logger.MaxBodySize())
+		}
 
 		// Additional header information if configured
 		if cfg.PrintHeaders {
-			logrus.Infof("[%s] Headers: Host=%s, Content-Type=%s, Accept=%s",
+			log.Infof("[%s] Headers: Host=%s, Content-Type=%s, Accept=%s",
 				listener,
 				r.Header.Get("Host"),
 				r.Header.Get("Content-Type"),
@@ -59,54 +116,87 @@ func HTTPHandler(cfg *config.Config, listener string) http.HandlerFunc {
 		}
 
 		// Debug logging (keep existing for detailed debugging)
-		logrus.Debugf("[%s] Incoming request: %s %s from %s", listener, r.Method, r.URL.Path, r.RemoteAddr)
+		log.Debugf("[%s] Incoming request: %s %s from %s", listener, r.Method, r.URL.Path, r.RemoteAddr)
 		if logrus.GetLevel() >= logrus.DebugLevel && cfg.PrintHeaders {
-			logrus.Debugf("[%s] Request headers: %+v", listener, r.Header)
+			log.Debugf("[%s] Request headers: %+v", listener, r.Header)
+		}
+
+		// Honour ?format= or the Accept header so every listener can serve
+		// the same response as JSON, YAML, text, HTML or MessagePack
+		w.Header().Add("Vary", "Accept")
+		responseFormat, err := format.Negotiate(r)
+		if err != nil {
+			log.Debugf("[%s] Rejecting request with invalid format: %v", listener, err)
+			metrics.RecordError(listener, "invalid_format")
+			http.Error(w, "Bad Request: "+err.Error(), http.StatusBadRequest)
+			return
 		}
 
 		response := buildHTTPResponse(r, cfg, listener)
-		data, err := json.Marshal(response)
+		response.RequestID = id
+		response.AltSvc = setAltSvcHeader(w, r, cfg, listener)
+		var payload any = response
+		statusCode := http.StatusOK
+		if respond != nil {
+			payload, statusCode, err = respond(r, cfg, response, log)
+			if err != nil {
+				log.Debugf("[%s] Rejecting request: %v", listener, err)
+				metrics.RecordError(listener, "invalid_request")
+				http.Error(w, http.StatusText(statusCode)+": "+err.Error(), statusCode)
+				return
+			}
+		}
+		data, err := format.Encode(responseFormat, payload)
 		if err != nil {
-			logrus.Errorf("Failed to marshal JSON: %v", err)
+			log.Errorf("Failed to marshal %s: %v", responseFormat, err)
 			metrics.RecordError(listener, "marshal_error")
 			w.WriteHeader(http.StatusInternalServerError)
 			if _, writeErr := w.Write([]byte("Internal Server Error")); writeErr != nil {
-				logrus.Errorf("Failed to write error response: %v", writeErr)
+				log.Errorf("Failed to write error response: %v", writeErr)
 			}
 			return
 		}
-		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Type", responseFormat.ContentType())
+		if statusCode != http.StatusOK {
+			w.WriteHeader(statusCode)
+		}
 		if _, writeErr := w.Write(data); writeErr != nil {
-			logrus.Errorf("Failed to write response: %v", writeErr)
+			log.Errorf("Failed to write response: %v", writeErr)
 			metrics.RecordError(listener, "write_error")
 		}
 		duration := time.Since(start).Seconds()
 		// Normalize endpoint to prevent high cardinality in metrics
 		normalizedPath := normalizeEndpoint(r.URL.Path)
-		metrics.RecordRequest(listener, r.Method, normalizedPath, duration)
+		metrics.RecordRequestContext(r.Context(), listener, r.Method, normalizedPath, duration)
 
 		// Debug logging for response
-		logrus.Debugf("[%s] Response sent: %d bytes in %.3fms", listener, len(data), duration*1000)
+		log.Debugf("[%s] Response sent: %d bytes in %.3fms", listener, len(data), duration*1000)
 	}
 }
 
 // buildHTTPResponse constructs the response struct
 func buildHTTPResponse(r *http.Request, cfg *config.Config, listener string) HTTPResponse {
-	// When running in H2C mode the listener serves both HTTP/1.1 and HTTP/2
-	// cleartext on the same port. Reflect the actually negotiated protocol so
-	// the response is meaningful rather than always showing "H2C".
-	effectiveListener := listener
-	if listener == "H2C" && r.ProtoMajor < 2 {
-		effectiveListener = "HTTP"
-	}
 	response := HTTPResponse{
-		BaseResponse: NewBaseResponse(cfg, effectiveListener, r.RemoteAddr),
+		BaseResponse: NewBaseResponse(cfg, effectiveListener(r, listener), r.RemoteAddr),
 		HTTPVersion:  r.Proto,
 		HTTPMethod:   r.Method,
 		HTTPEndpoint: r.URL.Path,
 	}
+	response.LocalAddr = requestLocalAddress(r)
+	response.Trace = tracing.InfoFromContext(r.Context())
 	if cfg.PrintHeaders {
 		response.Headers = r.Header
 	}
 	return response
 }
+
+// effectiveListener returns the listener name to report for a request. When
+// running in H2C mode the listener serves both HTTP/1.1 and HTTP/2 cleartext
+// on the same port. Reflect the actually negotiated protocol so the response
+// is meaningful rather than always showing "H2C".
+func effectiveListener(r *http.Request, listener string) string {
+	if listener == "H2C" && r.ProtoMajor < 2 {
+		return "HTTP"
+	}
+	return listener
+}
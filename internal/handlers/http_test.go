@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/PhilipSchmid/echo-app/internal/config"
@@ -42,3 +43,48 @@ func TestHTTPHandler(t *testing.T) {
 		t.Errorf("Expected listener 'HTTP', got '%s'", response.Listener)
 	}
 }
+
+func TestHTTPHandler_ContentNegotiation(t *testing.T) {
+	cfg := &config.Config{Message: "Test Message", MaxRequestSize: 1024}
+	handler := HTTPHandler(cfg, "HTTP")
+
+	tests := []struct {
+		name        string
+		url         string
+		accept      string
+		status      int
+		contentType string
+		contains    string
+	}{
+		{name: "default JSON", url: "/", status: http.StatusOK, contentType: "application/json", contains: `"message":"Test Message"`},
+		{name: "YAML via Accept", url: "/", accept: "application/yaml", status: http.StatusOK, contentType: "application/yaml", contains: "message: Test Message\n"},
+		{name: "text via query", url: "/?format=text", status: http.StatusOK, contentType: "text/plain; charset=utf-8", contains: "listener: HTTP\n"},
+		{name: "HTML for browsers", url: "/", accept: "text/html", status: http.StatusOK, contentType: "text/html; charset=utf-8", contains: "<th>message</th>"},
+		{name: "unknown format", url: "/?format=xml", status: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			if w.Code != tt.status {
+				t.Fatalf("Expected status %d, got %d", tt.status, w.Code)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			if got := w.Header().Get("Content-Type"); got != tt.contentType {
+				t.Errorf("Expected Content-Type '%s', got '%s'", tt.contentType, got)
+			}
+			if !strings.Contains(w.Body.String(), tt.contains) {
+				t.Errorf("Expected body to contain '%s', got '%s'", tt.contains, w.Body.String())
+			}
+		})
+	}
+}
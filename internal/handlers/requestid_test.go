@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/PhilipSchmid/echo-app/internal/config"
+	"github.com/PhilipSchmid/echo-app/proto"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// fakeServerTransportStream captures header metadata set by a gRPC handler
+type fakeServerTransportStream struct {
+	header metadata.MD
+}
+
+func (s *fakeServerTransportStream) Method() string { return "/echo.EchoService/Echo" }
+
+func (s *fakeServerTransportStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func (s *fakeServerTransportStream) SendHeader(md metadata.MD) error { return s.SetHeader(md) }
+
+func (s *fakeServerTransportStream) SetTrailer(metadata.MD) error { return nil }
+
+// captureLogs redirects application logs to a buffer for the duration of the test
+func captureLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	logrus.SetOutput(&buf)
+	t.Cleanup(func() { logrus.SetOutput(os.Stderr) })
+	return &buf
+}
+
+func assertUUIDv7(t *testing.T, id string) {
+	t.Helper()
+	parsed, err := uuid.Parse(id)
+	require.NoError(t, err, id)
+	assert.Equal(t, uuid.Version(7), parsed.Version())
+}
+
+func TestHTTPHandler_RequestID(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string // configured header, empty uses the default
+		incoming map[string]string
+		expected string // empty expects a generated ID
+	}{
+		{name: "generated when missing"},
+		{
+			name:     "propagates incoming ID",
+			incoming: map[string]string{"X-Request-ID": "client-123"},
+			expected: "client-123",
+		},
+		{
+			name:     "custom header",
+			header:   "X-Correlation-Id",
+			incoming: map[string]string{"X-Correlation-Id": "corr-456", "X-Request-ID": "ignored"},
+			expected: "corr-456",
+		},
+		{
+			name:     "replaces unsafe ID",
+			incoming: map[string]string{"X-Request-ID": "bad id\tvalue"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{MaxRequestSize: 1024, RequestIDHeader: tt.header}
+			header := tt.header
+			if header == "" {
+				header = "X-Request-ID"
+			}
+
+			req := httptest.NewRequest("GET", "/", nil)
+			for key, value := range tt.incoming {
+				req.Header.Set(key, value)
+			}
+			w := httptest.NewRecorder()
+			HTTPHandler(cfg, "HTTP")(w, req)
+			require.Equal(t, http.StatusOK, w.Code)
+
+			var response HTTPResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, w.Header().Get(header), response.RequestID)
+			if tt.expected != "" {
+				assert.Equal(t, tt.expected, response.RequestID)
+			} else {
+				assertUUIDv7(t, response.RequestID)
+			}
+		})
+	}
+}
+
+func TestHTTPHandler_RequestIDOnErrors(t *testing.T) {
+	req := httptest.NewRequest("GET", "/?format=bogus", nil)
+	req.Header.Set("X-Request-ID", "client-123")
+	w := httptest.NewRecorder()
+
+	HTTPHandler(&config.Config{MaxRequestSize: 1024}, "HTTP")(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "client-123", w.Header().Get("X-Request-ID"))
+}
+
+func TestHTTPHandler_RequestIDInLogs(t *testing.T) {
+	logs := captureLogs(t)
+	access := useAccessLog(t, config.AccessLog{Fields: []string{"request_id"}})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-Request-ID", "client-123")
+	HTTPHandler(&config.Config{MaxRequestSize: 1024}, "HTTP")(httptest.NewRecorder(), req)
+
+	assert.Contains(t, logs.String(), "request_id=client-123")
+	assert.Equal(t, `{"request_id":"client-123"}`+"\n", access.String())
+}
+
+func TestTCPHandler_RequestID(t *testing.T) {
+	logs := captureLogs(t)
+
+	mockConn := new(MockTCPConn)
+	mockConn.On("Write", mock.Anything).Return(len("some data"), nil).Once()
+	mockConn.On("Close").Return(nil).Once()
+
+	TCPHandler(context.Background(), mockConn, &config.Config{})
+
+	var response TCPResponse
+	require.NoError(t, json.Unmarshal(mockConn.Calls[0].Arguments.Get(0).([]byte), &response))
+	assertUUIDv7(t, response.RequestID)
+	assert.Contains(t, logs.String(), "request_id="+response.RequestID)
+}
+
+func TestEchoServer_RequestID(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		incoming metadata.MD
+		expected string
+	}{
+		{name: "generated when missing", incoming: metadata.MD{}},
+		{
+			name:     "propagates incoming ID",
+			incoming: metadata.Pairs("x-request-id", "client-123"),
+			expected: "client-123",
+		},
+		{
+			name:     "custom header",
+			header:   "X-Correlation-Id",
+			incoming: metadata.Pairs("x-correlation-id", "corr-456"),
+			expected: "corr-456",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream := &fakeServerTransportStream{}
+			ctx := metadata.NewIncomingContext(context.Background(), tt.incoming)
+			ctx = grpc.NewContextWithServerTransportStream(ctx, stream)
+
+			server := NewEchoServer(&config.Config{RequestIDHeader: tt.header})
+			resp, err := server.Echo(ctx, &proto.EchoRequest{})
+			require.NoError(t, err)
+
+			header := tt.header
+			if header == "" {
+				header = "X-Request-ID"
+			}
+			assert.Equal(t, []string{resp.RequestId}, stream.header.Get(header))
+			if tt.expected != "" {
+				assert.Equal(t, tt.expected, resp.RequestId)
+			} else {
+				assertUUIDv7(t, resp.RequestId)
+			}
+		})
+	}
+}
@@ -2,13 +2,19 @@ package handlers
 
 import (
 	"context"
-	"encoding/json"
 	"net"
 	"time"
 
+	"github.com/PhilipSchmid/echo-app/internal/accesslog"
 	"github.com/PhilipSchmid/echo-app/internal/config"
+	"github.com/PhilipSchmid/echo-app/internal/format"
 	"github.com/PhilipSchmid/echo-app/internal/metrics"
+	"github.com/PhilipSchmid/echo-app/internal/requestid"
+	"github.com/PhilipSchmid/echo-app/internal/tracing"
+	"github.com/PhilipSchmid/echo-app/internal/utils"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.43.0"
 )
 
 // TCPResponse represents the expected structure of the TCP response
@@ -18,64 +24,112 @@ type TCPResponse struct {
 
 func TCPHandler(ctx context.Context, conn net.Conn, cfg *config.Config) {
 	start := time.Now()
+	cfg = cfg.Current()
 	remoteAddr := conn.RemoteAddr().String()
 	sourceIP := extractIP(remoteAddr)
 
+	// TCP clients send no request, so every connection gets a fresh ID
+	id := requestid.New()
+	log := requestLogger(id)
+
 	// Panic recovery to prevent handler crashes
 	defer func() {
 		if rec := recover(); rec != nil {
-			logrus.Errorf("[TCP] Recovered from panic: %v", rec)
+			log.Errorf("[TCP] Recovered from panic: %v", rec)
 			metrics.RecordError("TCP", "panic")
 		}
 	}()
 
 	// Check if context is already cancelled
 	if ctx.Err() != nil {
-		logrus.Debugf("[TCP] Context cancelled before processing connection from %s", remoteAddr)
+		log.Debugf("[TCP] Context cancelled before processing connection from %s", remoteAddr)
 		return
 	}
 
+	// TCP carries no trace context, so each connection starts a new trace
+	ctx, span := tracing.StartServerSpan(ctx, nil, "TCP connection",
+		semconv.NetworkTransportTCP,
+		semconv.ClientAddress(sourceIP),
+	)
+	defer span.End()
+
 	// Enhanced request logging at INFO level for troubleshooting
-	logrus.Infof("[TCP] Connection from %s", sourceIP)
+	log.Infof("[TCP] Connection from %s", sourceIP)
 
 	// Debug logging (keep existing for detailed debugging)
-	logrus.Debugf("[TCP] New connection from %s", remoteAddr)
+	log.Debugf("[TCP] New connection from %s", remoteAddr)
 
 	defer func() {
 		if err := conn.Close(); err != nil {
-			logrus.Errorf("Failed to close TCP connection: %v", err)
+			log.Errorf("Failed to close TCP connection: %v", err)
 		}
 		duration := time.Since(start).Seconds()
-		logrus.Debugf("[TCP] Connection closed from %s after %.3fms", remoteAddr, duration*1000)
+		log.Debugf("[TCP] Connection closed from %s after %.3fms", remoteAddr, duration*1000)
 	}()
 
 	// Track connection
 	metrics.ConnectionOpened("TCP")
 	defer metrics.ConnectionClosed("TCP")
 
+	var bytesOut int64
+	var accessErr string
 	defer func() {
 		duration := time.Since(start).Seconds()
-		metrics.RecordRequest("TCP", "connection", "", duration)
+		metrics.RecordRequestContext(ctx, "TCP", "connection", "", duration)
+		accesslog.Default().Log(accesslog.Record{
+			Time:       start,
+			RequestID:  id,
+			Listener:   "TCP",
+			Protocol:   "TCP",
+			RemoteAddr: remoteAddr,
+			BytesOut:   bytesOut,
+			Duration:   time.Since(start),
+			Error:      accessErr,
+		})
 	}()
 
+	responseFormat := tcpFormat(cfg)
 	response := buildTCPResponse(conn, cfg)
-	data, err := json.Marshal(response)
+	response.RequestID = id
+	response.Trace = tracing.InfoFromContext(ctx)
+	data, err := format.Encode(responseFormat, response)
 	if err != nil {
-		logrus.Errorf("Failed to marshal JSON: %v", err)
+		log.Errorf("Failed to marshal %s: %v", responseFormat, err)
 		metrics.RecordError("TCP", "marshal_error")
+		accessErr = err.Error()
+		span.SetStatus(codes.Error, accessErr)
 		return
 	}
-	if _, err := conn.Write(data); err != nil {
-		logrus.Errorf("Failed to write to connection: %v", err)
+	n, err := conn.Write(data)
+	bytesOut = int64(n)
+	if err != nil {
+		log.Errorf("Failed to write to connection: %v", err)
 		metrics.RecordError("TCP", "write_error")
+		accessErr = err.Error()
+		span.SetStatus(codes.Error, accessErr)
 	} else {
-		logrus.Debugf("[TCP] Response sent to %s: %d bytes", remoteAddr, len(data))
+		log.Debugf("[TCP] Response sent to %s: %d bytes", remoteAddr, len(data))
 	}
 }
 
 // buildTCPResponse constructs the response for TCP
 func buildTCPResponse(conn net.Conn, cfg *config.Config) TCPResponse {
-	return TCPResponse{
+	response := TCPResponse{
 		BaseResponse: NewBaseResponse(cfg, "TCP", conn.RemoteAddr().String()),
 	}
+	response.LocalAddr = utils.FormatAddr(conn.LocalAddr())
+	return response
+}
+
+// tcpFormat returns the configured TCP response format, defaulting to JSON
+func tcpFormat(cfg *config.Config) format.Format {
+	if cfg.TCPFormat == "" {
+		return format.JSON
+	}
+	f, err := format.Parse(cfg.TCPFormat)
+	if err != nil {
+		logrus.Warnf("[TCP] %v, falling back to JSON", err)
+		return format.JSON
+	}
+	return f
 }
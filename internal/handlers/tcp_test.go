@@ -93,3 +93,23 @@ func TestTCPHandler(t *testing.T) {
 	// Ensure all mock expectations were met
 	mockConn.AssertExpectations(t)
 }
+
+func TestTCPHandler_ConfiguredFormat(t *testing.T) {
+	cfg := &config.Config{
+		Message:   "Test TCP",
+		TCPFormat: "text",
+	}
+
+	mockConn := new(MockTCPConn)
+	mockConn.On("Write", mock.Anything).Return(len("some data"), nil).Once()
+	mockConn.On("Close").Return(nil).Once()
+
+	TCPHandler(context.Background(), mockConn, cfg)
+
+	writtenData := string(mockConn.Calls[0].Arguments.Get(0).([]byte))
+	assert.Contains(t, writtenData, "message: Test TCP\n")
+	assert.Contains(t, writtenData, "listener: TCP\n")
+	assert.Contains(t, writtenData, "source_ip: 127.0.0.1\n")
+
+	mockConn.AssertExpectations(t)
+}
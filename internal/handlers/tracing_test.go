@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/PhilipSchmid/echo-app/internal/config"
+	"github.com/PhilipSchmid/echo-app/proto"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	testTraceID     = "4bf92f3577b34da6a3ce929d0e0e4736"
+	testParentID    = "00f067aa0ba902b7"
+	testTraceparent = "00-" + testTraceID + "-" + testParentID + "-01"
+)
+
+// useSpanRecorder installs an SDK tracer provider recording spans in memory
+func useSpanRecorder(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() {
+		otel.SetTracerProvider(noop.NewTracerProvider())
+		_ = provider.Shutdown(context.Background())
+	})
+	return recorder
+}
+
+func spanAttributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, kv := range span.Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	return attrs
+}
+
+// durationExemplar returns the trace ID exemplar recorded for listener
+func durationExemplar(t *testing.T, listener string) string {
+	t.Helper()
+	families, err := prometheus.DefaultGatherer.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		if family.GetName() != "echo_app_request_duration_seconds" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() != "listener" || label.GetValue() != listener {
+					continue
+				}
+				for _, bucket := range metric.GetHistogram().GetBucket() {
+					if exemplar := bucket.GetExemplar(); exemplar != nil {
+						return exemplar.GetLabel()[0].GetValue()
+					}
+				}
+			}
+		}
+	}
+	return ""
+}
+
+func TestHTTPHandler_TraceContext(t *testing.T) {
+	// Without span export the parsed traceparent and baggage are echoed
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("traceparent", testTraceparent)
+	req.Header.Set("baggage", "tenant=acme")
+	w := httptest.NewRecorder()
+	HTTPHandler(&config.Config{MaxRequestSize: 1024}, "HTTP")(w, req)
+
+	var response HTTPResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.NotNil(t, response.Trace)
+	assert.Equal(t, testTraceID, response.Trace.TraceID)
+	assert.Equal(t, testParentID, response.Trace.SpanID)
+	assert.True(t, response.Trace.Sampled)
+	assert.Equal(t, map[string]string{"tenant": "acme"}, response.Trace.Baggage)
+
+	// Untraced requests omit the trace field
+	w = httptest.NewRecorder()
+	HTTPHandler(&config.Config{MaxRequestSize: 1024}, "HTTP")(w, httptest.NewRequest("GET", "/", nil))
+	assert.NotContains(t, w.Body.String(), `"trace"`)
+}
+
+func TestHTTPHandler_ServerSpan(t *testing.T) {
+	recorder := useSpanRecorder(t)
+
+	req := httptest.NewRequest("POST", "/", nil)
+	req.Header.Set("traceparent", testTraceparent)
+	w := httptest.NewRecorder()
+	HTTPHandler(&config.Config{MaxRequestSize: 1024}, "HTTPTraceTest")(w, req)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	span := spans[0]
+	assert.Equal(t, "POST /", span.Name())
+	assert.Equal(t, trace.SpanKindServer, span.SpanKind())
+	assert.Equal(t, testTraceID, span.SpanContext().TraceID().String())
+	assert.Equal(t, testParentID, span.Parent().SpanID().String())
+	assert.True(t, span.Parent().IsRemote())
+	attrs := spanAttributes(span)
+	assert.Equal(t, "POST", attrs["http.request.method"].AsString())
+	assert.Equal(t, int64(http.StatusOK), attrs["http.response.status_code"].AsInt64())
+
+	var response HTTPResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.NotNil(t, response.Trace)
+	assert.Equal(t, span.SpanContext().SpanID().String(), response.Trace.SpanID)
+	assert.Equal(t, testParentID, response.Trace.ParentSpanID)
+
+	assert.Equal(t, testTraceID, durationExemplar(t, "HTTPTraceTest"))
+}
+
+func TestEchoServer_TraceContext(t *testing.T) {
+	recorder := useSpanRecorder(t)
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+		"traceparent", testTraceparent,
+		"baggage", "tenant=acme",
+	))
+	resp, err := NewEchoServer(&config.Config{}).Echo(ctx, &proto.EchoRequest{})
+	require.NoError(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, testParentID, spans[0].Parent().SpanID().String())
+	assert.Equal(t, "OK", spanAttributes(spans[0])["rpc.response.status_code"].AsString())
+
+	require.NotNil(t, resp.Trace)
+	assert.Equal(t, testTraceID, resp.Trace.TraceId)
+	assert.Equal(t, spans[0].SpanContext().SpanID().String(), resp.Trace.SpanId)
+	assert.Equal(t, testParentID, resp.Trace.ParentSpanId)
+	assert.True(t, resp.Trace.Sampled)
+	assert.Equal(t, map[string]string{"tenant": "acme"}, resp.Trace.Baggage)
+}
+
+func TestTCPHandler_ServerSpan(t *testing.T) {
+	recorder := useSpanRecorder(t)
+
+	mockConn := new(MockTCPConn)
+	mockConn.On("Write", mock.Anything).Return(len("some data"), nil).Once()
+	mockConn.On("Close").Return(nil).Once()
+
+	TCPHandler(context.Background(), mockConn, &config.Config{})
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "TCP connection", spans[0].Name())
+	assert.False(t, spans[0].Parent().IsValid())
+
+	var response TCPResponse
+	require.NoError(t, json.Unmarshal(mockConn.Calls[0].Arguments.Get(0).([]byte), &response))
+	require.NotNil(t, response.Trace)
+	assert.Equal(t, spans[0].SpanContext().TraceID().String(), response.Trace.TraceID)
+	assert.Empty(t, response.Trace.ParentSpanID)
+}
@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/PhilipSchmid/echo-app/internal/accesslog"
+	"github.com/PhilipSchmid/echo-app/internal/config"
+	"github.com/PhilipSchmid/echo-app/internal/format"
+	"github.com/PhilipSchmid/echo-app/internal/metrics"
+	"github.com/PhilipSchmid/echo-app/internal/requestid"
+	"github.com/quic-go/webtransport-go"
+	"github.com/sirupsen/logrus"
+)
+
+// WebTransportResponse is sent as the first message of every WebTransport session
+type WebTransportResponse struct {
+	BaseResponse
+	HTTPEndpoint string `json:"http_endpoint,omitempty"`
+	Protocol     string `json:"protocol,omitempty"`
+}
+
+// WebTransportUpgrader upgrades an HTTP/3 CONNECT request to a WebTransport session
+type WebTransportUpgrader interface {
+	Upgrade(w http.ResponseWriter, r *http.Request) (*webtransport.Session, error)
+}
+
+// WebTransportHandler returns an HTTP handler that accepts WebTransport sessions
+// and echoes their streams and datagrams
+func WebTransportHandler(cfg *config.Config, upgrader WebTransportUpgrader) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		cfg := cfg.Current()
+
+		// Return the request ID on the CONNECT response and in the
+		// session metadata message
+		header := requestIDHeader(cfg)
+		id := requestid.FromHeader(r.Header.Get(header))
+		w.Header().Set(header, id)
+		log := requestLogger(id)
+
+		// Panic recovery to prevent handler crashes
+		defer func() {
+			if rec := recover(); rec != nil {
+				log.Errorf("[WebTransport] Recovered from panic: %v", rec)
+				metrics.RecordError("WebTransport", "panic")
+			}
+		}()
+
+		sourceIP := extractIP(r.RemoteAddr)
+		log.Infof("[WebTransport] Session request: %s %s from %s", r.Method, r.URL.Path, sourceIP)
+
+		accessRecord := accesslog.Record{
+			Time:       start,
+			RequestID:  id,
+			Listener:   "WebTransport",
+			Protocol:   r.Proto,
+			RemoteAddr: r.RemoteAddr,
+			Method:     r.Method,
+			Path:       r.URL.Path,
+			Host:       r.Host,
+			UserAgent:  r.UserAgent(),
+			Status:     http.StatusOK,
+		}
+		defer func() {
+			accessRecord.Duration = time.Since(start)
+			accesslog.Default().Log(accessRecord)
+		}()
+
+		sess, err := upgrader.Upgrade(w, r)
+		if err != nil {
+			log.Warnf("[WebTransport] Upgrade from %s failed: %v", sourceIP, err)
+			metrics.RecordError("WebTransport", "upgrade_error")
+			http.Error(w, "WebTransport upgrade failed", http.StatusBadRequest)
+			accessRecord.Status = http.StatusBadRequest
+			accessRecord.Error = err.Error()
+			return
+		}
+
+		metrics.WebTransportSessionOpened()
+		defer metrics.WebTransportSessionClosed()
+
+		response := WebTransportResponse{
+			BaseResponse: NewBaseResponse(cfg, "WebTransport", r.RemoteAddr),
+			HTTPEndpoint: r.URL.Path,
+			Protocol:     sess.SessionState().ApplicationProtocol,
+		}
+		response.RequestID = id
+		response.LocalAddr = requestLocalAddress(r)
+		serveWebTransportSession(sess, response, cfg.MaxRequestSize)
+
+		duration := time.Since(start).Seconds()
+		metrics.RecordRequest("WebTransport", r.Method, normalizeEndpoint(r.URL.Path), duration)
+		log.Debugf("[WebTransport] Session from %s closed after %.3fms", r.RemoteAddr, duration*1000)
+	}
+}
+
+// serveWebTransportSession sends the metadata message and echoes all streams
+// and datagrams until the session is closed
+func serveWebTransportSession(sess *webtransport.Session, response WebTransportResponse, maxSize int64) {
+	ctx := sess.Context()
+	log := requestLogger(response.RequestID)
+
+	if err := sendWebTransportMetadata(ctx, sess, response); err != nil {
+		log.Errorf("[WebTransport] Failed to send session metadata: %v", err)
+		metrics.RecordError("WebTransport", "write_error")
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		echoWebTransportStreams(ctx, sess, log, maxSize)
+	}()
+	go func() {
+		defer wg.Done()
+		echoWebTransportUniStreams(ctx, sess, log, maxSize)
+	}()
+	go func() {
+		defer wg.Done()
+		echoWebTransportDatagrams(ctx, sess, log)
+	}()
+	wg.Wait()
+}
+
+// sendWebTransportMetadata writes the JSON echo response on a new unidirectional stream
+func sendWebTransportMetadata(ctx context.Context, sess *webtransport.Session, response WebTransportResponse) error {
+	data, err := format.Encode(format.JSON, response)
+	if err != nil {
+		return err
+	}
+
+	str, err := sess.OpenUniStreamSync(ctx)
+	if err != nil {
+		return err
+	}
+	if _, err := str.Write(data); err != nil {
+		return err
+	}
+	return str.Close()
+}
+
+// echoWebTransportStreams echoes every bidirectional stream back to the client,
+// up to maxSize bytes each
+func echoWebTransportStreams(ctx context.Context, sess *webtransport.Session, log *logrus.Entry, maxSize int64) {
+	for {
+		str, err := sess.AcceptStream(ctx)
+		if err != nil {
+			return
+		}
+		go func() {
+			if _, err := io.Copy(str, io.LimitReader(str, maxSize)); err != nil {
+				log.Debugf("[WebTransport] Stream echo ended: %v", err)
+			}
+			if err := str.Close(); err != nil {
+				log.Debugf("[WebTransport] Failed to close stream: %v", err)
+			}
+		}()
+	}
+}
+
+// echoWebTransportUniStreams reads each unidirectional stream and echoes its
+// contents on a new server-initiated unidirectional stream
+func echoWebTransportUniStreams(ctx context.Context, sess *webtransport.Session, log *logrus.Entry, maxSize int64) {
+	for {
+		str, err := sess.AcceptUniStream(ctx)
+		if err != nil {
+			return
+		}
+		go func() {
+			data, err := io.ReadAll(io.LimitReader(str, maxSize))
+			if err != nil {
+				log.Debugf("[WebTransport] Failed to read unidirectional stream: %v", err)
+				return
+			}
+			reply, err := sess.OpenUniStreamSync(ctx)
+			if err != nil {
+				log.Debugf("[WebTransport] Failed to open unidirectional stream: %v", err)
+				return
+			}
+			if _, err := reply.Write(data); err != nil {
+				log.Debugf("[WebTransport] Failed to write unidirectional stream: %v", err)
+				metrics.RecordError("WebTransport", "write_error")
+			}
+			if err := reply.Close(); err != nil {
+				log.Debugf("[WebTransport] Failed to close unidirectional stream: %v", err)
+			}
+		}()
+	}
+}
+
+// echoWebTransportDatagrams sends every received datagram back to the client
+func echoWebTransportDatagrams(ctx context.Context, sess *webtransport.Session, log *logrus.Entry) {
+	for {
+		data, err := sess.ReceiveDatagram(ctx)
+		if err != nil {
+			return
+		}
+		if err := sess.SendDatagram(data); err != nil {
+			log.Debugf("[WebTransport] Failed to echo datagram: %v", err)
+		}
+	}
+}
@@ -2,14 +2,23 @@ package health
 
 import (
 	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"maps"
 	"net"
 	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/PhilipSchmid/echo-app/internal/config"
+	"github.com/PhilipSchmid/echo-app/internal/metrics"
 	probing "github.com/prometheus-community/pro-bing"
 	"github.com/sirupsen/logrus"
 )
@@ -21,80 +30,265 @@ const (
 	StatusNotReady  = "not ready"
 )
 
+// Probes that can be overridden manually
+const (
+	ProbeLiveness  = "liveness"
+	ProbeReadiness = "readiness"
+)
+
 // Checker maintains cheap, cached health and readiness state for HTTP probes.
 type Checker struct {
-	mu                   sync.RWMutex
-	healthy              bool
-	ready                bool
-	lastError            string
-	lastChecked          time.Time
-	externalProbeChecked bool
-	externalProbeReady   bool
-	probe                config.ExternalReadinessProbe
-	client               *http.Client
-	icmpProbe            icmpProbeFunc
+	mu              sync.RWMutex
+	healthy         bool
+	ready           bool
+	lastError       string
+	lastChecked     time.Time
+	readiness       config.ExternalReadiness
+	probes          []*probeState        // External readiness probes, in configuration order
+	failedListeners map[string]string    // Why each failed listener failed, by name
+	overrides       map[string]*override // Manual states by probe
+	draining        bool                 // Not ready for good, whatever the overrides
+	icmpProbe       icmpProbeFunc
+	clock           clock
+	parent          context.Context // Context passed to Start, nil before
 }
 
 type icmpProbeFunc func(context.Context, string, time.Duration) error
 
-// NewChecker creates a Checker. Without an external readiness probe the app is
+// clock tells the time, schedules probe checks and expires overrides, faked
+// by tests
+type clock interface {
+	Now() time.Time
+	After(time.Duration) <-chan time.Time
+	AfterFunc(time.Duration, func()) timer
+}
+
+// timer is a function scheduled by clock.AfterFunc
+type timer interface {
+	Stop() bool
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                            { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time    { return time.After(d) }
+func (realClock) AfterFunc(d time.Duration, f func()) timer { return time.AfterFunc(d, f) }
+
+// probeState tracks the results of an external readiness probe
+type probeState struct {
+	probe       config.ExternalReadinessProbe
+	tlsConfig   *tls.Config             // TLS settings with the certificate files loaded
+	client      *http.Client            // Client of HTTP probes
+	statuses    config.StatusRanges     // Expected statuses of HTTP probes
+	bodyRegex   *regexp.Regexp          // Regular expression HTTP response bodies must match
+	jsonPath    []any                   // JSON path segments HTTP response bodies must contain
+	setupErr    error                   // Why the probe could not be configured, failing every check
+	stop        context.CancelCauseFunc // Stops the probe loop, nil until it runs
+	checked     bool                    // Whether the probe reported since it was configured
+	passed      bool                    // Whether the last check passed
+	ready       bool                    // Whether the probe passed its success threshold since failing its failure threshold
+	successes   int                     // Consecutive passing checks
+	failures    int                     // Consecutive failing checks
+	backoff     int                     // Failed checks while not ready, doubling the interval each
+	transitions int                     // Readiness changes
+	flaps       int                     // Check results differing from the previous one
+	err         string                  // Why the last check failed
+	checkedAt   time.Time               // When the probe last reported
+	since       time.Time               // When the probe's readiness last changed
+	duration    time.Duration           // How long the last check took
+}
+
+// newProbeState prepares probe for checks. Certificate files are read and
+// HTTP assertions parsed once here rather than on every check.
+func newProbeState(probe config.ExternalReadinessProbe) *probeState {
+	s := &probeState{probe: probe}
+	var tlsErr, statusErr, regexErr, pathErr error
+	s.tlsConfig, tlsErr = probe.TLSConfig()
+	s.client = newHTTPClient(probe, s.tlsConfig)
+	s.statuses, statusErr = probe.ExpectedStatusRanges()
+	if probe.HTTPBodyRegex != "" {
+		s.bodyRegex, regexErr = regexp.Compile(probe.HTTPBodyRegex)
+	}
+	if probe.HTTPBodyJSONPath != "" {
+		s.jsonPath, pathErr = config.JSONPathSegments(probe.HTTPBodyJSONPath)
+	}
+	s.setupErr = errors.Join(tlsErr, statusErr, regexErr, pathErr)
+	return s
+}
+
+// probeResult is the outcome of recording a check
+type probeResult struct {
+	changed bool          // Whether the check was the first or changed the probe's readiness
+	ready   bool          // Whether the probe is ready
+	streak  int           // Consecutive checks with the same result
+	next    time.Duration // How long to wait for the next check
+}
+
+// ProbeState describes an external readiness probe
+type ProbeState struct {
+	Name        string    `json:"name"`
+	Type        string    `json:"type"`
+	Target      string    `json:"target"`
+	Ready       bool      `json:"ready"`
+	Error       string    `json:"error,omitempty"`       // Why the last check failed
+	LastChecked time.Time `json:"last_checked,omitzero"` // Zero until the first check
+	Since       time.Time `json:"since,omitzero"`        // When the probe's readiness last changed
+	Duration    string    `json:"duration,omitempty"`    // How long the last check took
+
+	ConsecutiveSuccesses int    `json:"consecutive_successes"`
+	ConsecutiveFailures  int    `json:"consecutive_failures"`
+	Transitions          int    `json:"transitions"`       // Readiness changes
+	Flaps                int    `json:"flaps"`             // Check results differing from the previous one
+	Backoff              string `json:"backoff,omitempty"` // The interval while backing off
+}
+
+// override is a manual liveness or readiness state
+type override struct {
+	state  bool
+	until  time.Time // Zero if the override does not expire
+	expiry timer     // Clears an expiring override
+}
+
+// Override describes a manual liveness or readiness state
+type Override struct {
+	State bool      `json:"state"`
+	Until time.Time `json:"until,omitzero"` // When the override expires, zero if it does not
+}
+
+// errProbeReplaced stops a probe loop whose probe was replaced by SetReadiness
+var errProbeReplaced = errors.New("external readiness probe replaced")
+
+// ErrDraining rejects passing readiness overrides once the app is draining
+var ErrDraining = errors.New("draining: readiness cannot be forced to pass")
+
+// NewChecker creates a Checker. Without external readiness probes the app is
 // considered ready as soon as the probe endpoint is reachable.
-func NewChecker(probe config.ExternalReadinessProbe) *Checker {
-	ready := !probe.Enabled()
-	return &Checker{
+func NewChecker(readiness config.ExternalReadiness) *Checker {
+	c := &Checker{
 		healthy:   true,
-		ready:     ready,
-		probe:     probe,
-		client:    &http.Client{Timeout: probe.Timeout},
 		icmpProbe: runICMPProbe,
+		clock:     realClock{},
 	}
+	c.setReadiness(readiness)
+	return c
 }
 
 // Start begins the optional external readiness controller. It stores results in
 // memory so /ready never performs slow dependency I/O on the request path.
 func (c *Checker) Start(ctx context.Context) {
-	if !c.probe.Enabled() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.parent = ctx
+	c.startLocked()
+}
+
+// SetReadiness replaces the external readiness probes and policy, as on a
+// configuration reload, and starts the loops of new probes if the Checker was
+// started. Unchanged probes keep their results and loops, so a reload does
+// not reset their readiness; added and changed probes start over as for a new
+// Checker.
+func (c *Checker) SetReadiness(readiness config.ExternalReadiness) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if readiness.Equal(c.readiness) {
 		return
 	}
-	go c.run(ctx)
+	c.setReadiness(readiness)
+	if c.parent != nil {
+		c.startLocked()
+	}
 }
 
-func (c *Checker) run(ctx context.Context) {
-	c.checkOnce(ctx)
-	ticker := time.NewTicker(c.probe.Interval)
-	defer ticker.Stop()
+// setReadiness replaces the probe states for readiness, keeping those of
+// unchanged probes and stopping the others. c.mu must be held.
+func (c *Checker) setReadiness(readiness config.ExternalReadiness) {
+	current := make(map[string]*probeState, len(c.probes))
+	for _, s := range c.probes {
+		current[s.probe.Name] = s
+	}
+	c.readiness = readiness
+	c.probes = make([]*probeState, 0, len(readiness.Probes))
+	for _, probe := range readiness.Probes {
+		if s, ok := current[probe.Name]; ok && s.probe.Equal(probe) {
+			delete(current, probe.Name)
+			c.probes = append(c.probes, s)
+			continue
+		}
+		c.probes = append(c.probes, newProbeState(probe))
+	}
+	for name, s := range current {
+		if s.stop != nil {
+			s.stop(errProbeReplaced)
+		}
+		if !slices.ContainsFunc(readiness.Probes, func(p config.ExternalReadinessProbe) bool { return p.Name == name }) {
+			metrics.ReadinessProbeRemoved(name)
+		}
+	}
+	c.ready, c.lastError = c.combine()
+	c.lastChecked = c.clock.Now()
+}
+
+// startLocked starts a loop per probe not running yet. c.mu must be held.
+func (c *Checker) startLocked() {
+	for _, s := range c.probes {
+		if s.stop != nil {
+			continue
+		}
+		var ctx context.Context
+		ctx, s.stop = context.WithCancelCause(c.parent)
+		go c.run(ctx, s)
+	}
+}
+
+func (c *Checker) run(ctx context.Context, s *probeState) {
 	for {
+		next := c.checkProbe(ctx, s)
 		select {
 		case <-ctx.Done():
-			c.SetReady(false, "shutting down")
+			if !errors.Is(context.Cause(ctx), errProbeReplaced) {
+				c.SetReady(false, "shutting down")
+			}
 			return
-		case <-ticker.C:
-			c.checkOnce(ctx)
+		case <-c.clock.After(next):
 		}
 	}
 }
 
-func (c *Checker) checkOnce(parent context.Context) {
-	ctx, cancel := context.WithTimeout(parent, c.probe.Timeout)
+// checkOnce runs every probe once, one after the other
+func (c *Checker) checkOnce(ctx context.Context) {
+	c.mu.RLock()
+	probes := slices.Clone(c.probes)
+	c.mu.RUnlock()
+	for _, s := range probes {
+		c.checkProbe(ctx, s)
+	}
+}
+
+// checkProbe runs the probe s, records its result and returns how long to
+// wait for the next check
+func (c *Checker) checkProbe(parent context.Context, s *probeState) time.Duration {
+	ctx, cancel := context.WithTimeout(parent, s.probe.Timeout)
 	defer cancel()
-	err := c.check(ctx)
-	if err != nil {
-		if c.setExternalReady(false, err.Error()) {
-			c.logExternalReadinessChange(false, err)
-		}
-		return
+	start := c.clock.Now()
+	err := s.setupErr
+	if err == nil {
+		err = c.check(ctx, s)
 	}
-	if c.setExternalReady(true, "") {
-		c.logExternalReadinessChange(true, nil)
+	result := c.setProbeResult(parent, s, err, c.clock.Now().Sub(start))
+	if result.changed {
+		c.logExternalReadinessChange(s.probe, result, err)
 	}
+	return result.next
 }
 
-func (c *Checker) logExternalReadinessChange(ready bool, err error) {
+func (c *Checker) logExternalReadinessChange(probe config.ExternalReadinessProbe, result probeResult, err error) {
 	entry := logrus.WithFields(logrus.Fields{
-		"probe_type": c.probe.Type,
-		"target":     c.probe.Target,
+		"probe":      probe.Name,
+		"probe_type": probe.Type,
+		"target":     probe.Target,
+		"checks":     result.streak,
 	})
-	if ready {
+	if result.ready {
 		entry.Info("external readiness probe is ready")
 		return
 	}
@@ -104,46 +298,86 @@ func (c *Checker) logExternalReadinessChange(ready bool, err error) {
 	entry.Warn("external readiness probe is not ready")
 }
 
-func (c *Checker) check(ctx context.Context) error {
-	switch strings.ToLower(c.probe.Type) {
+func (c *Checker) check(ctx context.Context, s *probeState) error {
+	probe := s.probe
+	switch strings.ToLower(probe.Type) {
 	case "http", "https":
-		return c.checkHTTP(ctx)
+		return checkHTTP(ctx, s)
 	case "tcp":
-		return c.checkTCP(ctx)
+		return checkTCP(ctx, probe)
 	case "ping", "icmp":
-		return c.checkICMP(ctx)
+		return c.icmpProbe(ctx, probe.Target, probe.Timeout)
+	case "grpc":
+		return checkGRPC(ctx, probe, s.tlsConfig)
+	case "dns":
+		return checkDNS(ctx, probe)
+	case "tls":
+		return checkTLS(ctx, probe, s.tlsConfig)
 	default:
-		return fmt.Errorf("unsupported external readiness probe type %q", c.probe.Type)
+		return fmt.Errorf("unsupported external readiness probe type %q", probe.Type)
 	}
 }
 
-func (c *Checker) checkHTTP(ctx context.Context) error {
-	req, err := http.NewRequestWithContext(ctx, c.probe.HTTPMethod, c.probe.Target, nil)
+func checkHTTP(ctx context.Context, s *probeState) error {
+	probe := s.probe
+	req, err := newRequest(ctx, probe.HTTPMethod, probe.Target, probe.HTTPBody, probe.HTTPHeaders)
 	if err != nil {
 		return err
 	}
-	resp, err := c.client.Do(req)
+	resp, err := s.client.Do(req)
 	if err != nil {
 		return err
 	}
 	defer func() { _ = resp.Body.Close() }()
-	if resp.StatusCode != c.probe.HTTPExpectedStatus {
-		return fmt.Errorf("expected HTTP status %d from %s, got %d", c.probe.HTTPExpectedStatus, c.probe.Target, resp.StatusCode)
+	if !s.statuses.Contains(resp.StatusCode) {
+		return fmt.Errorf("expected HTTP status %s from %s, got %d", probe.ExpectedStatuses(), probe.Target, resp.StatusCode)
 	}
-	return nil
+	return checkHTTPBody(s, resp.Body)
 }
 
-func (c *Checker) checkTCP(ctx context.Context) error {
-	var d net.Dialer
-	conn, err := d.DialContext(ctx, "tcp", c.probe.Target)
+func checkTCP(ctx context.Context, probe config.ExternalReadinessProbe) error {
+	conn, err := Dial(ctx, "tcp", probe.Target)
 	if err != nil {
 		return err
 	}
 	return conn.Close()
 }
 
-func (c *Checker) checkICMP(ctx context.Context) error {
-	return c.icmpProbe(ctx, c.probe.Target, c.probe.Timeout)
+// DoHTTP sends a request without body or headers to target, built as HTTP
+// probes build theirs. The caller must close the response body.
+func DoHTTP(ctx context.Context, client *http.Client, method, target string) (*http.Response, error) {
+	req, err := newRequest(ctx, method, target, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	return client.Do(req)
+}
+
+// newRequest builds a request to target with an optional body and headers.
+// A Host header overrides the request's host.
+func newRequest(ctx context.Context, method, target, body string, headers map[string]string) (*http.Request, error) {
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range headers {
+		if strings.EqualFold(name, "Host") {
+			req.Host = value
+			continue
+		}
+		req.Header.Set(name, value)
+	}
+	return req, nil
+}
+
+// Dial connects to target over network, as TCP probes do
+func Dial(ctx context.Context, network, target string) (net.Conn, error) {
+	var d net.Dialer
+	return d.DialContext(ctx, network, target)
 }
 
 func runICMPProbe(ctx context.Context, target string, timeout time.Duration) error {
@@ -166,60 +400,347 @@ func runICMPProbe(ctx context.Context, target string, timeout time.Duration) err
 	return nil
 }
 
-// SetHealthy updates the liveness state.
+// SetHealthy updates the liveness state, ending a manual override.
 func (c *Checker) SetHealthy(healthy bool, reason string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	c.clearOverride(ProbeLiveness)
 	c.healthy = healthy
 	c.lastError = reason
-	c.lastChecked = time.Now()
+	c.lastChecked = c.clock.Now()
 }
 
-// SetReady updates the readiness state.
+// SetReady updates the readiness state, ending a manual override.
 func (c *Checker) SetReady(ready bool, reason string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	c.clearOverride(ProbeReadiness)
 	c.ready = ready
 	c.lastError = reason
-	c.lastChecked = time.Now()
+	c.lastChecked = c.clock.Now()
 }
 
-// setExternalReady updates readiness from the external probe and reports
-// whether the probe's observed status changed.
-func (c *Checker) setExternalReady(ready bool, reason string) bool {
+// SetDraining marks the app not ready for the rest of its life, ending a
+// readiness override, as it is taken out of service. Draining takes
+// precedence over passing readiness overrides.
+func (c *Checker) SetDraining() {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	changed := !c.externalProbeChecked || c.externalProbeReady != ready
-	c.externalProbeChecked = true
-	c.externalProbeReady = ready
-	c.ready = ready
-	c.lastError = reason
-	c.lastChecked = time.Now()
-	return changed
+	c.clearOverride(ProbeReadiness)
+	c.draining = true
+	c.lastChecked = c.clock.Now()
+}
+
+// SetOverride forces the liveness or readiness probe to state for duration,
+// or until ClearOverride if duration is 0. A failing override takes precedence
+// over everything else. A passing readiness override ignores the external
+// readiness probe and failed listeners, but not a failing liveness state or
+// draining, when it is rejected with ErrDraining.
+func (c *Checker) SetOverride(probe string, state bool, duration time.Duration) error {
+	if probe != ProbeLiveness && probe != ProbeReadiness {
+		return fmt.Errorf("invalid probe %q: must be %s or %s", probe, ProbeLiveness, ProbeReadiness)
+	}
+	if duration < 0 {
+		return fmt.Errorf("invalid override duration %s: must not be negative", duration)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.draining && probe == ProbeReadiness && state {
+		return ErrDraining
+	}
+	c.clearOverride(probe)
+	o := &override{state: state}
+	if duration > 0 {
+		o.until = c.clock.Now().Add(duration)
+		o.expiry = c.clock.AfterFunc(duration, func() {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			if c.overrides[probe] == o {
+				logrus.Infof("Manual %s override expired", probe)
+				c.clearOverride(probe)
+			}
+		})
+	}
+	if c.overrides == nil {
+		c.overrides = make(map[string]*override)
+	}
+	c.overrides[probe] = o
+	c.lastChecked = c.clock.Now()
+	metrics.HealthOverrideSet(probe, state)
+	return nil
+}
+
+// ClearOverride ends a manual override set by SetOverride
+func (c *Checker) ClearOverride(probe string) error {
+	if probe != ProbeLiveness && probe != ProbeReadiness {
+		return fmt.Errorf("invalid probe %q: must be %s or %s", probe, ProbeLiveness, ProbeReadiness)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.clearOverride(probe)
+	return nil
+}
+
+// clearOverride ends the override of probe, if any. c.mu must be held.
+func (c *Checker) clearOverride(probe string) {
+	o, ok := c.overrides[probe]
+	if !ok {
+		return
+	}
+	if o.expiry != nil {
+		o.expiry.Stop()
+	}
+	delete(c.overrides, probe)
+	c.lastChecked = c.clock.Now()
+	metrics.HealthOverrideCleared(probe)
+}
+
+// reason describes the failing override of probe
+func (o *override) reason(probe string) string {
+	if o.until.IsZero() {
+		return probe + " overridden manually"
+	}
+	return fmt.Sprintf("%s overridden manually until %s", probe, o.until.UTC().Format(time.RFC3339))
+}
+
+// SetListenerFailed marks the listener name as failed, keeping the app not
+// ready until SetListenerRunning clears it
+func (c *Checker) SetListenerFailed(name string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.failedListeners == nil {
+		c.failedListeners = make(map[string]string)
+	}
+	c.failedListeners[name] = err.Error()
+	c.lastChecked = c.clock.Now()
+}
+
+// SetListenerRunning clears a failure recorded by SetListenerFailed
+func (c *Checker) SetListenerRunning(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.failedListeners, name)
+	c.lastChecked = c.clock.Now()
+}
+
+// listenerFailure describes the failed listeners, or returns "" if none
+// failed. c.mu must be held.
+func (c *Checker) listenerFailure() string {
+	names := slices.Sorted(maps.Keys(c.failedListeners))
+	failures := make([]string, 0, len(names))
+	for _, name := range names {
+		failures = append(failures, fmt.Sprintf("listener %s failed: %s", name, c.failedListeners[name]))
+	}
+	return strings.Join(failures, "; ")
+}
+
+// setProbeResult records the result of the probe s and updates readiness by
+// the policy. Like Kubernetes probes, the probe becomes ready after its
+// success threshold of consecutive passing checks and not ready after its
+// failure threshold of consecutive failing checks. Results of probes
+// replaced or stopped while they ran are dropped.
+func (c *Checker) setProbeResult(ctx context.Context, s *probeState, err error, duration time.Duration) probeResult {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if ctx.Err() != nil || !slices.Contains(c.probes, s) {
+		return probeResult{next: s.probe.Interval}
+	}
+	passed := err == nil
+	if s.checked && passed != s.passed {
+		s.flaps++
+		metrics.ReadinessProbeFlapsTotal.WithLabelValues(s.probe.Name).Inc()
+	}
+	ready, streak := s.ready, 0
+	if passed {
+		s.successes, s.failures, s.backoff, s.err = s.successes+1, 0, 0, ""
+		ready, streak = ready || s.successes >= max(1, s.probe.SuccessThreshold), s.successes
+	} else {
+		s.successes, s.failures, s.err = 0, s.failures+1, err.Error()
+		ready, streak = ready && s.failures < max(1, s.probe.FailureThreshold), s.failures
+		if !ready {
+			s.backoff++
+		}
+	}
+	changed := !s.checked || ready != s.ready
+	if ready != s.ready {
+		s.transitions++
+		metrics.ReadinessProbeTransition(s.probe.Name, ready)
+	}
+	s.checked, s.passed, s.ready, s.checkedAt, s.duration = true, passed, ready, c.clock.Now(), duration
+	if changed {
+		s.since = s.checkedAt
+	}
+	metrics.RecordReadinessProbe(s.probe.Name, passed, ready, duration.Seconds())
+	c.ready, c.lastError = c.combine()
+	c.lastChecked = s.checkedAt
+	return probeResult{changed: changed, ready: ready, streak: streak, next: s.nextInterval()}
+}
+
+// nextInterval returns how long the probe waits for its next check: its
+// interval, doubled for every failed check while not ready up to the maximum
+// interval
+func (s *probeState) nextInterval() time.Duration {
+	interval := s.probe.Interval
+	if s.probe.MaxInterval <= interval {
+		return interval
+	}
+	for range s.backoff {
+		interval *= 2
+		if interval >= s.probe.MaxInterval {
+			return s.probe.MaxInterval
+		}
+	}
+	return interval
+}
+
+// combine applies the readiness policy to the probe results, returning the
+// readiness and why the app is not ready. c.mu must be held.
+func (c *Checker) combine() (bool, string) {
+	passing := 0
+	var failures []string
+	for _, s := range c.probes {
+		switch {
+		case s.ready:
+			passing++
+		case !s.checked:
+			failures = append(failures, fmt.Sprintf("probe %s not checked yet", s.probe.Name))
+		case s.passed:
+			failures = append(failures, fmt.Sprintf("probe %s passed %d of %d checks to become ready", s.probe.Name, s.successes, max(1, s.probe.SuccessThreshold)))
+		default:
+			failures = append(failures, fmt.Sprintf("probe %s failed: %s", s.probe.Name, s.err))
+		}
+	}
+	required := c.readiness.Required()
+	if passing >= required {
+		return true, ""
+	}
+	if len(c.probes) == 1 {
+		return false, "external readiness " + failures[0]
+	}
+	return false, fmt.Sprintf("%d of %d external readiness probes passing, %d required: %s",
+		passing, len(c.probes), required, strings.Join(failures, "; "))
 }
 
 // HealthHandler returns 200 only while the app process considers itself live.
 func (c *Checker) HealthHandler(w http.ResponseWriter, _ *http.Request) {
-	c.mu.RLock()
-	healthy := c.healthy
-	reason := c.lastError
-	c.mu.RUnlock()
-	if !healthy {
-		http.Error(w, StatusUnhealthy+": "+reason, http.StatusServiceUnavailable)
+	state := c.State()
+	if !state.Healthy {
+		http.Error(w, StatusUnhealthy+": "+state.Reason, http.StatusServiceUnavailable)
 		return
 	}
-	_, _ = w.Write([]byte(StatusHealthy))
+	_, _ = w.Write([]byte(StatusHealthy + overrideSuffix(state.Overrides[ProbeLiveness])))
 }
 
-// ReadyHandler returns cached readiness without blocking on external checks.
-func (c *Checker) ReadyHandler(w http.ResponseWriter, _ *http.Request) {
+// State is a snapshot of the liveness and readiness state
+type State struct {
+	Healthy   bool                `json:"healthy"`
+	Ready     bool                `json:"ready"`
+	Reason    string              `json:"reason,omitempty"`    // Why the app is not live or not ready
+	Overrides map[string]Override `json:"overrides,omitempty"` // Manual states by probe
+	Policy    string              `json:"policy,omitempty"`    // How the external readiness probes combine
+	Required  int                 `json:"required,omitempty"`  // Passing external readiness probes required
+	Probes    []ProbeState        `json:"probes,omitempty"`    // External readiness probes
+}
+
+// State returns the current liveness and readiness, as served by
+// HealthHandler and ReadyHandler
+func (c *Checker) State() State {
 	c.mu.RLock()
-	ready := c.ready && c.healthy
-	reason := c.lastError
-	c.mu.RUnlock()
-	if !ready {
-		http.Error(w, StatusNotReady+": "+reason, http.StatusServiceUnavailable)
+	defer c.mu.RUnlock()
+	state := State{Healthy: c.healthy, Ready: c.ready && c.healthy}
+	live, ready := c.overrides[ProbeLiveness], c.overrides[ProbeReadiness]
+	if live != nil {
+		state.Healthy = live.state
+		if !live.state {
+			state.Reason = live.reason(ProbeLiveness)
+		}
+	}
+	switch {
+	case !state.Healthy:
+		state.Ready = false
+	case c.draining:
+		state.Ready = false
+		state.Reason = "draining"
+	case ready != nil:
+		state.Ready = ready.state
+		if !ready.state {
+			state.Reason = ready.reason(ProbeReadiness)
+		}
+	default:
+		state.Ready = c.ready
+		if failure := c.listenerFailure(); failure != "" {
+			state.Ready = false
+			state.Reason = failure
+		}
+	}
+	if state.Reason == "" && (!state.Healthy || !state.Ready) {
+		state.Reason = c.lastError
+	}
+	if len(c.probes) > 0 {
+		state.Policy, state.Required = c.readiness.Policy, c.readiness.Required()
+		if state.Policy == "" {
+			state.Policy = config.ReadinessPolicyAll
+		}
+	}
+	for _, s := range c.probes {
+		probe := ProbeState{
+			Name:        s.probe.Name,
+			Type:        s.probe.Type,
+			Target:      s.probe.Target,
+			Ready:       s.ready,
+			Error:       s.err,
+			LastChecked: s.checkedAt,
+			Since:       s.since,
+		}
+		if s.checked {
+			probe.Duration = s.duration.Round(time.Microsecond).String()
+		}
+		probe.ConsecutiveSuccesses, probe.ConsecutiveFailures = s.successes, s.failures
+		probe.Transitions, probe.Flaps = s.transitions, s.flaps
+		if next := s.nextInterval(); next > s.probe.Interval {
+			probe.Backoff = next.String()
+		}
+		state.Probes = append(state.Probes, probe)
+	}
+	for probe, o := range c.overrides {
+		if state.Overrides == nil {
+			state.Overrides = make(map[string]Override)
+		}
+		state.Overrides[probe] = Override{State: o.state, Until: o.until}
+	}
+	return state
+}
+
+// ReadyHandler returns cached readiness without blocking on external checks.
+// With ?verbose=1 it responds with the State as JSON, including every
+// external readiness probe.
+func (c *Checker) ReadyHandler(w http.ResponseWriter, r *http.Request) {
+	state := c.State()
+	if verbose, _ := strconv.ParseBool(r.URL.Query().Get("verbose")); verbose {
+		w.Header().Set("Content-Type", "application/json")
+		if !state.Ready {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		if err := json.NewEncoder(w).Encode(state); err != nil {
+			logrus.Errorf("Failed to write readiness state: %v", err)
+		}
+		return
+	}
+	if !state.Ready {
+		http.Error(w, StatusNotReady+": "+state.Reason, http.StatusServiceUnavailable)
 		return
 	}
-	_, _ = w.Write([]byte(StatusReady))
+	_, _ = w.Write([]byte(StatusReady + overrideSuffix(state.Overrides[ProbeReadiness])))
+}
+
+// overrideSuffix notes a passing manual override in probe responses
+func overrideSuffix(o Override) string {
+	switch {
+	case !o.State:
+		return ""
+	case o.Until.IsZero():
+		return " (overridden manually)"
+	default:
+		return " (overridden manually until " + o.Until.UTC().Format(time.RFC3339) + ")"
+	}
 }
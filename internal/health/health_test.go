@@ -2,23 +2,29 @@ package health
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"io"
 	"net"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/PhilipSchmid/echo-app/internal/config"
+	"github.com/PhilipSchmid/echo-app/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
 func TestCheckerDefaultReady(t *testing.T) {
-	checker := NewChecker(config.ExternalReadinessProbe{})
+	checker := NewChecker(config.ExternalReadiness{})
 
 	ready := httptest.NewRecorder()
 	checker.ReadyHandler(ready, httptest.NewRequest(http.MethodGet, "/ready", nil))
@@ -43,14 +49,14 @@ func TestCheckerReadinessTracksHTTPProbe(t *testing.T) {
 	}))
 	defer upstream.Close()
 
-	checker := NewChecker(config.ExternalReadinessProbe{
+	checker := NewChecker(readinessOf(config.ExternalReadinessProbe{
 		Type:               "http",
 		Target:             upstream.URL,
 		Interval:           10 * time.Millisecond,
 		Timeout:            time.Second,
 		HTTPMethod:         http.MethodGet,
 		HTTPExpectedStatus: http.StatusNoContent,
-	})
+	}))
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -73,23 +79,23 @@ func TestCheckerTCPProbe(t *testing.T) {
 		}
 	}()
 
-	checker := NewChecker(config.ExternalReadinessProbe{
+	checker := NewChecker(readinessOf(config.ExternalReadinessProbe{
 		Type:     "tcp",
 		Target:   listener.Addr().String(),
 		Interval: time.Second,
 		Timeout:  time.Second,
-	})
+	}))
 	checker.checkOnce(context.Background())
 	assert.Equal(t, http.StatusOK, readyStatus(checker))
 }
 
 func TestCheckerICMPProbe(t *testing.T) {
-	checker := NewChecker(config.ExternalReadinessProbe{
+	checker := NewChecker(readinessOf(config.ExternalReadinessProbe{
 		Type:     "icmp",
 		Target:   "192.0.2.1",
 		Interval: time.Second,
 		Timeout:  250 * time.Millisecond,
-	})
+	}))
 	called := false
 	checker.icmpProbe = func(ctx context.Context, target string, timeout time.Duration) error {
 		called = true
@@ -106,12 +112,12 @@ func TestCheckerICMPProbe(t *testing.T) {
 }
 
 func TestCheckerICMPProbeFailure(t *testing.T) {
-	checker := NewChecker(config.ExternalReadinessProbe{
+	checker := NewChecker(readinessOf(config.ExternalReadinessProbe{
 		Type:     "icmp",
 		Target:   "192.0.2.1",
 		Interval: time.Second,
 		Timeout:  time.Second,
-	})
+	}))
 	checker.icmpProbe = func(context.Context, string, time.Duration) error {
 		return errors.New("icmp failed")
 	}
@@ -121,14 +127,298 @@ func TestCheckerICMPProbeFailure(t *testing.T) {
 	assert.Equal(t, http.StatusServiceUnavailable, readyStatus(checker))
 }
 
+func TestCheckerSetReadiness(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	target := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	checker := NewChecker(config.ExternalReadiness{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	checker.Start(ctx)
+	assert.Equal(t, http.StatusOK, readyStatus(checker))
+
+	// A probe added by a reload starts running
+	checker.SetReadiness(readinessOf(config.ExternalReadinessProbe{
+		Type:     "tcp",
+		Target:   target,
+		Interval: 10 * time.Millisecond,
+		Timeout:  time.Second,
+	}))
+	require.Eventually(t, func() bool { return readyStatus(checker) == http.StatusServiceUnavailable }, time.Second, 10*time.Millisecond)
+
+	// Removing the probe stops it without reporting a shutdown
+	checker.SetReadiness(config.ExternalReadiness{})
+	assert.Equal(t, http.StatusOK, readyStatus(checker))
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, http.StatusOK, readyStatus(checker))
+}
+
+func TestCheckerSetReadinessKeepsUnchangedProbes(t *testing.T) {
+	db := config.ExternalReadinessProbe{Name: "db", Type: "icmp", Target: "192.0.2.1", Interval: time.Second, Timeout: time.Second, SuccessThreshold: 3}
+	cache := config.ExternalReadinessProbe{Name: "cache", Type: "icmp", Target: "192.0.2.2", Interval: time.Second, Timeout: time.Second}
+	checker := NewChecker(config.ExternalReadiness{Probes: []config.ExternalReadinessProbe{db}})
+	checker.icmpProbe = func(context.Context, string, time.Duration) error { return nil }
+	for range db.SuccessThreshold {
+		checker.checkOnce(context.Background())
+	}
+	require.Equal(t, http.StatusOK, readyStatus(checker))
+
+	// Adding a probe under the any policy keeps the unchanged probe ready
+	checker.SetReadiness(config.ExternalReadiness{Policy: config.ReadinessPolicyAny, Probes: []config.ExternalReadinessProbe{db, cache}})
+	assert.Equal(t, http.StatusOK, readyStatus(checker))
+	state := checker.State()
+	require.Len(t, state.Probes, 2)
+	assert.True(t, state.Probes[0].Ready)
+	assert.Equal(t, 3, state.Probes[0].ConsecutiveSuccesses)
+	assert.True(t, state.Probes[1].LastChecked.IsZero())
+
+	// Changing the probe starts it over
+	db.Target = "192.0.2.3"
+	checker.SetReadiness(config.ExternalReadiness{Probes: []config.ExternalReadinessProbe{db, cache}})
+	assert.Equal(t, http.StatusServiceUnavailable, readyStatus(checker))
+	state = checker.State()
+	assert.False(t, state.Probes[0].Ready)
+	assert.True(t, state.Probes[0].LastChecked.IsZero())
+	assert.Contains(t, state.Reason, "probe db not checked yet")
+}
+
+func TestCheckerReadinessPolicy(t *testing.T) {
+	tests := []struct {
+		name       string
+		policy     string
+		quorum     int
+		failing    []string // Targets of the failing probes
+		wantReady  bool
+		wantReason string
+	}{
+		{name: "all passing", policy: config.ReadinessPolicyAll, wantReady: true},
+		{name: "all with one failing", policy: config.ReadinessPolicyAll, failing: []string{"b"}, wantReason: "2 of 3 external readiness probes passing, 3 required: probe b failed: b down"},
+		{name: "default policy", failing: []string{"a"}, wantReason: "2 of 3 external readiness probes passing, 3 required: probe a failed: a down"},
+		{name: "any with one passing", policy: config.ReadinessPolicyAny, failing: []string{"a", "b"}, wantReady: true},
+		{name: "any with none passing", policy: config.ReadinessPolicyAny, failing: []string{"a", "b", "c"}, wantReason: "0 of 3 external readiness probes passing, 1 required: probe a failed: a down; probe b failed: b down; probe c failed: c down"},
+		{name: "majority passing", policy: config.ReadinessPolicyQuorum, failing: []string{"c"}, wantReady: true},
+		{name: "majority failing", policy: config.ReadinessPolicyQuorum, failing: []string{"a", "c"}, wantReason: "1 of 3 external readiness probes passing, 2 required: probe a failed: a down; probe c failed: c down"},
+		{name: "quorum of one", policy: config.ReadinessPolicyQuorum, quorum: 1, failing: []string{"a", "c"}, wantReady: true},
+		{name: "quorum of three", policy: config.ReadinessPolicyQuorum, quorum: 3, failing: []string{"c"}, wantReason: "2 of 3 external readiness probes passing, 3 required: probe c failed: c down"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			readiness := config.ExternalReadiness{Policy: tt.policy, Quorum: tt.quorum}
+			for _, name := range []string{"a", "b", "c"} {
+				readiness.Probes = append(readiness.Probes, config.ExternalReadinessProbe{
+					Name: name, Type: "icmp", Target: name, Interval: time.Second, Timeout: time.Second,
+				})
+			}
+			checker := NewChecker(readiness)
+			checker.icmpProbe = func(_ context.Context, target string, _ time.Duration) error {
+				if slices.Contains(tt.failing, target) {
+					return errors.New(target + " down")
+				}
+				return nil
+			}
+			assert.Equal(t, http.StatusServiceUnavailable, readyStatus(checker), "not ready before the probes report")
+
+			checker.checkOnce(context.Background())
+
+			state := checker.State()
+			assert.Equal(t, tt.wantReady, state.Ready)
+			assert.Equal(t, tt.wantReason, state.Reason)
+			require.Len(t, state.Probes, 3)
+			for _, probe := range state.Probes {
+				assert.Equal(t, !slices.Contains(tt.failing, probe.Target), probe.Ready, probe.Name)
+				up := 0.0
+				if probe.Ready {
+					up = 1
+				}
+				assert.Equal(t, up, testutil.ToFloat64(metrics.ReadinessProbeUp.WithLabelValues(probe.Name)), probe.Name)
+			}
+		})
+	}
+}
+
+func TestCheckerReadyVerbose(t *testing.T) {
+	checker := NewChecker(config.ExternalReadiness{
+		Policy: config.ReadinessPolicyAny,
+		Probes: []config.ExternalReadinessProbe{
+			{Name: "db", Type: "icmp", Target: "192.0.2.1", Interval: time.Second, Timeout: time.Second},
+			{Name: "cache", Type: "icmp", Target: "192.0.2.2", Interval: time.Second, Timeout: time.Second},
+		},
+	})
+	checker.icmpProbe = func(_ context.Context, target string, _ time.Duration) error {
+		if target == "192.0.2.2" {
+			return errors.New("timeout")
+		}
+		return nil
+	}
+	checker.checkOnce(context.Background())
+
+	w := httptest.NewRecorder()
+	checker.ReadyHandler(w, httptest.NewRequest(http.MethodGet, "/ready?verbose=1", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	var state State
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &state))
+	assert.True(t, state.Ready)
+	assert.Equal(t, config.ReadinessPolicyAny, state.Policy)
+	assert.Equal(t, 1, state.Required)
+	require.Len(t, state.Probes, 2)
+	assert.Equal(t, "db", state.Probes[0].Name)
+	assert.True(t, state.Probes[0].Ready)
+	assert.Empty(t, state.Probes[0].Error)
+	assert.Equal(t, "cache", state.Probes[1].Name)
+	assert.False(t, state.Probes[1].Ready)
+	assert.Equal(t, "timeout", state.Probes[1].Error)
+	assert.False(t, state.Probes[1].LastChecked.IsZero())
+	assert.NotEmpty(t, state.Probes[1].Duration)
+
+	checker.SetReady(false, "shutting down")
+	w = httptest.NewRecorder()
+	checker.ReadyHandler(w, httptest.NewRequest(http.MethodGet, "/ready?verbose=true", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Contains(t, w.Body.String(), `"reason":"shutting down"`)
+
+	// Removing a probe drops its metrics
+	checker.SetReadiness(config.ExternalReadiness{})
+	assert.False(t, metrics.ReadinessProbeUp.DeleteLabelValues("db"), "metrics of removed probes are deleted")
+}
+
+func TestCheckerThresholds(t *testing.T) {
+	tests := []struct {
+		name             string
+		successThreshold int
+		failureThreshold int
+		results          []bool // Whether each check passes
+		wantReady        []bool // Readiness after each check
+		wantTransitions  int
+		wantFlaps        int
+	}{
+		{
+			name:             "single failure keeps ready",
+			failureThreshold: 3,
+			results:          []bool{true, false, true},
+			wantReady:        []bool{true, true, true},
+			wantTransitions:  1,
+			wantFlaps:        2,
+		},
+		{
+			name:             "failure threshold",
+			failureThreshold: 3,
+			results:          []bool{true, false, false, false, true},
+			wantReady:        []bool{true, true, true, false, true},
+			wantTransitions:  3,
+			wantFlaps:        2,
+		},
+		{
+			name:             "success threshold",
+			successThreshold: 2,
+			results:          []bool{true, true, false, true, true},
+			wantReady:        []bool{false, true, false, false, true},
+			wantTransitions:  3,
+			wantFlaps:        2,
+		},
+		{
+			name:             "not ready until the first pass",
+			failureThreshold: 3,
+			results:          []bool{false, false, true},
+			wantReady:        []bool{false, false, true},
+			wantTransitions:  1,
+			wantFlaps:        1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := NewChecker(config.ExternalReadiness{Probes: []config.ExternalReadinessProbe{{
+				Name:             "thresholds",
+				Type:             "icmp",
+				Target:           "192.0.2.1",
+				Interval:         time.Second,
+				Timeout:          time.Second,
+				SuccessThreshold: tt.successThreshold,
+				FailureThreshold: tt.failureThreshold,
+			}}})
+			transitions := metrics.ReadinessProbeTransitionsTotal.MustCurryWith(prometheus.Labels{"probe": "thresholds"})
+			before := testutil.ToFloat64(transitions.WithLabelValues("ready")) + testutil.ToFloat64(transitions.WithLabelValues("not_ready"))
+			for i, passes := range tt.results {
+				checker.icmpProbe = func(context.Context, string, time.Duration) error {
+					if passes {
+						return nil
+					}
+					return errors.New("icmp failed")
+				}
+				checker.checkOnce(context.Background())
+				assert.Equal(t, tt.wantReady[i], checker.State().Ready, "after check %d", i+1)
+			}
+
+			probe := checker.State().Probes[0]
+			assert.Equal(t, tt.wantTransitions, probe.Transitions)
+			assert.Equal(t, tt.wantFlaps, probe.Flaps)
+			after := testutil.ToFloat64(transitions.WithLabelValues("ready")) + testutil.ToFloat64(transitions.WithLabelValues("not_ready"))
+			assert.Equal(t, float64(tt.wantTransitions), after-before)
+		})
+	}
+}
+
+func TestCheckerBackoff(t *testing.T) {
+	clock := newFakeClock()
+	checker := NewChecker(config.ExternalReadiness{Probes: []config.ExternalReadinessProbe{{
+		Name:             "backoff",
+		Type:             "icmp",
+		Target:           "192.0.2.1",
+		Interval:         time.Second,
+		Timeout:          time.Second,
+		FailureThreshold: 2,
+		MaxInterval:      10 * time.Second,
+	}}})
+	checker.clock = clock
+	var failing atomic.Bool
+	checker.icmpProbe = func(context.Context, string, time.Duration) error {
+		if failing.Load() {
+			return errors.New("icmp failed")
+		}
+		return nil
+	}
+
+	steps := []struct {
+		fail      bool
+		wantReady bool
+		wantWait  time.Duration // Until the next check
+	}{
+		{fail: true, wantWait: 2 * time.Second},
+		{fail: true, wantWait: 4 * time.Second},
+		{fail: true, wantWait: 8 * time.Second},
+		{fail: true, wantWait: 10 * time.Second},
+		{fail: true, wantWait: 10 * time.Second},
+		{fail: false, wantReady: true, wantWait: time.Second},
+		{fail: true, wantReady: true, wantWait: time.Second}, // Below the failure threshold
+		{fail: true, wantWait: 2 * time.Second},
+	}
+	failing.Store(steps[0].fail)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	checker.Start(ctx)
+	for i, step := range steps {
+		failing.Store(step.fail)
+		if i > 0 {
+			clock.fire()
+		}
+		assert.Equal(t, step.wantWait, clock.next(t), "wait after check %d", i+1)
+		state := checker.State()
+		assert.Equal(t, step.wantReady, state.Ready, "after check %d", i+1)
+		assert.Equal(t, clock.Now(), state.Probes[0].LastChecked)
+	}
+	assert.Equal(t, "2s", checker.State().Probes[0].Backoff)
+}
+
 func TestCheckerLogsExternalProbeStatusChangesOnly(t *testing.T) {
 	hook := captureLogEntries(t)
-	checker := NewChecker(config.ExternalReadinessProbe{
+	checker := NewChecker(readinessOf(config.ExternalReadinessProbe{
 		Type:     "icmp",
 		Target:   "192.0.2.1",
 		Interval: time.Second,
 		Timeout:  time.Second,
-	})
+	}))
 	attempts := 0
 	checker.icmpProbe = func(context.Context, string, time.Duration) error {
 		attempts++
@@ -158,6 +448,263 @@ func TestCheckerLogsExternalProbeStatusChangesOnly(t *testing.T) {
 	assert.Equal(t, "192.0.2.1", entries[1].Data["target"])
 }
 
+func TestCheckerListenerFailures(t *testing.T) {
+	checker := NewChecker(config.ExternalReadiness{})
+	checker.SetListenerFailed("TCP", errors.New("address already in use"))
+	checker.SetListenerFailed("HTTP", errors.New("permission denied"))
+
+	w := httptest.NewRecorder()
+	checker.ReadyHandler(w, httptest.NewRequest(http.MethodGet, "/ready", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "not ready: listener HTTP failed: permission denied; listener TCP failed: address already in use\n", w.Body.String())
+
+	// Readiness set elsewhere does not hide a failed listener
+	checker.SetReady(true, "")
+	checker.SetListenerRunning("HTTP")
+	assert.Equal(t, http.StatusServiceUnavailable, readyStatus(checker))
+	checker.SetListenerRunning("TCP")
+	assert.Equal(t, http.StatusOK, readyStatus(checker))
+}
+
+func TestCheckerState(t *testing.T) {
+	checker := NewChecker(config.ExternalReadiness{})
+	assert.Equal(t, State{Healthy: true, Ready: true}, checker.State())
+
+	checker.SetListenerFailed("HTTP", errors.New("address already in use"))
+	assert.Equal(t, State{Healthy: true, Reason: "listener HTTP failed: address already in use"}, checker.State())
+}
+
+func TestCheckerOverrides(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	target := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	tests := []struct {
+		name          string
+		failingProbe  bool
+		failListener  bool
+		live, ready   *bool
+		wantHealthy   bool
+		wantReady     bool
+		wantReason    string
+		wantReadyBody string // Body of /ready when ready
+	}{
+		{name: "no override", wantHealthy: true, wantReady: true, wantReadyBody: "ready"},
+		{name: "not ready", ready: ptr(false), wantHealthy: true, wantReason: "readiness overridden manually"},
+		{name: "not live", live: ptr(false), wantReason: "liveness overridden manually"},
+		{name: "not live overrides ready", live: ptr(false), ready: ptr(true), wantReason: "liveness overridden manually"},
+		{name: "failing probe", failingProbe: true, wantHealthy: true, wantReason: "connect: connection refused"},
+		{name: "ready despite failing probe", failingProbe: true, ready: ptr(true), wantHealthy: true, wantReady: true, wantReadyBody: "ready (overridden manually)"},
+		{name: "ready despite failed listener", failListener: true, ready: ptr(true), wantHealthy: true, wantReady: true, wantReadyBody: "ready (overridden manually)"},
+		{name: "live", live: ptr(true), wantHealthy: true, wantReady: true, wantReadyBody: "ready"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			probe := config.ExternalReadinessProbe{}
+			if tt.failingProbe {
+				probe = config.ExternalReadinessProbe{Type: "tcp", Target: target, Interval: time.Hour, Timeout: time.Second}
+			}
+			checker := NewChecker(readinessOf(probe))
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			checker.Start(ctx)
+			require.Eventually(t, func() bool {
+				return allChecked(checker)
+			}, time.Second, 10*time.Millisecond)
+			if tt.failListener {
+				checker.SetListenerFailed("HTTP", errors.New("address already in use"))
+			}
+			if tt.live != nil {
+				require.NoError(t, checker.SetOverride(ProbeLiveness, *tt.live, 0))
+			}
+			if tt.ready != nil {
+				require.NoError(t, checker.SetOverride(ProbeReadiness, *tt.ready, 0))
+			}
+
+			state := checker.State()
+			assert.Equal(t, tt.wantHealthy, state.Healthy)
+			assert.Equal(t, tt.wantReady, state.Ready)
+			assert.Contains(t, state.Reason, tt.wantReason)
+			w := httptest.NewRecorder()
+			checker.ReadyHandler(w, httptest.NewRequest(http.MethodGet, "/ready", nil))
+			if tt.wantReady {
+				assert.Equal(t, tt.wantReadyBody, w.Body.String())
+			} else {
+				assert.Contains(t, w.Body.String(), "not ready: ")
+				assert.Contains(t, w.Body.String(), tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestCheckerDraining(t *testing.T) {
+	checker := NewChecker(config.ExternalReadiness{})
+	require.NoError(t, checker.SetOverride(ProbeReadiness, true, time.Hour))
+
+	checker.SetDraining()
+	state := checker.State()
+	assert.False(t, state.Ready)
+	assert.Equal(t, "draining", state.Reason)
+	assert.Empty(t, state.Overrides, "draining should end the readiness override")
+
+	// Nothing marks a draining app ready again
+	assert.ErrorIs(t, checker.SetOverride(ProbeReadiness, true, 0), ErrDraining)
+	checker.SetReady(true, "")
+	assert.False(t, checker.State().Ready)
+	assert.Equal(t, http.StatusServiceUnavailable, readyStatus(checker))
+
+	// Failing overrides are still accepted
+	require.NoError(t, checker.SetOverride(ProbeReadiness, false, 0))
+	require.NoError(t, checker.SetOverride(ProbeLiveness, false, 0))
+	state = checker.State()
+	assert.False(t, state.Ready)
+	assert.False(t, state.Healthy)
+}
+
+func TestCheckerOverrideExpires(t *testing.T) {
+	checker := NewChecker(config.ExternalReadiness{})
+	clock := newFakeClock()
+	checker.clock = clock
+	require.NoError(t, checker.SetOverride(ProbeReadiness, false, time.Minute))
+	state := checker.State()
+	assert.False(t, state.Ready)
+	assert.Equal(t, "readiness overridden manually until 2026-01-01T00:01:00Z", state.Reason)
+	assert.Equal(t, clock.Now().Add(time.Minute), state.Overrides[ProbeReadiness].Until)
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.HealthOverride.WithLabelValues(ProbeReadiness, "false")))
+
+	clock.advance(time.Minute - time.Second)
+	assert.False(t, checker.State().Ready)
+	clock.advance(time.Second)
+	assert.True(t, checker.State().Ready)
+	assert.Empty(t, checker.State().Overrides)
+	assert.Equal(t, 0.0, testutil.ToFloat64(metrics.HealthOverride.WithLabelValues(ProbeReadiness, "false")))
+
+	// Shutting down ends an override that would keep the app ready
+	require.NoError(t, checker.SetOverride(ProbeReadiness, true, 0))
+	checker.SetReady(false, "shutting down")
+	assert.Equal(t, State{Healthy: true, Reason: "shutting down"}, checker.State())
+
+	assert.ErrorContains(t, checker.SetOverride("startup", false, 0), `invalid probe "startup"`)
+	assert.ErrorContains(t, checker.SetOverride(ProbeLiveness, false, -time.Second), "must not be negative")
+}
+
+// fakeClock is a clock that only moves when a test fires the check
+// scheduled last or advances it
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waits   chan fakeWait
+	pending fakeWait
+	timers  []*fakeTimer // Functions scheduled by AfterFunc, not run yet
+}
+
+// fakeTimer is a function scheduled on a fakeClock
+type fakeTimer struct {
+	clock *fakeClock
+	at    time.Time
+	f     func()
+}
+
+type fakeWait struct {
+	d    time.Duration
+	fire chan time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), waits: make(chan fakeWait, 16)}
+}
+
+func (f *fakeClock) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+func (f *fakeClock) After(d time.Duration) <-chan time.Time {
+	w := fakeWait{d: d, fire: make(chan time.Time, 1)}
+	f.waits <- w
+	return w.fire
+}
+
+func (f *fakeClock) AfterFunc(d time.Duration, fn func()) timer {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	t := &fakeTimer{clock: f, at: f.now.Add(d), f: fn}
+	f.timers = append(f.timers, t)
+	return t
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	n := len(t.clock.timers)
+	t.clock.timers = slices.DeleteFunc(t.clock.timers, func(other *fakeTimer) bool { return other == t })
+	return len(t.clock.timers) < n
+}
+
+// advance moves the time by d and runs the functions scheduled until then
+func (f *fakeClock) advance(d time.Duration) {
+	f.mu.Lock()
+	f.now = f.now.Add(d)
+	var due []*fakeTimer
+	f.timers = slices.DeleteFunc(f.timers, func(t *fakeTimer) bool {
+		if t.at.After(f.now) {
+			return false
+		}
+		due = append(due, t)
+		return true
+	})
+	f.mu.Unlock()
+	for _, t := range due {
+		t.f()
+	}
+}
+
+// next waits until a check is scheduled and returns how far ahead
+func (f *fakeClock) next(t *testing.T) time.Duration {
+	t.Helper()
+	select {
+	case f.pending = <-f.waits:
+		return f.pending.d
+	case <-time.After(5 * time.Second):
+		t.Fatal("no check scheduled")
+		return 0
+	}
+}
+
+// fire moves the time to the scheduled check and runs it
+func (f *fakeClock) fire() {
+	f.mu.Lock()
+	f.now = f.now.Add(f.pending.d)
+	now := f.now
+	f.mu.Unlock()
+	f.pending.fire <- now
+}
+
+// readinessOf configures the single probe as the flags do
+func readinessOf(probe config.ExternalReadinessProbe) config.ExternalReadiness {
+	if !probe.Enabled() {
+		return config.ExternalReadiness{}
+	}
+	probe.Name = config.FlagProbeName
+	return config.ExternalReadiness{Probes: []config.ExternalReadinessProbe{probe}}
+}
+
+// allChecked reports whether every probe of checker reported
+func allChecked(checker *Checker) bool {
+	for _, probe := range checker.State().Probes {
+		if probe.LastChecked.IsZero() {
+			return false
+		}
+	}
+	return true
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
 func readyStatus(checker *Checker) int {
 	w := httptest.NewRecorder()
 	checker.ReadyHandler(w, httptest.NewRequest(http.MethodGet, "/ready", nil))
@@ -0,0 +1,260 @@
+package health
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/PhilipSchmid/echo-app/internal/config"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// maxProbeBodySize bounds the response body HTTP probes read for assertions
+const maxProbeBodySize = 1 << 20
+
+// newHTTPClient returns the client of an HTTP probe, with its redirect
+// policy and the TLS settings loaded when the probe was configured
+func newHTTPClient(probe config.ExternalReadinessProbe, tlsCfg *tls.Config) *http.Client {
+	client := &http.Client{Timeout: probe.Timeout}
+	switch probe.HTTPRedirectPolicy {
+	case config.HTTPRedirectNone:
+		client.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+	case config.HTTPRedirectSameHost:
+		client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			if req.URL.Host != via[0].URL.Host {
+				return fmt.Errorf("redirect to another host: %s", req.URL.Host)
+			}
+			if len(via) >= 10 {
+				return errors.New("stopped after 10 redirects")
+			}
+			return nil
+		}
+	}
+	if tlsCfg == nil || !hasCustomTLS(probe) {
+		return client
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsCfg
+	client.Transport = transport
+	return client
+}
+
+// hasCustomTLS reports whether an HTTP probe overrides the default TLS
+// settings of the HTTP client
+func hasCustomTLS(probe config.ExternalReadinessProbe) bool {
+	if probe.Type != "http" && probe.Type != "https" {
+		return false
+	}
+	return probe.TLSServerName != "" || probe.TLSInsecureSkipVerify || probe.TLSCAFile != "" || probe.TLSCertFile != ""
+}
+
+// checkHTTPBody applies the body assertions of an HTTP probe to the
+// response body
+func checkHTTPBody(s *probeState, r io.Reader) error {
+	probe := s.probe
+	if probe.HTTPBodyContains == "" && s.bodyRegex == nil && s.jsonPath == nil {
+		return nil
+	}
+	body, err := io.ReadAll(io.LimitReader(r, maxProbeBodySize))
+	if err != nil {
+		return fmt.Errorf("read response body from %s: %w", probe.Target, err)
+	}
+	if probe.HTTPBodyContains != "" && !bytes.Contains(body, []byte(probe.HTTPBodyContains)) {
+		return fmt.Errorf("response body from %s does not contain %q", probe.Target, probe.HTTPBodyContains)
+	}
+	if s.bodyRegex != nil && !s.bodyRegex.Match(body) {
+		return fmt.Errorf("response body from %s does not match %q", probe.Target, probe.HTTPBodyRegex)
+	}
+	if s.jsonPath != nil {
+		return checkJSONPath(probe, s.jsonPath, body)
+	}
+	return nil
+}
+
+// checkJSONPath requires the JSON path segments of an HTTP probe in the
+// JSON body, with the expected value if any
+func checkJSONPath(probe config.ExternalReadinessProbe, segments []any, body []byte) error {
+	var value any
+	if err := json.Unmarshal(body, &value); err != nil {
+		return fmt.Errorf("response body from %s is not JSON: %w", probe.Target, err)
+	}
+	for _, segment := range segments {
+		found := false
+		switch key := segment.(type) {
+		case string:
+			if object, ok := value.(map[string]any); ok {
+				value, found = object[key]
+			}
+		case int:
+			if array, ok := value.([]any); ok && key < len(array) {
+				value, found = array[key], true
+			}
+		}
+		if !found {
+			return fmt.Errorf("JSON path %s not found in response from %s", probe.HTTPBodyJSONPath, probe.Target)
+		}
+	}
+	if probe.HTTPBodyJSONValue == "" {
+		return nil
+	}
+	if actual := jsonString(value); actual != probe.HTTPBodyJSONValue {
+		return fmt.Errorf("JSON path %s in response from %s is %s, expected %s", probe.HTTPBodyJSONPath, probe.Target, actual, probe.HTTPBodyJSONValue)
+	}
+	return nil
+}
+
+// jsonString renders JSON strings as is and other JSON values as JSON
+func jsonString(value any) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	data, _ := json.Marshal(value)
+	return string(data)
+}
+
+// checkGRPC asks the standard gRPC health service of the target whether the
+// probe's service is serving
+func checkGRPC(ctx context.Context, probe config.ExternalReadinessProbe, tlsCfg *tls.Config) error {
+	creds := insecure.NewCredentials()
+	if probe.GRPCTLS {
+		creds = credentials.NewTLS(tlsCfg)
+	}
+	conn, err := grpc.NewClient(probe.Target, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return err
+	}
+	defer func() { _ = conn.Close() }()
+
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: probe.GRPCService})
+	if err != nil {
+		return fmt.Errorf("gRPC health check of %s: %w", probe.Target, err)
+	}
+	if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		return fmt.Errorf("gRPC service %q on %s is %s", probe.GRPCService, probe.Target, resp.GetStatus())
+	}
+	return nil
+}
+
+// checkDNS resolves the target and requires every expected record in the
+// answer
+func checkDNS(ctx context.Context, probe config.ExternalReadinessProbe) error {
+	recordType := probe.DNSRecordType
+	if recordType == "" {
+		recordType = "IP"
+	}
+	records, err := Lookup(ctx, Resolver(probe.DNSServer), recordType, probe.Target)
+	if err != nil {
+		return err
+	}
+	if len(records) == 0 {
+		return fmt.Errorf("no %s records for %s", recordType, probe.Target)
+	}
+	for _, expected := range probe.DNSExpectedRecords {
+		if !slices.ContainsFunc(records, func(record string) bool { return sameRecord(record, expected) }) {
+			return fmt.Errorf("expected %s record %q for %s, got %s", recordType, expected, probe.Target, strings.Join(records, ", "))
+		}
+	}
+	return nil
+}
+
+// sameRecord compares DNS records ignoring case and the trailing dot of
+// fully qualified names
+func sameRecord(a, b string) bool {
+	return strings.EqualFold(strings.TrimSuffix(a, "."), strings.TrimSuffix(b, "."))
+}
+
+// checkTLS completes a TLS handshake with the target and fails when a
+// certificate of the presented chain expires within the probe's minimum
+// validity
+func checkTLS(ctx context.Context, probe config.ExternalReadinessProbe, tlsCfg *tls.Config) error {
+	tlsCfg = tlsCfg.Clone()
+	if tlsCfg.ServerName == "" {
+		tlsCfg.ServerName, _, _ = net.SplitHostPort(probe.Target)
+	}
+	conn, err := Dial(ctx, "tcp", probe.Target)
+	if err != nil {
+		return err
+	}
+	tlsConn := tls.Client(conn, tlsCfg)
+	defer func() { _ = tlsConn.Close() }()
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(probe.TLSMinValidity)
+	for _, cert := range tlsConn.ConnectionState().PeerCertificates {
+		if cert.NotAfter.Before(deadline) {
+			return fmt.Errorf("certificate %q of %s expires at %s, within %s", cert.Subject.String(), probe.Target, cert.NotAfter.UTC().Format(time.RFC3339), probe.TLSMinValidity)
+		}
+	}
+	return nil
+}
+
+// Resolver returns a resolver querying server, as host or host:port, or the
+// system resolver if server is empty
+func Resolver(server string) *net.Resolver {
+	if server == "" {
+		return net.DefaultResolver
+	}
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, "53")
+	}
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, server)
+		},
+	}
+}
+
+// Lookup resolves name as recordType, one of config.DNSRecordTypes, and
+// renders the records like dig does, as DNS probes do
+func Lookup(ctx context.Context, resolver *net.Resolver, recordType, name string) ([]string, error) {
+	switch recordType {
+	case "IP", "A", "AAAA":
+		network := map[string]string{"IP": "ip", "A": "ip4", "AAAA": "ip6"}[recordType]
+		ips, err := resolver.LookupIP(ctx, network, name)
+		records := make([]string, 0, len(ips))
+		for _, ip := range ips {
+			records = append(records, ip.String())
+		}
+		return records, err
+	case "CNAME":
+		cname, err := resolver.LookupCNAME(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		return []string{cname}, nil
+	case "SRV":
+		_, srvs, err := resolver.LookupSRV(ctx, "", "", name)
+		records := make([]string, 0, len(srvs))
+		for _, srv := range srvs {
+			records = append(records, fmt.Sprintf("%d %d %d %s", srv.Priority, srv.Weight, srv.Port, srv.Target))
+		}
+		return records, err
+	case "TXT":
+		return resolver.LookupTXT(ctx, name)
+	case "MX":
+		mxs, err := resolver.LookupMX(ctx, name)
+		records := make([]string, 0, len(mxs))
+		for _, mx := range mxs {
+			records = append(records, fmt.Sprintf("%d %s", mx.Pref, mx.Host))
+		}
+		return records, err
+	default:
+		return nil, fmt.Errorf("unsupported record type %q", recordType)
+	}
+}
@@ -0,0 +1,357 @@
+package health
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/PhilipSchmid/echo-app/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestCheckGRPC(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := grpc.NewServer()
+	healthServer := grpchealth.NewServer()
+	healthServer.SetServingStatus("echo", healthpb.HealthCheckResponse_SERVING)
+	healthServer.SetServingStatus("billing", healthpb.HealthCheckResponse_NOT_SERVING)
+	healthpb.RegisterHealthServer(server, healthServer)
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	tests := []struct {
+		name      string
+		service   string
+		tls       bool
+		wantError string
+	}{
+		{name: "whole server", service: ""},
+		{name: "serving service", service: "echo"},
+		{name: "not serving service", service: "billing", wantError: `gRPC service "billing" on ` + listener.Addr().String() + " is NOT_SERVING"},
+		{name: "unknown service", service: "missing", wantError: "code = NotFound"},
+		{name: "TLS against plaintext server", tls: true, wantError: "gRPC health check of"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			probe := config.ExternalReadinessProbe{
+				Type:                  "grpc",
+				Target:                listener.Addr().String(),
+				GRPCService:           tt.service,
+				GRPCTLS:               tt.tls,
+				TLSInsecureSkipVerify: true,
+			}
+			tlsCfg, err := probe.TLSConfig()
+			require.NoError(t, err)
+			err = checkGRPC(ctx, probe, tlsCfg)
+			if tt.wantError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestCheckDNS(t *testing.T) {
+	tests := []struct {
+		name       string
+		recordType string
+		expected   []string
+		target     string
+		wantError  string
+	}{
+		{name: "resolves", target: "localhost"},
+		{name: "expected record", recordType: "A", target: "localhost", expected: []string{"127.0.0.1"}},
+		{name: "missing record", recordType: "A", target: "localhost", expected: []string{"192.0.2.1"}, wantError: `expected A record "192.0.2.1" for localhost, got 127.0.0.1`},
+		{name: "unknown name", target: "echo-app.invalid", wantError: "echo-app.invalid"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkDNS(context.Background(), config.ExternalReadinessProbe{
+				Type:               "dns",
+				Target:             tt.target,
+				DNSRecordType:      tt.recordType,
+				DNSExpectedRecords: tt.expected,
+			})
+			if tt.wantError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestCheckTLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	defer server.Close()
+	target := server.Listener.Addr().String()
+	// The test certificate is valid until 2084
+	expiry := server.Certificate().NotAfter
+
+	tests := []struct {
+		name        string
+		serverName  string
+		minValidity time.Duration
+		skipVerify  bool
+		wantError   string
+	}{
+		{name: "untrusted certificate", wantError: "certificate signed by unknown authority"},
+		{name: "valid long enough", serverName: "example.com", minValidity: 24 * time.Hour, skipVerify: true},
+		{name: "expires within window", minValidity: time.Until(expiry) + time.Hour, skipVerify: true, wantError: "expires at " + expiry.UTC().Format(time.RFC3339)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			probe := config.ExternalReadinessProbe{
+				Type:                  "tls",
+				Target:                target,
+				TLSServerName:         tt.serverName,
+				TLSMinValidity:        tt.minValidity,
+				TLSInsecureSkipVerify: tt.skipVerify,
+			}
+			tlsCfg, err := probe.TLSConfig()
+			require.NoError(t, err)
+			err = checkTLS(context.Background(), probe, tlsCfg)
+			if tt.wantError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestCheckerLoadsTLSFilesOnce(t *testing.T) {
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	defer server.Close()
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0o600))
+
+	checker := NewChecker(readinessOf(config.ExternalReadinessProbe{
+		Type:             "tls",
+		Target:           server.Listener.Addr().String(),
+		TLSServerName:    "example.com",
+		TLSCAFile:        caFile,
+		Interval:         time.Hour,
+		Timeout:          time.Second,
+		SuccessThreshold: 1,
+		FailureThreshold: 1,
+	}))
+	// Checks keep using the CA loaded when the probe was configured
+	require.NoError(t, os.Remove(caFile))
+	for range 2 {
+		checker.checkOnce(context.Background())
+		probe := checker.State().Probes[0]
+		assert.True(t, probe.Ready, probe.Error)
+	}
+}
+
+func TestCheckHTTP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"status":"UP","host":%q,"body":%q,"checks":[{"name":"db","ok":true}]}`, r.Host, body)
+	}))
+	defer server.Close()
+	auth := map[string]string{"Authorization": "Bearer secret"}
+
+	tests := []struct {
+		name      string
+		probe     config.ExternalReadinessProbe
+		wantError string
+	}{
+		{name: "missing header", probe: config.ExternalReadinessProbe{}, wantError: "expected HTTP status 200 from " + server.URL + ", got 401"},
+		{name: "status in range", probe: config.ExternalReadinessProbe{HTTPExpectedStatuses: "200-299,304", HTTPHeaders: auth}},
+		{name: "status list", probe: config.ExternalReadinessProbe{HTTPExpectedStatuses: "200,401"}},
+		{name: "status outside range", probe: config.ExternalReadinessProbe{HTTPExpectedStatuses: "500-599"}, wantError: "expected HTTP status 500-599"},
+		{name: "body contains", probe: config.ExternalReadinessProbe{HTTPHeaders: auth, HTTPBodyContains: `"status":"UP"`}},
+		{name: "body does not contain", probe: config.ExternalReadinessProbe{HTTPHeaders: auth, HTTPBodyContains: "DOWN"}, wantError: `does not contain "DOWN"`},
+		{name: "body matches", probe: config.ExternalReadinessProbe{HTTPHeaders: auth, HTTPBodyRegex: `"status":\s*"(UP|OK)"`}},
+		{name: "body does not match", probe: config.ExternalReadinessProbe{HTTPHeaders: auth, HTTPBodyRegex: "^DOWN"}, wantError: `does not match "^DOWN"`},
+		{name: "JSON path exists", probe: config.ExternalReadinessProbe{HTTPHeaders: auth, HTTPBodyJSONPath: "$.checks[0].name"}},
+		{name: "JSON path string value", probe: config.ExternalReadinessProbe{HTTPHeaders: auth, HTTPBodyJSONPath: "$.status", HTTPBodyJSONValue: "UP"}},
+		{name: "JSON path bool value", probe: config.ExternalReadinessProbe{HTTPHeaders: auth, HTTPBodyJSONPath: "$.checks[0].ok", HTTPBodyJSONValue: "true"}},
+		{name: "JSON path wrong value", probe: config.ExternalReadinessProbe{HTTPHeaders: auth, HTTPBodyJSONPath: "$.status", HTTPBodyJSONValue: "DOWN"}, wantError: "JSON path $.status in response from " + server.URL + " is UP, expected DOWN"},
+		{name: "JSON path missing", probe: config.ExternalReadinessProbe{HTTPHeaders: auth, HTTPBodyJSONPath: "$.checks[1]"}, wantError: "JSON path $.checks[1] not found"},
+		{name: "request body", probe: config.ExternalReadinessProbe{HTTPMethod: http.MethodPost, HTTPHeaders: auth, HTTPBody: "ping", HTTPBodyJSONPath: "$.body", HTTPBodyJSONValue: "ping"}},
+		{name: "host header", probe: config.ExternalReadinessProbe{HTTPHeaders: map[string]string{"Authorization": "Bearer secret", "Host": "upstream.example"}, HTTPBodyJSONPath: "$.host", HTTPBodyJSONValue: "upstream.example"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			probe := tt.probe
+			probe.Type = "http"
+			probe.Target = server.URL
+			probe.HTTPExpectedStatus = http.StatusOK
+			if probe.HTTPMethod == "" {
+				probe.HTTPMethod = http.MethodGet
+			}
+			err := checkHTTP(context.Background(), newProbeState(probe))
+			if tt.wantError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestNewProbeState(t *testing.T) {
+	s := newProbeState(config.ExternalReadinessProbe{
+		Type:                 "http",
+		HTTPExpectedStatuses: "200-299,304",
+		HTTPBodyRegex:        "^UP",
+		HTTPBodyJSONPath:     "$.checks[0].ok",
+	})
+	require.NoError(t, s.setupErr)
+	assert.Equal(t, config.StatusRanges{{200, 299}, {304, 304}}, s.statuses)
+	assert.Equal(t, "^UP", s.bodyRegex.String())
+	assert.Equal(t, []any{"checks", 0, "ok"}, s.jsonPath)
+
+	s = newProbeState(config.ExternalReadinessProbe{Type: "http", HTTPExpectedStatus: http.StatusOK, HTTPBodyRegex: "("})
+	assert.ErrorContains(t, s.setupErr, "missing closing )")
+}
+
+func TestCheckHTTPRedirects(t *testing.T) {
+	other := httptest.NewServer(http.NotFoundHandler())
+	defer other.Close()
+	mux := http.NewServeMux()
+	mux.Handle("/ok", http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	mux.Handle("/local", http.RedirectHandler("/ok", http.StatusFound))
+	mux.Handle("/remote", http.RedirectHandler(other.URL, http.StatusFound))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	tests := []struct {
+		name      string
+		policy    string
+		path      string
+		statuses  string
+		wantError string
+	}{
+		{name: "follow", policy: config.HTTPRedirectFollow, path: "/local"},
+		{name: "follow to another host", policy: config.HTTPRedirectFollow, path: "/remote", wantError: "got 404"},
+		{name: "none", policy: config.HTTPRedirectNone, path: "/local", wantError: "got 302"},
+		{name: "none accepting redirects", policy: config.HTTPRedirectNone, path: "/local", statuses: "300-399"},
+		{name: "same host", policy: config.HTTPRedirectSameHost, path: "/local"},
+		{name: "same host to another host", policy: config.HTTPRedirectSameHost, path: "/remote", wantError: "redirect to another host"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			probe := config.ExternalReadinessProbe{
+				Type:                 "http",
+				Target:               server.URL + tt.path,
+				HTTPMethod:           http.MethodGet,
+				HTTPExpectedStatus:   http.StatusOK,
+				HTTPExpectedStatuses: tt.statuses,
+				HTTPRedirectPolicy:   tt.policy,
+			}
+			err := checkHTTP(context.Background(), newProbeState(probe))
+			if tt.wantError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestCheckHTTPTLS(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) == 0 {
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequestClientCert}
+	server.StartTLS()
+	defer server.Close()
+
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0o600))
+	certFile, keyFile := writeClientCertificate(t, dir)
+
+	tests := []struct {
+		name      string
+		probe     config.ExternalReadinessProbe
+		wantError string
+		wantSetup string
+	}{
+		{name: "untrusted certificate", wantError: "certificate signed by unknown authority"},
+		{name: "insecure skip verify", probe: config.ExternalReadinessProbe{TLSInsecureSkipVerify: true}, wantError: "got 403"},
+		{name: "custom CA", probe: config.ExternalReadinessProbe{TLSCAFile: caFile}, wantError: "got 403"},
+		{name: "custom CA and server name", probe: config.ExternalReadinessProbe{TLSCAFile: caFile, TLSServerName: "example.com"}, wantError: "got 403"},
+		{name: "client certificate", probe: config.ExternalReadinessProbe{TLSCAFile: caFile, TLSCertFile: certFile, TLSKeyFile: keyFile}},
+		{name: "missing CA file", probe: config.ExternalReadinessProbe{TLSCAFile: filepath.Join(dir, "missing.pem")}, wantSetup: "read TLS CA file"},
+		{name: "CA file without certificates", probe: config.ExternalReadinessProbe{TLSCAFile: keyFile}, wantSetup: "no PEM certificates"},
+		{name: "mismatched key", probe: config.ExternalReadinessProbe{TLSCertFile: certFile, TLSKeyFile: caFile}, wantSetup: "load TLS client certificate"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			probe := tt.probe
+			probe.Type = "https"
+			probe.Target = server.URL
+			probe.HTTPMethod = http.MethodGet
+			probe.HTTPExpectedStatus = http.StatusOK
+			s := newProbeState(probe)
+			if tt.wantSetup != "" {
+				assert.ErrorContains(t, s.setupErr, tt.wantSetup)
+				return
+			}
+			require.NoError(t, s.setupErr)
+			err := checkHTTP(context.Background(), s)
+			if tt.wantError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.wantError)
+			}
+		})
+	}
+}
+
+// writeClientCertificate writes a self-signed client certificate and its key
+// to dir and returns their paths
+func writeClientCertificate(t *testing.T, dir string) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile, keyFile := filepath.Join(dir, "client.pem"), filepath.Join(dir, "client-key.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return certFile, keyFile
+}
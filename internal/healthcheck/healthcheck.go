@@ -0,0 +1,187 @@
+// Package healthcheck checks a local echo-app from inside its container, so
+// the scratch image can be probed without a shell or curl
+package healthcheck
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/PhilipSchmid/echo-app/internal/config"
+	"github.com/PhilipSchmid/echo-app/internal/health"
+	"github.com/PhilipSchmid/echo-app/internal/server"
+	"github.com/PhilipSchmid/echo-app/internal/utils"
+	"github.com/quic-go/quic-go"
+)
+
+// Supported checks
+const (
+	CheckHealth    = "health"    // GET /health on the metrics server
+	CheckReady     = "ready"     // GET /ready on the metrics server
+	CheckListeners = "listeners" // Connect to every enabled listener
+)
+
+// Result is the outcome of checking one endpoint
+type Result struct {
+	Name   string
+	Target string
+	Err    error
+}
+
+// Run performs check against the server configured by cfg and returns one
+// result per checked endpoint. Without a metrics server, the health and ready
+// checks fall back to connecting to the listeners. With a ports file, the
+// addresses the server reported binding are checked, so listeners on port 0
+// are found.
+func Run(ctx context.Context, cfg *config.Config, check string) ([]Result, error) {
+	if check != CheckHealth && check != CheckReady && check != CheckListeners {
+		return nil, fmt.Errorf("invalid check %q: must be one of %s, %s, %s", check, CheckHealth, CheckReady, CheckListeners)
+	}
+	bound, err := boundAddresses(cfg)
+	if err != nil {
+		return nil, err
+	}
+	metricsAddress := config.ListenAddress(cfg.MetricsAddress, cfg.MetricsPort)
+	if addr, ok := bound[metricsName]; ok {
+		metricsAddress = addr
+	}
+
+	if check != CheckListeners && cfg.Metrics {
+		return []Result{checkEndpoint(ctx, metricsAddress, check)}, nil
+	}
+	return checkListeners(ctx, cfg, bound, metricsAddress), nil
+}
+
+// metricsName is the name the metrics server is reported under
+const metricsName = "Metrics"
+
+// boundAddresses returns the addresses in the configured ports file by
+// server name, or nil without a ports file
+func boundAddresses(cfg *config.Config) (map[string]string, error) {
+	if cfg.PortsFile == "" {
+		return nil, nil
+	}
+	addrs, err := server.ReadPortsFile(cfg.PortsFile)
+	if err != nil {
+		return nil, err
+	}
+	bound := make(map[string]string, len(addrs))
+	for _, addr := range addrs {
+		bound[addr.Name] = addr.Address
+	}
+	return bound, nil
+}
+
+// localTarget returns the network and address a listen address is reached at
+// locally: the socket path for Unix sockets, and loopback for listeners bound
+// to all interfaces
+func localTarget(address string) (network, target string) {
+	network, addr, err := utils.ParseAddress(address)
+	if err != nil {
+		return "tcp", address
+	}
+	if network == "unix" {
+		return network, addr
+	}
+	host, port, _ := net.SplitHostPort(addr)
+	switch host {
+	case "", "0.0.0.0":
+		host = "127.0.0.1"
+	case "::":
+		host = "::1"
+	}
+	return network, net.JoinHostPort(host, port)
+}
+
+// checkEndpoint expects a 200 from the /health or /ready endpoint
+func checkEndpoint(ctx context.Context, address, check string) Result {
+	network, target := localTarget(address)
+	client, url := http.DefaultClient, "http://"+target+"/"+check
+	result := Result{Name: check, Target: url}
+	if network == "unix" {
+		// Requests are sent over the socket, whatever the URL's host
+		client = &http.Client{Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return health.Dial(ctx, network, target)
+			},
+		}}
+		url = "http://localhost/" + check
+		result.Target = url + " (" + utils.UnixScheme + target + ")"
+	}
+	resp, err := health.DoHTTP(ctx, client, http.MethodGet, url)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		result.Err = fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return result
+}
+
+// checkListeners connects to every enabled listener in parallel, preferring
+// the bound addresses over the configured ones
+func checkListeners(ctx context.Context, cfg *config.Config, bound map[string]string, metricsAddress string) []Result {
+	type listener struct {
+		name, address string
+		dial          func(ctx context.Context, network, target string) error
+	}
+	var listeners []listener
+	for _, l := range cfg.Listeners {
+		dial := dialTCP
+		if l.Protocol == config.ListenerQUIC {
+			dial = dialQUIC
+		}
+		address, ok := bound[l.Name]
+		if !ok {
+			address = l.ListenAddress()
+		}
+		listeners = append(listeners, listener{l.Name, address, dial})
+	}
+	if cfg.Metrics {
+		listeners = append(listeners, listener{metricsName, metricsAddress, dialTCP})
+	}
+
+	results := make([]Result, len(listeners))
+	var wg sync.WaitGroup
+	for i, l := range listeners {
+		network, target := localTarget(l.address)
+		results[i] = Result{Name: l.name, Target: target}
+		if network == "unix" {
+			results[i].Target = utils.UnixScheme + target
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i].Err = l.dial(ctx, network, target)
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// dialTCP connects over TCP or to a Unix socket
+func dialTCP(ctx context.Context, network, target string) error {
+	conn, err := health.Dial(ctx, network, target)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// dialQUIC completes a QUIC handshake, as UDP has no connection to check
+func dialQUIC(ctx context.Context, _, target string) error {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: true, // #nosec G402 -- echo-app serves a self-signed certificate
+		NextProtos:         []string{"h3"},
+	}
+	conn, err := quic.DialAddr(ctx, target, tlsConfig, nil)
+	if err != nil {
+		return err
+	}
+	return conn.CloseWithError(0, "")
+}
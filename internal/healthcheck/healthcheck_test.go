@@ -0,0 +1,198 @@
+package healthcheck
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/PhilipSchmid/echo-app/internal/config"
+	"github.com/PhilipSchmid/echo-app/internal/handlers"
+	"github.com/PhilipSchmid/echo-app/internal/server"
+	"github.com/quic-go/quic-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// port returns the port of a listener address
+func port(t *testing.T, addr net.Addr) string {
+	t.Helper()
+	_, p, err := net.SplitHostPort(addr.String())
+	require.NoError(t, err)
+	return p
+}
+
+// closedPort returns a loopback port nothing listens on
+func closedPort(t *testing.T) string {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	p := port(t, lis.Addr())
+	require.NoError(t, lis.Close())
+	return p
+}
+
+func TestRun_Endpoints(t *testing.T) {
+	ready := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/ready" && !ready {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+	cfg := &config.Config{Metrics: true, MetricsPort: port(t, srv.Listener.Addr())}
+
+	tests := []struct {
+		name          string
+		check         string
+		ready         bool
+		expectedError string
+	}{
+		{name: "healthy", check: CheckHealth},
+		{name: "ready", check: CheckReady, ready: true},
+		{name: "not ready", check: CheckReady, expectedError: "unexpected status 503 Service Unavailable"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ready = tt.ready
+			results, err := Run(context.Background(), cfg, tt.check)
+			require.NoError(t, err)
+			require.Len(t, results, 1)
+			assert.Equal(t, tt.check, results[0].Name)
+			assert.Equal(t, "http://127.0.0.1:"+cfg.MetricsPort+"/"+tt.check, results[0].Target)
+			if tt.expectedError == "" {
+				assert.NoError(t, results[0].Err)
+			} else {
+				assert.EqualError(t, results[0].Err, tt.expectedError)
+			}
+		})
+	}
+}
+
+func TestRun_Listeners(t *testing.T) {
+	tcp, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() { _ = tcp.Close() }()
+
+	tlsConfig, err := handlers.GetTLSConfig()
+	require.NoError(t, err)
+	tlsConfig = tlsConfig.Clone()
+	tlsConfig.NextProtos = []string{"h3"}
+	quicListener, err := quic.ListenAddr("127.0.0.1:0", tlsConfig, nil)
+	require.NoError(t, err)
+	defer func() { _ = quicListener.Close() }()
+	go func() {
+		for {
+			conn, err := quicListener.Accept(context.Background())
+			if err != nil {
+				return
+			}
+			_ = conn.CloseWithError(0, "")
+		}
+	}()
+
+	socket := filepath.Join(t.TempDir(), "grpc.sock")
+	unix, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+	defer func() { _ = unix.Close() }()
+
+	cfg := &config.Config{Listeners: []config.Listener{
+		{Name: "HTTP", Protocol: config.ListenerHTTP, Port: port(t, tcp.Addr())},
+		{Name: "TCP", Protocol: config.ListenerTCP, Port: closedPort(t)},
+		{Name: "QUIC", Protocol: config.ListenerQUIC, Port: port(t, quicListener.Addr())},
+		{Name: "gRPC", Protocol: config.ListenerGRPC, Address: "unix://" + socket},
+		{Name: "TLS", Protocol: config.ListenerTLS, Address: "127.0.0.1:" + port(t, tcp.Addr())},
+	}}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	results, err := Run(ctx, cfg, CheckListeners)
+	require.NoError(t, err)
+
+	require.Len(t, results, 5)
+	assert.Equal(t, "HTTP", results[0].Name)
+	assert.Equal(t, "127.0.0.1:"+port(t, tcp.Addr()), results[0].Target)
+	assert.NoError(t, results[0].Err)
+	assert.Equal(t, "TCP", results[1].Name)
+	assert.ErrorContains(t, results[1].Err, "connection refused")
+	assert.Equal(t, "QUIC", results[2].Name)
+	assert.NoError(t, results[2].Err)
+	assert.Equal(t, "unix://"+socket, results[3].Target)
+	assert.NoError(t, results[3].Err)
+	assert.NoError(t, results[4].Err)
+}
+
+func TestRun_EndpointOverUnixSocket(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "metrics.sock")
+	ln, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/health" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})}
+	go func() { _ = srv.Serve(ln) }()
+	defer func() { _ = srv.Close() }()
+
+	cfg := &config.Config{Metrics: true, MetricsAddress: "unix://" + socket}
+	results, err := Run(context.Background(), cfg, CheckHealth)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "http://localhost/health (unix://"+socket+")", results[0].Target)
+	assert.NoError(t, results[0].Err)
+}
+
+func TestRun_FallsBackToListenersWithoutMetrics(t *testing.T) {
+	cfg := &config.Config{Listeners: []config.Listener{{Name: "HTTP", Protocol: config.ListenerHTTP, Port: closedPort(t)}}}
+	results, err := Run(context.Background(), cfg, CheckReady)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "HTTP", results[0].Name)
+	assert.Error(t, results[0].Err)
+}
+
+func TestRun_PortsFile(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer srv.Close()
+	tcp, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() { _ = tcp.Close() }()
+
+	path := filepath.Join(t.TempDir(), "ports.json")
+	require.NoError(t, server.WritePortsFile(path, []server.BoundAddress{
+		{Name: "web", Network: "tcp", Address: tcp.Addr().String()},
+		{Name: "Metrics", Network: "tcp", Address: srv.Listener.Addr().String()},
+	}))
+	// Both listen on port 0, so only the ports file knows where they are
+	cfg := &config.Config{
+		Metrics:     true,
+		MetricsPort: "0",
+		PortsFile:   path,
+		Listeners:   []config.Listener{{Name: "web", Protocol: config.ListenerHTTP, Port: "0"}},
+	}
+
+	results, err := Run(context.Background(), cfg, CheckReady)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "http://"+srv.Listener.Addr().String()+"/ready", results[0].Target)
+	assert.NoError(t, results[0].Err)
+
+	results, err = Run(context.Background(), cfg, CheckListeners)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, tcp.Addr().String(), results[0].Target)
+	assert.NoError(t, results[0].Err)
+	assert.NoError(t, results[1].Err)
+
+	cfg.PortsFile = filepath.Join(t.TempDir(), "missing.json")
+	_, err = Run(context.Background(), cfg, CheckReady)
+	assert.ErrorContains(t, err, "failed to read ports file")
+}
+
+func TestRun_InvalidCheck(t *testing.T) {
+	_, err := Run(context.Background(), &config.Config{}, "live")
+	assert.EqualError(t, err, `invalid check "live": must be one of health, ready, listeners`)
+}
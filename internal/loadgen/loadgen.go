@@ -0,0 +1,226 @@
+// Package loadgen drives traffic against an echo-app at a fixed rate or
+// concurrency and reports latency histograms, errors and the instances that
+// served the requests
+package loadgen
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/HdrHistogram/hdrhistogram-go"
+)
+
+// Protocols the load generator can speak
+const (
+	ProtocolHTTP1 = "http1"
+	ProtocolHTTP2 = "http2" // h2c for http:// targets
+	ProtocolHTTP3 = "http3"
+	ProtocolGRPC  = "grpc"
+	ProtocolTCP   = "tcp"
+)
+
+// Protocols lists all supported protocols
+var Protocols = []string{ProtocolHTTP1, ProtocolHTTP2, ProtocolHTTP3, ProtocolGRPC, ProtocolTCP}
+
+// Histogram bounds in microseconds
+const (
+	minLatency        = 1
+	maxLatency        = int64(time.Minute / time.Microsecond)
+	significantDigits = 3
+)
+
+// Options configures a load test
+type Options struct {
+	Protocol           string
+	Target             string        // URL for HTTP protocols, host:port for gRPC and TCP
+	Concurrency        int           // Number of workers, each with its own connection
+	Rate               float64       // Requests per second across all workers; 0 sends as fast as the workers can
+	Duration           time.Duration // Stops the test after this long; 0 runs until Requests are sent
+	Requests           int64         // Stops the test after this many requests; 0 runs for Duration
+	Timeout            time.Duration // Timeout per request
+	InsecureSkipVerify bool          // echo-app serves a self-signed certificate
+	GRPCTLS            bool          // The gRPC listener serves TLS, as with --grpc-tls
+}
+
+// Validate checks the options before a run
+func (o Options) Validate() error {
+	if _, ok := requesters[o.Protocol]; !ok {
+		return fmt.Errorf("unsupported protocol %q: must be one of %v", o.Protocol, Protocols)
+	}
+	if o.Target == "" {
+		return fmt.Errorf("missing target")
+	}
+	if o.Concurrency <= 0 {
+		return fmt.Errorf("concurrency must be greater than zero")
+	}
+	if o.Rate < 0 {
+		return fmt.Errorf("rate must not be negative")
+	}
+	if o.Duration < 0 || o.Requests < 0 {
+		return fmt.Errorf("duration and requests must not be negative")
+	}
+	if o.Duration == 0 && o.Requests == 0 {
+		return fmt.Errorf("either duration or requests must be set")
+	}
+	if o.Timeout <= 0 {
+		return fmt.Errorf("timeout must be greater than zero")
+	}
+	return nil
+}
+
+// stats collects the results of one worker, merged once the run ends
+type stats struct {
+	histogram *hdrhistogram.Histogram
+	requests  int64
+	failures  int64
+	errors    map[string]int64
+	hostnames map[string]int64
+	nodes     map[string]int64
+}
+
+func newStats() *stats {
+	return &stats{
+		histogram: hdrhistogram.New(minLatency, maxLatency, significantDigits),
+		errors:    make(map[string]int64),
+		hostnames: make(map[string]int64),
+		nodes:     make(map[string]int64),
+	}
+}
+
+func (s *stats) record(latency time.Duration, resp response, err error) {
+	s.requests++
+	if err != nil {
+		s.failures++
+		s.errors[classify(err)]++
+		return
+	}
+	_ = s.histogram.RecordValue(min(max(latency.Microseconds(), minLatency), maxLatency))
+	s.hostnames[resp.Hostname]++
+	if resp.Node != "" {
+		s.nodes[resp.Node]++
+	}
+}
+
+func (s *stats) merge(other *stats) {
+	s.histogram.Merge(other.histogram)
+	s.requests += other.requests
+	s.failures += other.failures
+	for _, pair := range []struct{ to, from map[string]int64 }{
+		{s.errors, other.errors},
+		{s.hostnames, other.hostnames},
+		{s.nodes, other.nodes},
+	} {
+		for key, count := range pair.from {
+			pair.to[key] += count
+		}
+	}
+}
+
+// Run executes the load test and reports its results. Requests in flight when
+// the duration ends are completed, and in rate mode latencies are measured
+// from the scheduled send time so a slow server cannot hide queueing delay.
+func Run(ctx context.Context, opts Options) (*Report, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	workers := make([]requester, 0, opts.Concurrency)
+	defer func() {
+		for _, r := range workers {
+			r.close()
+		}
+	}()
+	for i := 0; i < opts.Concurrency; i++ {
+		r, err := requesters[opts.Protocol](opts)
+		if err != nil {
+			return nil, err
+		}
+		workers = append(workers, r)
+	}
+
+	runCtx := ctx
+	if opts.Duration > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, opts.Duration)
+		defer cancel()
+	}
+
+	// next hands out the scheduled start of each request, or false when the
+	// run is over
+	var next func() (time.Time, bool)
+	if opts.Rate > 0 {
+		schedule := make(chan time.Time, opts.Concurrency)
+		go pace(runCtx, schedule, opts.Rate, opts.Requests)
+		next = func() (time.Time, bool) {
+			select {
+			case scheduled, ok := <-schedule:
+				return scheduled, ok
+			case <-runCtx.Done():
+				return time.Time{}, false
+			}
+		}
+	} else {
+		var issued atomic.Int64
+		next = func() (time.Time, bool) {
+			if runCtx.Err() != nil || (opts.Requests > 0 && issued.Add(1) > opts.Requests) {
+				return time.Time{}, false
+			}
+			return time.Now(), true
+		}
+	}
+
+	results := make([]*stats, len(workers))
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i, r := range workers {
+		results[i] = newStats()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				scheduled, ok := next()
+				if !ok {
+					return
+				}
+				reqCtx, cancel := context.WithTimeout(ctx, opts.Timeout)
+				resp, err := r.do(reqCtx)
+				cancel()
+				results[i].record(time.Since(scheduled), resp, err)
+			}
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	total := newStats()
+	for _, s := range results {
+		total.merge(s)
+	}
+	return newReport(opts, elapsed, total), nil
+}
+
+// pace sends the scheduled start times of requests at rate per second until
+// ctx ends or limit requests were scheduled
+func pace(ctx context.Context, schedule chan<- time.Time, rate float64, limit int64) {
+	defer close(schedule)
+	interval := time.Duration(float64(time.Second) / rate)
+	start := time.Now()
+	for i := int64(0); limit == 0 || i < limit; i++ {
+		scheduled := start.Add(time.Duration(i) * interval)
+		timer := time.NewTimer(time.Until(scheduled))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		select {
+		case schedule <- scheduled:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
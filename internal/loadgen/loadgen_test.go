@@ -0,0 +1,289 @@
+package loadgen
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/HdrHistogram/hdrhistogram-go"
+	"github.com/PhilipSchmid/echo-app/echoapp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startEchoApp serves every protocol on random loopback ports and returns
+// the target per protocol
+func startEchoApp(t *testing.T) map[string]string {
+	t.Helper()
+	app := echoapp.NewTest(t, echoapp.Options{Node: "node-1", Listeners: []echoapp.Listener{
+		{Protocol: echoapp.ProtocolHTTP, H2C: true},
+		{Protocol: echoapp.ProtocolQUIC},
+		{Protocol: echoapp.ProtocolTCP},
+		{Protocol: echoapp.ProtocolGRPC},
+	}})
+	addrs := app.Addrs()
+	// HTTP/1.1 and h2c share one listener, as with --h2c
+	return map[string]string{
+		ProtocolHTTP1: "http://" + addrs[echoapp.ProtocolHTTP] + "/",
+		ProtocolHTTP2: "http://" + addrs[echoapp.ProtocolHTTP] + "/",
+		ProtocolHTTP3: "https://" + addrs[echoapp.ProtocolQUIC] + "/",
+		ProtocolTCP:   addrs[echoapp.ProtocolTCP],
+		ProtocolGRPC:  addrs[echoapp.ProtocolGRPC],
+	}
+}
+
+func TestRun_Protocols(t *testing.T) {
+	targets := startEchoApp(t)
+	hostname, err := os.Hostname()
+	require.NoError(t, err)
+
+	for _, protocol := range Protocols {
+		t.Run(protocol, func(t *testing.T) {
+			report, err := Run(context.Background(), Options{
+				Protocol:           protocol,
+				Target:             targets[protocol],
+				Concurrency:        4,
+				Requests:           20,
+				Timeout:            5 * time.Second,
+				InsecureSkipVerify: true,
+			})
+			require.NoError(t, err)
+
+			assert.True(t, report.OK(), "%v", report.Errors)
+			assert.Equal(t, int64(20), report.Requests)
+			assert.Equal(t, map[string]int64{hostname: 20}, report.Hostnames)
+			assert.Equal(t, map[string]int64{"node-1": 20}, report.Nodes)
+			require.NotNil(t, report.Latency)
+			assert.LessOrEqual(t, report.Latency.MinMs, report.Latency.P50Ms)
+			assert.LessOrEqual(t, report.Latency.P50Ms, report.Latency.MaxMs)
+			var counted int64
+			for _, bucket := range report.Histogram {
+				counted += bucket.Count
+			}
+			assert.Equal(t, int64(20), counted)
+		})
+	}
+}
+
+func TestRun_GRPCTLS(t *testing.T) {
+	app := echoapp.NewTest(t, echoapp.Options{Listeners: []echoapp.Listener{{Protocol: echoapp.ProtocolGRPC, TLS: true}}})
+	target := app.Addrs()[echoapp.ProtocolGRPC]
+
+	tests := []struct {
+		name      string
+		grpcTLS   bool
+		insecure  bool
+		wantError string
+	}{
+		{name: "TLS", grpcTLS: true, insecure: true},
+		{name: "TLS with verification", grpcTLS: true, wantError: "gRPC Unavailable"},
+		{name: "plaintext", insecure: true, wantError: "gRPC Unavailable"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := Run(context.Background(), Options{
+				Protocol:           ProtocolGRPC,
+				Target:             target,
+				Concurrency:        1,
+				Requests:           2,
+				Timeout:            time.Second,
+				InsecureSkipVerify: tt.insecure,
+				GRPCTLS:            tt.grpcTLS,
+			})
+			require.NoError(t, err)
+			if tt.wantError == "" {
+				assert.True(t, report.OK(), "%v", report.Errors)
+				return
+			}
+			assert.Equal(t, int64(2), report.Failures)
+			require.NotEmpty(t, report.Errors)
+			for message := range report.Errors {
+				assert.Contains(t, message, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestRun_Rate(t *testing.T) {
+	targets := startEchoApp(t)
+	report, err := Run(context.Background(), Options{
+		Protocol:    ProtocolHTTP1,
+		Target:      targets[ProtocolHTTP1],
+		Concurrency: 2,
+		Rate:        200,
+		Requests:    10,
+		Timeout:     5 * time.Second,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(10), report.Requests)
+	// Ten requests 5ms apart take at least 45ms
+	assert.GreaterOrEqual(t, report.DurationMs, 45.0)
+}
+
+func TestRun_Duration(t *testing.T) {
+	targets := startEchoApp(t)
+	report, err := Run(context.Background(), Options{
+		Protocol:    ProtocolGRPC,
+		Target:      targets[ProtocolGRPC],
+		Concurrency: 2,
+		Duration:    100 * time.Millisecond,
+		Timeout:     5 * time.Second,
+	})
+	require.NoError(t, err)
+
+	assert.Positive(t, report.Requests)
+	assert.Zero(t, report.Failures)
+	assert.GreaterOrEqual(t, report.DurationMs, 100.0)
+}
+
+func TestRun_ErrorBreakdown(t *testing.T) {
+	unavailable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer unavailable.Close()
+	notEcho := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("hello"))
+	}))
+	defer notEcho.Close()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	closed := lis.Addr().String()
+	require.NoError(t, lis.Close())
+
+	tests := []struct {
+		name     string
+		protocol string
+		target   string
+		expected string
+	}{
+		{name: "status", protocol: ProtocolHTTP1, target: unavailable.URL, expected: "HTTP 503"},
+		{name: "not echo-app", protocol: ProtocolHTTP1, target: notEcho.URL, expected: "invalid response: not an echo-app JSON response"},
+		{name: "refused", protocol: ProtocolTCP, target: closed, expected: "connection refused"},
+		{name: "gRPC unavailable", protocol: ProtocolGRPC, target: closed, expected: "gRPC Unavailable"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := Run(context.Background(), Options{
+				Protocol:    tt.protocol,
+				Target:      tt.target,
+				Concurrency: 2,
+				Requests:    4,
+				Timeout:     time.Second,
+			})
+			require.NoError(t, err)
+			assert.False(t, report.OK())
+			assert.Equal(t, int64(4), report.Failures)
+			assert.Equal(t, map[string]int64{tt.expected: 4}, report.Errors)
+			assert.Nil(t, report.Latency)
+		})
+	}
+}
+
+func TestRun_InvalidTarget(t *testing.T) {
+	tests := []struct {
+		name          string
+		protocol      string
+		target        string
+		expectedError string
+	}{
+		{name: "HTTP without scheme", protocol: ProtocolHTTP1, target: "localhost:8080", expectedError: `invalid target "localhost:8080": must be an http:// or https:// URL`},
+		{name: "HTTP/3 over http", protocol: ProtocolHTTP3, target: "http://localhost:4433/", expectedError: `invalid target "http://localhost:4433/": HTTP/3 requires an https:// URL`},
+		{name: "TCP without port", protocol: ProtocolTCP, target: "localhost", expectedError: `invalid target "localhost": must be host:port`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Run(context.Background(), Options{Protocol: tt.protocol, Target: tt.target, Concurrency: 1, Requests: 1, Timeout: time.Second})
+			assert.EqualError(t, err, tt.expectedError)
+		})
+	}
+}
+
+func TestOptions_Validate(t *testing.T) {
+	valid := func() Options {
+		return Options{Protocol: ProtocolHTTP1, Target: "http://localhost:8080/", Concurrency: 1, Duration: time.Second, Timeout: time.Second}
+	}
+	tests := []struct {
+		name          string
+		modify        func(*Options)
+		expectedError string
+	}{
+		{name: "valid", modify: func(*Options) {}},
+		{name: "unknown protocol", modify: func(o *Options) { o.Protocol = "smtp" }, expectedError: `unsupported protocol "smtp"`},
+		{name: "missing target", modify: func(o *Options) { o.Target = "" }, expectedError: "missing target"},
+		{name: "zero concurrency", modify: func(o *Options) { o.Concurrency = 0 }, expectedError: "concurrency must be greater than zero"},
+		{name: "negative rate", modify: func(o *Options) { o.Rate = -1 }, expectedError: "rate must not be negative"},
+		{name: "unbounded", modify: func(o *Options) { o.Duration = 0 }, expectedError: "either duration or requests must be set"},
+		{name: "zero timeout", modify: func(o *Options) { o.Timeout = 0 }, expectedError: "timeout must be greater than zero"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := valid()
+			tt.modify(&opts)
+			err := opts.Validate()
+			if tt.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.expectedError)
+			}
+		})
+	}
+}
+
+func TestBuckets(t *testing.T) {
+	h := hdrhistogram.New(minLatency, maxLatency, significantDigits)
+	for _, us := range []int64{100, 150, 190, 500, 1000} {
+		require.NoError(t, h.RecordValue(us))
+	}
+
+	result := buckets(h, 3)
+	require.Len(t, result, 3)
+	assert.Equal(t, 0.1, result[0].FromMs)
+	assert.Equal(t, []int64{3, 1, 1}, []int64{result[0].Count, result[1].Count, result[2].Count})
+	assert.GreaterOrEqual(t, result[2].ToMs, 1.0)
+}
+
+func TestReport_Writers(t *testing.T) {
+	report := &Report{
+		Protocol:          ProtocolHTTP1,
+		Target:            "http://svc/",
+		Concurrency:       2,
+		DurationMs:        1000,
+		Requests:          4,
+		Failures:          1,
+		RequestsPerSecond: 4,
+		Latency:           &Latency{MinMs: 1, MeanMs: 2, P50Ms: 2, P90Ms: 3, P99Ms: 3, P999Ms: 3, MaxMs: 3},
+		Histogram:         []Bucket{{FromMs: 1, ToMs: 2, Count: 2}, {FromMs: 2, ToMs: 3, Count: 1}},
+		Hostnames:         map[string]int64{"pod-b": 1, "pod-a": 2},
+		Nodes:             map[string]int64{"node-1": 3},
+		Errors:            map[string]int64{"timeout": 1},
+	}
+
+	var text bytes.Buffer
+	require.NoError(t, report.WriteText(&text))
+	assert.Contains(t, text.String(), "Requests:    4 (3 ok, 1 failed)")
+	assert.Contains(t, text.String(), "  p99.9   3.00ms")
+	assert.Contains(t, text.String(), "  1.00ms - 2.00ms  2  ########################################\n")
+	assert.Contains(t, text.String(), "Hostnames:\n  pod-a  2  (50.0%)\n  pod-b  1  (25.0%)")
+	assert.Contains(t, text.String(), "Errors:\n  timeout  1  (25.0%)")
+
+	var csv bytes.Buffer
+	require.NoError(t, report.WriteCSV(&csv))
+	assert.Contains(t, csv.String(), "section,name,value\nsummary,protocol,http1\nsummary,target,http://svc/\n")
+	assert.Contains(t, csv.String(), "latency,p999_ms,3\n")
+	assert.Contains(t, csv.String(), "histogram,1-2ms,2\n")
+	assert.Contains(t, csv.String(), "hostname,pod-a,2\nhostname,pod-b,1\nnode,node-1,3\nerror,timeout,1\n")
+
+	var json bytes.Buffer
+	require.NoError(t, report.WriteJSON(&json))
+	assert.Contains(t, json.String(), `"requests_per_second": 4`)
+	assert.Contains(t, json.String(), `"p999_ms": 3`)
+}
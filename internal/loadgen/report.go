@@ -0,0 +1,250 @@
+package loadgen
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/HdrHistogram/hdrhistogram-go"
+)
+
+// histogramBuckets is the number of equal-width latency buckets reported
+const histogramBuckets = 10
+
+// Report is the outcome of a load test
+type Report struct {
+	Protocol          string           `json:"protocol"`
+	Target            string           `json:"target"`
+	Concurrency       int              `json:"concurrency"`
+	Rate              float64          `json:"rate,omitempty"`
+	DurationMs        float64          `json:"duration_ms"`
+	Requests          int64            `json:"requests"`
+	Failures          int64            `json:"failures"`
+	RequestsPerSecond float64          `json:"requests_per_second"`
+	Latency           *Latency         `json:"latency,omitempty"`
+	Histogram         []Bucket         `json:"histogram,omitempty"`
+	Hostnames         map[string]int64 `json:"hostnames,omitempty"`
+	Nodes             map[string]int64 `json:"nodes,omitempty"`
+	Errors            map[string]int64 `json:"errors,omitempty"`
+}
+
+// Latency summarises the latencies of successful requests
+type Latency struct {
+	MinMs    float64 `json:"min_ms"`
+	MeanMs   float64 `json:"mean_ms"`
+	StdDevMs float64 `json:"stddev_ms"`
+	P50Ms    float64 `json:"p50_ms"`
+	P90Ms    float64 `json:"p90_ms"`
+	P99Ms    float64 `json:"p99_ms"`
+	P999Ms   float64 `json:"p999_ms"`
+	MaxMs    float64 `json:"max_ms"`
+}
+
+// Bucket counts the successful requests with a latency in [FromMs, ToMs)
+type Bucket struct {
+	FromMs float64 `json:"from_ms"`
+	ToMs   float64 `json:"to_ms"`
+	Count  int64   `json:"count"`
+}
+
+func newReport(opts Options, elapsed time.Duration, s *stats) *Report {
+	report := &Report{
+		Protocol:    opts.Protocol,
+		Target:      opts.Target,
+		Concurrency: opts.Concurrency,
+		Rate:        opts.Rate,
+		DurationMs:  milliseconds(elapsed.Microseconds()),
+		Requests:    s.requests,
+		Failures:    s.failures,
+		Hostnames:   s.hostnames,
+		Nodes:       s.nodes,
+		Errors:      s.errors,
+	}
+	if elapsed > 0 {
+		report.RequestsPerSecond = math.Round(100*float64(s.requests)/elapsed.Seconds()) / 100
+	}
+	if h := s.histogram; h.TotalCount() > 0 {
+		report.Latency = &Latency{
+			MinMs:    milliseconds(h.Min()),
+			MeanMs:   milliseconds(int64(math.Round(h.Mean()))),
+			StdDevMs: milliseconds(int64(math.Round(h.StdDev()))),
+			P50Ms:    milliseconds(h.ValueAtQuantile(50)),
+			P90Ms:    milliseconds(h.ValueAtQuantile(90)),
+			P99Ms:    milliseconds(h.ValueAtQuantile(99)),
+			P999Ms:   milliseconds(h.ValueAtQuantile(99.9)),
+			MaxMs:    milliseconds(h.Max()),
+		}
+		report.Histogram = buckets(h, histogramBuckets)
+	}
+	return report
+}
+
+// buckets folds the HDR histogram into n equal-width buckets between the
+// lowest and highest recorded latency
+func buckets(h *hdrhistogram.Histogram, n int) []Bucket {
+	lowest, highest := h.Min(), h.Max()+1
+	width := max((highest-lowest+int64(n)-1)/int64(n), 1)
+	result := make([]Bucket, 0, n)
+	for from := lowest; from < highest; from += width {
+		result = append(result, Bucket{FromMs: milliseconds(from), ToMs: milliseconds(from + width)})
+	}
+	for _, bar := range h.Distribution() {
+		if bar.Count == 0 {
+			continue
+		}
+		i := min(max(int((bar.From-lowest)/width), 0), len(result)-1)
+		result[i].Count += bar.Count
+	}
+	return result
+}
+
+// OK reports whether every request succeeded
+func (r *Report) OK() bool {
+	return r.Requests > 0 && r.Failures == 0
+}
+
+// WriteText prints a human readable summary with a latency histogram and the
+// distribution of responding instances
+func (r *Report) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(tw, "Target:\t%s (%s)\n", r.Target, r.Protocol)
+	mode := "as fast as possible"
+	if r.Rate > 0 {
+		mode = fmt.Sprintf("%g req/s", r.Rate)
+	}
+	_, _ = fmt.Fprintf(tw, "Load:\t%d workers, %s\n", r.Concurrency, mode)
+	_, _ = fmt.Fprintf(tw, "Duration:\t%s\n", time.Duration(r.DurationMs*float64(time.Millisecond)).Round(time.Millisecond))
+	_, _ = fmt.Fprintf(tw, "Requests:\t%d (%d ok, %d failed)\n", r.Requests, r.Requests-r.Failures, r.Failures)
+	_, _ = fmt.Fprintf(tw, "Throughput:\t%.2f req/s\n", r.RequestsPerSecond)
+
+	if l := r.Latency; l != nil {
+		_, _ = fmt.Fprintln(tw, "\nLatency:")
+		for _, row := range []struct {
+			name  string
+			value float64
+		}{
+			{"min", l.MinMs}, {"mean", l.MeanMs}, {"stddev", l.StdDevMs}, {"p50", l.P50Ms},
+			{"p90", l.P90Ms}, {"p99", l.P99Ms}, {"p99.9", l.P999Ms}, {"max", l.MaxMs},
+		} {
+			_, _ = fmt.Fprintf(tw, "  %s\t%s\n", row.name, formatMs(row.value))
+		}
+
+		_, _ = fmt.Fprintln(tw, "\nHistogram:")
+		var largest int64
+		for _, bucket := range r.Histogram {
+			largest = max(largest, bucket.Count)
+		}
+		for _, bucket := range r.Histogram {
+			bar := strings.Repeat("#", int(40*bucket.Count/max(largest, 1)))
+			_, _ = fmt.Fprintf(tw, "  %s - %s\t%d\t%s\n", formatMs(bucket.FromMs), formatMs(bucket.ToMs), bucket.Count, bar)
+		}
+	}
+
+	for _, section := range []struct {
+		title  string
+		counts map[string]int64
+	}{
+		{"Hostnames", r.Hostnames},
+		{"Nodes", r.Nodes},
+		{"Errors", r.Errors},
+	} {
+		if len(section.counts) == 0 {
+			continue
+		}
+		_, _ = fmt.Fprintf(tw, "\n%s:\n", section.title)
+		for _, key := range byCount(section.counts) {
+			count := section.counts[key]
+			_, _ = fmt.Fprintf(tw, "  %s\t%d\t(%.1f%%)\n", key, count, 100*float64(count)/float64(r.Requests))
+		}
+	}
+	return tw.Flush()
+}
+
+// WriteJSON prints the report as indented JSON
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WriteCSV prints the report as section,name,value rows for spreadsheets
+func (r *Report) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	rows := [][]string{
+		{"section", "name", "value"},
+		{"summary", "protocol", r.Protocol},
+		{"summary", "target", r.Target},
+		{"summary", "concurrency", strconv.Itoa(r.Concurrency)},
+		{"summary", "rate", formatFloat(r.Rate)},
+		{"summary", "duration_ms", formatFloat(r.DurationMs)},
+		{"summary", "requests", strconv.FormatInt(r.Requests, 10)},
+		{"summary", "failures", strconv.FormatInt(r.Failures, 10)},
+		{"summary", "requests_per_second", formatFloat(r.RequestsPerSecond)},
+	}
+	if l := r.Latency; l != nil {
+		rows = append(rows,
+			[]string{"latency", "min_ms", formatFloat(l.MinMs)},
+			[]string{"latency", "mean_ms", formatFloat(l.MeanMs)},
+			[]string{"latency", "stddev_ms", formatFloat(l.StdDevMs)},
+			[]string{"latency", "p50_ms", formatFloat(l.P50Ms)},
+			[]string{"latency", "p90_ms", formatFloat(l.P90Ms)},
+			[]string{"latency", "p99_ms", formatFloat(l.P99Ms)},
+			[]string{"latency", "p999_ms", formatFloat(l.P999Ms)},
+			[]string{"latency", "max_ms", formatFloat(l.MaxMs)},
+		)
+	}
+	for _, bucket := range r.Histogram {
+		rows = append(rows, []string{"histogram", formatFloat(bucket.FromMs) + "-" + formatFloat(bucket.ToMs) + "ms", strconv.FormatInt(bucket.Count, 10)})
+	}
+	for _, section := range []struct {
+		name   string
+		counts map[string]int64
+	}{
+		{"hostname", r.Hostnames},
+		{"node", r.Nodes},
+		{"error", r.Errors},
+	} {
+		for _, key := range byCount(section.counts) {
+			rows = append(rows, []string{section.name, key, strconv.FormatInt(section.counts[key], 10)})
+		}
+	}
+	if err := cw.WriteAll(rows); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+// byCount returns the keys of counts, most frequent first
+func byCount(counts map[string]int64) []string {
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+// milliseconds converts a histogram value in microseconds
+func milliseconds(us int64) float64 {
+	return float64(us) / 1000
+}
+
+func formatMs(ms float64) string {
+	return fmt.Sprintf("%.2fms", ms)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
@@ -0,0 +1,204 @@
+package loadgen
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+
+	"github.com/PhilipSchmid/echo-app/internal/handlers"
+	"github.com/PhilipSchmid/echo-app/proto"
+	"github.com/quic-go/quic-go/http3"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// response holds the fields identifying the instance that served a request
+type response struct {
+	Hostname string `json:"hostname"`
+	Node     string `json:"node"`
+}
+
+// requester sends requests for one worker over a connection it keeps open
+// between requests, except for TCP where each request is a connection
+type requester interface {
+	do(ctx context.Context) (response, error)
+	close()
+}
+
+var requesters = map[string]func(opts Options) (requester, error){
+	ProtocolHTTP1: newHTTPRequester,
+	ProtocolHTTP2: newHTTPRequester,
+	ProtocolHTTP3: newHTTPRequester,
+	ProtocolGRPC:  newGRPCRequester,
+	ProtocolTCP:   newTCPRequester,
+}
+
+// statusError reports a non-2xx HTTP response
+type statusError struct {
+	code int
+}
+
+func (e statusError) Error() string {
+	return fmt.Sprintf("HTTP %d", e.code)
+}
+
+// classify groups errors into the categories of the error breakdown
+func classify(err error) string {
+	var statusErr statusError
+	var netErr net.Error
+	switch {
+	case errors.As(err, &statusErr):
+		return statusErr.Error()
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return "timeout"
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return "connection refused"
+	case errors.Is(err, syscall.ECONNRESET):
+		return "connection reset"
+	}
+	if s, ok := status.FromError(err); ok {
+		if s.Code() == codes.DeadlineExceeded {
+			return "timeout"
+		}
+		return "gRPC " + s.Code().String()
+	}
+	return err.Error()
+}
+
+type httpRequester struct {
+	client         *http.Client
+	url            string
+	closeTransport func()
+}
+
+func newHTTPRequester(opts Options) (requester, error) {
+	target, err := url.Parse(opts.Target)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return nil, fmt.Errorf("invalid target %q: must be an http:// or https:// URL", opts.Target)
+	}
+	tlsConfig := &tls.Config{InsecureSkipVerify: opts.InsecureSkipVerify} // #nosec G402 -- echo-app serves a self-signed certificate
+
+	var transport http.RoundTripper
+	closeTransport := func() {}
+	switch opts.Protocol {
+	case ProtocolHTTP3:
+		if target.Scheme != "https" {
+			return nil, fmt.Errorf("invalid target %q: HTTP/3 requires an https:// URL", opts.Target)
+		}
+		t := &http3.Transport{TLSClientConfig: tlsConfig}
+		transport, closeTransport = t, func() { _ = t.Close() }
+	default:
+		t := http.DefaultTransport.(*http.Transport).Clone()
+		t.TLSClientConfig = tlsConfig
+		t.Protocols = new(http.Protocols)
+		if opts.Protocol == ProtocolHTTP2 {
+			t.Protocols.SetHTTP2(true)
+			t.Protocols.SetUnencryptedHTTP2(true)
+		} else {
+			t.Protocols.SetHTTP1(true)
+		}
+		transport, closeTransport = t, t.CloseIdleConnections
+	}
+	return &httpRequester{client: &http.Client{Transport: transport}, url: target.String(), closeTransport: closeTransport}, nil
+}
+
+func (r *httpRequester) do(ctx context.Context) (response, error) {
+	var resp response
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.url, nil)
+	if err != nil {
+		return resp, err
+	}
+	req.Header.Set("Accept", "application/json")
+	res, err := r.client.Do(req)
+	if err != nil {
+		return resp, err
+	}
+	defer func() { _ = res.Body.Close() }()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		// Drain the body so the connection is reused
+		_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, handlers.MaxResponseSize))
+		return resp, statusError{code: res.StatusCode}
+	}
+	err = decode(res.Body, &resp)
+	return resp, err
+}
+
+func (r *httpRequester) close() {
+	r.closeTransport()
+}
+
+type grpcRequester struct {
+	conn   *grpc.ClientConn
+	client proto.EchoServiceClient
+}
+
+func newGRPCRequester(opts Options) (requester, error) {
+	creds := insecure.NewCredentials()
+	if opts.GRPCTLS {
+		creds = credentials.NewTLS(&tls.Config{InsecureSkipVerify: opts.InsecureSkipVerify}) // #nosec G402 -- echo-app serves a self-signed certificate
+	}
+	conn, err := grpc.NewClient(opts.Target, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, fmt.Errorf("invalid target %q: %w", opts.Target, err)
+	}
+	return &grpcRequester{conn: conn, client: proto.NewEchoServiceClient(conn)}, nil
+}
+
+func (r *grpcRequester) do(ctx context.Context) (response, error) {
+	res, err := r.client.Echo(ctx, &proto.EchoRequest{})
+	if err != nil {
+		return response{}, err
+	}
+	return response{Hostname: res.GetHostname(), Node: res.GetNode()}, nil
+}
+
+func (r *grpcRequester) close() {
+	_ = r.conn.Close()
+}
+
+// tcpRequester opens a connection per request, as the TCP listener writes
+// one response and closes the connection
+type tcpRequester struct {
+	target string
+}
+
+func newTCPRequester(opts Options) (requester, error) {
+	if _, _, err := net.SplitHostPort(opts.Target); err != nil {
+		return nil, fmt.Errorf("invalid target %q: must be host:port", opts.Target)
+	}
+	return &tcpRequester{target: opts.Target}, nil
+}
+
+func (r *tcpRequester) do(ctx context.Context) (response, error) {
+	var resp response
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", r.target)
+	if err != nil {
+		return resp, err
+	}
+	defer func() { _ = conn.Close() }()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	err = decode(conn, &resp)
+	return resp, err
+}
+
+func (r *tcpRequester) close() {}
+
+// decode reads a JSON echo response into v, requiring the hostname
+func decode(r io.Reader, v *response) error {
+	if err := handlers.DecodeResponse(r, v); err != nil || v.Hostname == "" {
+		return errors.New("invalid response: not an echo-app JSON response")
+	}
+	return nil
+}
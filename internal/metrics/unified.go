@@ -1,8 +1,13 @@
 package metrics
 
 import (
+	"context"
+	"strconv"
+	"time"
+
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var (
@@ -42,12 +47,177 @@ var (
 		},
 		[]string{"listener"},
 	)
+
+	// WebTransportSessionsTotal tracks WebTransport sessions accepted on the QUIC listener
+	WebTransportSessionsTotal = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "echo_app_webtransport_sessions_total",
+			Help: "Total number of WebTransport sessions",
+		},
+	)
+
+	// UpstreamRequestsTotal tracks calls made to chained upstreams
+	UpstreamRequestsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "echo_app_upstream_requests_total",
+			Help: "Total number of upstream calls",
+		},
+		[]string{"protocol", "result"},
+	)
+
+	// UpstreamDuration tracks the latency of calls made to chained upstreams
+	UpstreamDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "echo_app_upstream_duration_seconds",
+			Help:    "Upstream call duration in seconds",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"protocol"},
+	)
+
+	// ListenerUp tracks whether each listener is bound and serving
+	ListenerUp = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "echo_app_listener_up",
+			Help: "Whether the listener is serving (1) or failed or stopped (0)",
+		},
+		[]string{"listener"},
+	)
+
+	// ListenerFailuresTotal tracks listeners failing to start or stopping unexpectedly
+	ListenerFailuresTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "echo_app_listener_failures_total",
+			Help: "Total number of listener failures",
+		},
+		[]string{"listener"},
+	)
+
+	// ListenerRestartsTotal tracks listeners restarted by the restart failure policy
+	ListenerRestartsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "echo_app_listener_restarts_total",
+			Help: "Total number of listener restarts after failures",
+		},
+		[]string{"listener"},
+	)
+
+	// ReadinessProbeUp tracks whether each external readiness probe passes
+	ReadinessProbeUp = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "echo_app_readiness_probe_up",
+			Help: "Whether the external readiness probe is ready (1) or not (0) after its success and failure thresholds",
+		},
+		[]string{"probe"},
+	)
+
+	// ReadinessProbeChecksTotal tracks external readiness probe checks
+	ReadinessProbeChecksTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "echo_app_readiness_probe_checks_total",
+			Help: "Total number of external readiness probe checks",
+		},
+		[]string{"probe", "result"},
+	)
+
+	// ReadinessProbeTransitionsTotal tracks external readiness probe readiness changes
+	ReadinessProbeTransitionsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "echo_app_readiness_probe_transitions_total",
+			Help: "Total number of external readiness probe changes to ready or not ready",
+		},
+		[]string{"probe", "state"},
+	)
+
+	// ReadinessProbeFlapsTotal tracks external readiness probe check results
+	// differing from the previous one, whether or not the thresholds let
+	// them change readiness
+	ReadinessProbeFlapsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "echo_app_readiness_probe_flaps_total",
+			Help: "Total number of external readiness probe checks whose result differed from the previous check",
+		},
+		[]string{"probe"},
+	)
+
+	// ReadinessProbeDuration tracks how long external readiness probe checks take
+	ReadinessProbeDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "echo_app_readiness_probe_duration_seconds",
+			Help:    "External readiness probe check duration in seconds",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"probe"},
+	)
+
+	// HealthOverride tracks manual liveness and readiness overrides
+	HealthOverride = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "echo_app_health_override",
+			Help: "Whether the probe is manually overridden to passing (state=true) or failing (state=false)",
+		},
+		[]string{"probe", "state"},
+	)
+
+	// DrainPhase tracks the progress of a graceful drain
+	DrainPhase = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "echo_app_drain_phase",
+			Help: "Drain progress: serving (0), not ready while still serving (1) or closing connections (2)",
+		},
+	)
+
+	// DrainRemainingConnections tracks the connections left while draining
+	DrainRemainingConnections = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "echo_app_drain_remaining_connections",
+			Help: "Active connections the drain is still waiting for",
+		},
+	)
+
+	// ConfigReloadsTotal tracks configuration reload attempts
+	ConfigReloadsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "echo_app_config_reloads_total",
+			Help: "Total number of configuration reloads",
+		},
+		[]string{"trigger", "result"},
+	)
+
+	// ConfigLastReloadTimestamp tracks when the configuration was last reloaded
+	ConfigLastReloadTimestamp = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "echo_app_config_last_reload_timestamp_seconds",
+			Help: "Unix time of the last configuration reload",
+		},
+	)
+
+	// ConfigLastReloadSuccess tracks whether the last configuration reload succeeded
+	ConfigLastReloadSuccess = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "echo_app_config_last_reload_successful",
+			Help: "Whether the last configuration reload succeeded (1) or failed (0)",
+		},
+	)
 )
 
 // RecordRequest records a successful request
 func RecordRequest(listener, method, endpoint string, duration float64) {
+	RecordRequestContext(context.Background(), listener, method, endpoint, duration)
+}
+
+// RecordRequestContext records a successful request, attaching the trace ID
+// of a sampled span in ctx to the duration observation as an exemplar
+func RecordRequestContext(ctx context.Context, listener, method, endpoint string, duration float64) {
 	RequestsTotal.WithLabelValues(listener, method, endpoint).Inc()
-	RequestDuration.WithLabelValues(listener, method, endpoint).Observe(duration)
+	observer := RequestDuration.WithLabelValues(listener, method, endpoint)
+	if sc := trace.SpanContextFromContext(ctx); sc.IsSampled() {
+		if exemplarObserver, ok := observer.(prometheus.ExemplarObserver); ok {
+			exemplarObserver.ObserveWithExemplar(duration, prometheus.Labels{"trace_id": sc.TraceID().String()})
+			return
+		}
+	}
+	observer.Observe(duration)
 }
 
 // RecordError records an error
@@ -64,3 +234,107 @@ func ConnectionOpened(listener string) {
 func ConnectionClosed(listener string) {
 	ActiveConnections.WithLabelValues(listener).Dec()
 }
+
+// WebTransportSessionOpened records a new WebTransport session
+func WebTransportSessionOpened() {
+	WebTransportSessionsTotal.Inc()
+	ConnectionOpened("WebTransport")
+}
+
+// WebTransportSessionClosed records the end of a WebTransport session
+func WebTransportSessionClosed() {
+	ConnectionClosed("WebTransport")
+}
+
+// RecordUpstream records an upstream call and its outcome
+func RecordUpstream(protocol string, success bool, duration float64) {
+	result := "success"
+	if !success {
+		result = "error"
+	}
+	UpstreamRequestsTotal.WithLabelValues(protocol, result).Inc()
+	UpstreamDuration.WithLabelValues(protocol).Observe(duration)
+}
+
+// ListenerServing records that the listener is bound and serving
+func ListenerServing(listener string) {
+	ListenerUp.WithLabelValues(listener).Set(1)
+}
+
+// ListenerFailed records a listener failure
+func ListenerFailed(listener string) {
+	ListenerUp.WithLabelValues(listener).Set(0)
+	ListenerFailuresTotal.WithLabelValues(listener).Inc()
+}
+
+// ListenerStopped records that the listener was stopped on purpose
+func ListenerStopped(listener string) {
+	ListenerUp.WithLabelValues(listener).Set(0)
+}
+
+// ListenerRestarted records a restart of a failed listener
+func ListenerRestarted(listener string) {
+	ListenerRestartsTotal.WithLabelValues(listener).Inc()
+}
+
+// ListenerRemoved drops the status of a listener that was stopped
+func ListenerRemoved(listener string) {
+	ListenerUp.DeleteLabelValues(listener)
+}
+
+// RecordReadinessProbe records a check of the external readiness probe and
+// whether the probe is ready
+func RecordReadinessProbe(probe string, passed, ready bool, duration float64) {
+	result, up := "failure", 0.0
+	if passed {
+		result = "success"
+	}
+	if ready {
+		up = 1
+	}
+	ReadinessProbeUp.WithLabelValues(probe).Set(up)
+	ReadinessProbeChecksTotal.WithLabelValues(probe, result).Inc()
+	ReadinessProbeDuration.WithLabelValues(probe).Observe(duration)
+}
+
+// ReadinessProbeTransition records the external readiness probe becoming
+// ready or not ready
+func ReadinessProbeTransition(probe string, ready bool) {
+	state := "not_ready"
+	if ready {
+		state = "ready"
+	}
+	ReadinessProbeTransitionsTotal.WithLabelValues(probe, state).Inc()
+}
+
+// ReadinessProbeRemoved drops the metrics of a removed external readiness probe
+func ReadinessProbeRemoved(probe string) {
+	ReadinessProbeUp.DeleteLabelValues(probe)
+	ReadinessProbeChecksTotal.DeletePartialMatch(prometheus.Labels{"probe": probe})
+	ReadinessProbeDuration.DeleteLabelValues(probe)
+	ReadinessProbeTransitionsTotal.DeletePartialMatch(prometheus.Labels{"probe": probe})
+	ReadinessProbeFlapsTotal.DeleteLabelValues(probe)
+}
+
+// HealthOverrideSet records a manual override of probe to state
+func HealthOverrideSet(probe string, state bool) {
+	HealthOverride.WithLabelValues(probe, strconv.FormatBool(state)).Set(1)
+	HealthOverride.WithLabelValues(probe, strconv.FormatBool(!state)).Set(0)
+}
+
+// HealthOverrideCleared records the end of a manual override of probe
+func HealthOverrideCleared(probe string) {
+	HealthOverride.WithLabelValues(probe, "true").Set(0)
+	HealthOverride.WithLabelValues(probe, "false").Set(0)
+}
+
+// RecordConfigReload records a configuration reload and its outcome
+func RecordConfigReload(trigger string, success bool, at time.Time) {
+	result, value := "success", 1.0
+	if !success {
+		result, value = "error", 0
+	}
+	ConfigReloadsTotal.WithLabelValues(trigger, result).Inc()
+	ConfigLastReloadTimestamp.Set(float64(at.Unix()))
+	ConfigLastReloadSuccess.Set(value)
+}
@@ -0,0 +1,67 @@
+// Package reload tracks configuration reloads and watches the config file for
+// changes
+package reload
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/PhilipSchmid/echo-app/internal/metrics"
+)
+
+// Reload triggers
+const (
+	TriggerSignal = "signal" // SIGHUP
+	TriggerFile   = "file"   // Config file change
+)
+
+// Status is the outcome of the last configuration reload
+type Status struct {
+	Reloads    int        `json:"reloads"`
+	LastReload *time.Time `json:"last_reload,omitempty"`
+	Trigger    string     `json:"trigger,omitempty"`
+	Success    bool       `json:"success"`
+	Error      string     `json:"error,omitempty"`
+}
+
+var (
+	mu     sync.RWMutex
+	status Status
+)
+
+// Record stores the outcome of a reload and updates the reload metrics
+func Record(trigger string, err error) {
+	now := time.Now()
+	mu.Lock()
+	status = Status{
+		Reloads:    status.Reloads + 1,
+		LastReload: &now,
+		Trigger:    trigger,
+		Success:    err == nil,
+	}
+	if err != nil {
+		status.Error = err.Error()
+	}
+	mu.Unlock()
+	metrics.RecordConfigReload(trigger, err == nil, now)
+}
+
+// Current returns the outcome of the last reload
+func Current() Status {
+	mu.RLock()
+	defer mu.RUnlock()
+	return status
+}
+
+// Handler serves the outcome of the last reload as JSON
+func Handler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(Current())
+}
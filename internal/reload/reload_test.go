@@ -0,0 +1,127 @@
+package reload
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/PhilipSchmid/echo-app/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecord(t *testing.T) {
+	before := testutil.ToFloat64(metrics.ConfigReloadsTotal.WithLabelValues(TriggerSignal, "error"))
+
+	Record(TriggerFile, nil)
+	status := Current()
+	assert.True(t, status.Success)
+	assert.Equal(t, TriggerFile, status.Trigger)
+	assert.Empty(t, status.Error)
+	require.NotNil(t, status.LastReload)
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.ConfigLastReloadSuccess))
+
+	Record(TriggerSignal, errors.New("invalid log format: xml"))
+	status = Current()
+	assert.False(t, status.Success)
+	assert.Equal(t, "invalid log format: xml", status.Error)
+	assert.Equal(t, 0.0, testutil.ToFloat64(metrics.ConfigLastReloadSuccess))
+	assert.Equal(t, before+1, testutil.ToFloat64(metrics.ConfigReloadsTotal.WithLabelValues(TriggerSignal, "error")))
+	assert.Equal(t, float64(status.LastReload.Unix()), testutil.ToFloat64(metrics.ConfigLastReloadTimestamp))
+}
+
+func TestHandler(t *testing.T) {
+	Record(TriggerSignal, errors.New("boom"))
+
+	rec := httptest.NewRecorder()
+	Handler(rec, httptest.NewRequest(http.MethodGet, "/admin/reload", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	var body map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, false, body["success"])
+	assert.Equal(t, "signal", body["trigger"])
+	assert.Equal(t, "boom", body["error"])
+	assert.NotEmpty(t, body["last_reload"])
+
+	rec = httptest.NewRecorder()
+	Handler(rec, httptest.NewRequest(http.MethodPost, "/admin/reload", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
+
+func TestWatch(t *testing.T) {
+	tests := []struct {
+		name   string
+		setup  func(t *testing.T, dir string) string
+		change func(t *testing.T, dir string)
+	}{
+		{
+			name: "write",
+			setup: func(t *testing.T, dir string) string {
+				path := filepath.Join(dir, "config.yaml")
+				require.NoError(t, os.WriteFile(path, []byte("message: a\n"), 0o600))
+				return path
+			},
+			change: func(t *testing.T, dir string) {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, "config.yaml"), []byte("message: b\n"), 0o600))
+			},
+		},
+		{
+			// Kubernetes mounts ConfigMap keys through a ..data symlink
+			// that is swapped atomically on updates
+			name: "configmap symlink swap",
+			setup: func(t *testing.T, dir string) string {
+				require.NoError(t, os.Mkdir(filepath.Join(dir, "v1"), 0o700))
+				require.NoError(t, os.WriteFile(filepath.Join(dir, "v1", "config.yaml"), []byte("message: a\n"), 0o600))
+				require.NoError(t, os.Symlink("v1", filepath.Join(dir, "..data")))
+				path := filepath.Join(dir, "config.yaml")
+				require.NoError(t, os.Symlink(filepath.Join("..data", "config.yaml"), path))
+				return path
+			},
+			change: func(t *testing.T, dir string) {
+				require.NoError(t, os.Mkdir(filepath.Join(dir, "v2"), 0o700))
+				require.NoError(t, os.WriteFile(filepath.Join(dir, "v2", "config.yaml"), []byte("message: b\n"), 0o600))
+				require.NoError(t, os.Symlink("v2", filepath.Join(dir, "..data_tmp")))
+				require.NoError(t, os.Rename(filepath.Join(dir, "..data_tmp"), filepath.Join(dir, "..data")))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := tt.setup(t, dir)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			changes, err := Watch(ctx, path)
+			require.NoError(t, err)
+
+			// Unrelated files in the directory are ignored
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "other.yaml"), nil, 0o600))
+			select {
+			case <-changes:
+				t.Fatal("unexpected change for an unrelated file")
+			case <-time.After(3 * debounce):
+			}
+
+			tt.change(t, dir)
+			select {
+			case <-changes:
+			case <-time.After(5 * time.Second):
+				t.Fatal("no change reported")
+			}
+		})
+	}
+}
+
+func TestWatch_MissingDirectory(t *testing.T) {
+	_, err := Watch(context.Background(), filepath.Join(t.TempDir(), "missing", "config.yaml"))
+	assert.ErrorContains(t, err, "failed to watch config file")
+}
@@ -0,0 +1,71 @@
+package reload
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/sirupsen/logrus"
+)
+
+// debounce groups the bursts of events editors and ConfigMap updates emit
+// into a single reload
+const debounce = 100 * time.Millisecond
+
+// Watch signals on the returned channel whenever the config file at path
+// changes, until ctx is done. The file's directory is watched rather than the
+// file itself, so files replaced by editors or by Kubernetes ConfigMap symlink
+// swaps keep being watched.
+func Watch(ctx context.Context, path string) (<-chan struct{}, error) {
+	path = filepath.Clean(path)
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create config file watcher: %w", err)
+	}
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		_ = watcher.Close()
+		return nil, fmt.Errorf("failed to watch config file: %w", err)
+	}
+
+	changes := make(chan struct{}, 1)
+	go func() {
+		defer func() { _ = watcher.Close() }()
+		realPath, _ := filepath.EvalSymlinks(path)
+		timer := time.NewTimer(debounce)
+		timer.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				// A ConfigMap update swaps the symlink the file resolves
+				// through, without an event for the file itself
+				current, _ := filepath.EvalSymlinks(path)
+				swapped := current != realPath
+				realPath = current
+				written := filepath.Clean(event.Name) == path &&
+					(event.Has(fsnotify.Write) || event.Has(fsnotify.Create) || event.Has(fsnotify.Rename))
+				if swapped || written {
+					timer.Reset(debounce)
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				logrus.Warnf("Config file watcher error: %v", err)
+			case <-timer.C:
+				select {
+				case changes <- struct{}{}:
+				default: // A reload is already pending
+				}
+			}
+		}
+	}()
+	return changes, nil
+}
@@ -0,0 +1,63 @@
+// Package requestid generates and propagates per-request correlation IDs
+package requestid
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// DefaultHeader is the header used to accept and return request IDs
+const DefaultHeader = "X-Request-ID"
+
+// LogField is the log field carrying the request ID
+const LogField = "request_id"
+
+// maxLength caps accepted IDs so clients cannot bloat log lines
+const maxLength = 128
+
+type contextKey struct{}
+
+// New generates a time-ordered UUIDv7, falling back to a random UUIDv4
+func New() string {
+	id, err := uuid.NewV7()
+	if err != nil {
+		return uuid.NewString()
+	}
+	return id.String()
+}
+
+// FromHeader returns the incoming ID when it is usable, or a new one. IDs
+// that are too long or contain anything but visible ASCII are replaced so
+// they cannot break log lines or response headers.
+func FromHeader(value string) string {
+	if Valid(value) {
+		return value
+	}
+	return New()
+}
+
+// Valid reports whether id can be propagated as is
+func Valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		c := id[i]
+		if c <= ' ' || c > '~' || c == '"' || c == '\\' {
+			return false
+		}
+	}
+	return true
+}
+
+// NewContext returns a copy of ctx carrying id
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the request ID stored in ctx, if any
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
@@ -0,0 +1,59 @@
+package requestid
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	first, second := New(), New()
+	assert.NotEqual(t, first, second)
+
+	id, err := uuid.Parse(first)
+	require.NoError(t, err)
+	assert.Equal(t, uuid.Version(7), id.Version())
+	// UUIDv7 sorts by creation time
+	assert.Less(t, first, second)
+}
+
+func TestFromHeader(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		accepted bool
+	}{
+		{name: "uuid", value: "0190d6a4-8f1e-7c3a-9b2d-1f4e5a6b7c8d", accepted: true},
+		{name: "opaque token", value: "req_abc123:retry/2", accepted: true},
+		{name: "empty", value: ""},
+		{name: "too long", value: strings.Repeat("a", maxLength+1)},
+		{name: "whitespace", value: "abc def"},
+		{name: "newline", value: "abc\nforged=1"},
+		{name: "quote", value: `abc"def`},
+		{name: "non-ASCII", value: "abcé"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id := FromHeader(tt.value)
+			if tt.accepted {
+				assert.Equal(t, tt.value, id)
+				return
+			}
+			assert.NotEqual(t, tt.value, id)
+			_, err := uuid.Parse(id)
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestContext(t *testing.T) {
+	assert.Empty(t, FromContext(context.Background()))
+
+	ctx := NewContext(context.Background(), "abc")
+	assert.Equal(t, "abc", FromContext(ctx))
+}
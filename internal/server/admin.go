@@ -0,0 +1,227 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"time"
+
+	"github.com/PhilipSchmid/echo-app/internal/health"
+	"github.com/PhilipSchmid/echo-app/internal/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// Listener states reported by the admin API
+const (
+	ListenerStarting = "starting" // Launched, not listening yet
+	ListenerRunning  = "running"  // Listening
+	ListenerFailed   = "failed"   // Failed to start or stopped unexpectedly
+	ListenerStopped  = "stopped"  // Not started, shut down or stopped through the admin API
+)
+
+var (
+	// ErrListenerNotFound is returned for listeners that are not configured
+	ErrListenerNotFound = errors.New("listener not found")
+	// ErrListenerState is returned when a listener cannot be started or
+	// stopped in its current state
+	ErrListenerState = errors.New("invalid listener state")
+)
+
+// adminRequestTimeout bounds starting and stopping a listener through the
+// admin API, below the metrics server's write timeout
+const adminRequestTimeout = 9 * time.Second
+
+// ListenerStatus describes a server managed by the Manager
+type ListenerStatus struct {
+	Name              string    `json:"name"`
+	Protocol          string    `json:"protocol,omitempty"`
+	State             string    `json:"state"`
+	Since             time.Time `json:"since,omitzero"` // When the server entered its state
+	Network           string    `json:"network,omitempty"`
+	Address           string    `json:"address,omitempty"`
+	Port              int       `json:"port,omitempty"`
+	ActiveConnections *int      `json:"active_connections,omitempty"` // Open connections, only counted by HTTP, TLS and TCP listeners
+	Restarts          int       `json:"restarts"`                     // Restarts by the restart failure policy
+	Error             string    `json:"error,omitempty"`
+}
+
+// AdminStatus summarizes the running instance
+type AdminStatus struct {
+	Version    string         `json:"version"`
+	Revision   string         `json:"revision,omitempty"`
+	StartedAt  time.Time      `json:"started_at"`
+	Uptime     string         `json:"uptime"`
+	ConfigHash string         `json:"config_hash"`
+	Health     health.State   `json:"health"`
+	Listeners  map[string]int `json:"listeners"` // Number of servers in each state
+}
+
+// connectionCounter is implemented by servers counting their active
+// connections
+type connectionCounter interface {
+	activeConnections() int
+}
+
+// unwrap returns the server behind a configured listener's name
+func unwrap(srv Server) Server {
+	if named, ok := srv.(namedServer); ok {
+		return named.Server
+	}
+	return srv
+}
+
+// registerAdmin adds the admin API fed by manager and checker to mux. Changing
+// listeners or probes requires the admin token and is disabled without one.
+func registerAdmin(mux *http.ServeMux, manager *Manager, checker *health.Checker) {
+	mux.HandleFunc("GET /admin/status", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusOK, manager.adminStatus(checker))
+	})
+	mux.HandleFunc("GET /admin/listeners", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusOK, map[string][]ListenerStatus{"listeners": manager.Listeners()})
+	})
+	mux.HandleFunc("GET /admin/listeners/{name}", func(w http.ResponseWriter, r *http.Request) {
+		status, err := manager.Listener(r.PathValue("name"))
+		writeAdminResult(w, status, err)
+	})
+	mux.Handle("POST /admin/listeners/{name}/stop", manager.requireAdminToken(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), adminRequestTimeout)
+		defer cancel()
+		status, err := manager.StopListener(ctx, r.PathValue("name"))
+		writeAdminResult(w, status, err)
+	}))
+	mux.Handle("POST /admin/listeners/{name}/start", manager.requireAdminToken(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), adminRequestTimeout)
+		defer cancel()
+		status, err := manager.StartListener(ctx, r.PathValue("name"))
+		writeAdminResult(w, status, err)
+	}))
+	for path, probe := range map[string]string{"/admin/live": health.ProbeLiveness, "/admin/ready": health.ProbeReadiness} {
+		mux.Handle("POST "+path, manager.requireAdminToken(overrideHandler(checker, probe)))
+		mux.Handle("DELETE "+path, manager.requireAdminToken(func(w http.ResponseWriter, _ *http.Request) {
+			if err := checker.ClearOverride(probe); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			logrus.Infof("[Admin] Manual %s override cleared", probe)
+			writeJSON(w, http.StatusOK, checker.State())
+		}))
+	}
+}
+
+// overrideHandler forces probe to the state query parameter, for the
+// optional duration
+func overrideHandler(checker *health.Checker, probe string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		state, err := strconv.ParseBool(query.Get("state"))
+		if err != nil {
+			http.Error(w, "Bad Request: state must be true or false", http.StatusBadRequest)
+			return
+		}
+		var duration time.Duration
+		if d := query.Get("duration"); d != "" {
+			if duration, err = time.ParseDuration(d); err != nil || duration <= 0 {
+				http.Error(w, "Bad Request: duration must be a positive duration such as 60s", http.StatusBadRequest)
+				return
+			}
+		}
+		if err := checker.SetOverride(probe, state, duration); errors.Is(err, health.ErrDraining) {
+			http.Error(w, "Conflict: "+err.Error(), http.StatusConflict)
+			return
+		} else if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if duration > 0 {
+			logrus.Warnf("[Admin] Manual %s override set to %t for %s", probe, state, duration)
+		} else {
+			logrus.Warnf("[Admin] Manual %s override set to %t", probe, state)
+		}
+		writeJSON(w, http.StatusOK, checker.State())
+	}
+}
+
+// requireAdminToken admits requests carrying the current admin token as
+// bearer token
+func (m *Manager) requireAdminToken(next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.mu.Lock()
+		expected := m.cfg.AdminToken
+		m.mu.Unlock()
+		if expected == "" {
+			http.Error(w, "Forbidden: the admin API is disabled without an admin token", http.StatusForbidden)
+			return
+		}
+		if !utils.RequireBearerToken(w, r, expected, "echo-app admin") {
+			logrus.Warnf("[Admin] Rejected request from %s: invalid token", r.RemoteAddr)
+			return
+		}
+		logrus.Infof("[Admin] %s %s from %s", r.Method, r.URL.Path, r.RemoteAddr)
+		next(w, r)
+	})
+}
+
+// adminStatus summarizes the instance for /admin/status
+func (m *Manager) adminStatus(checker *health.Checker) AdminStatus {
+	version, revision := buildVersion()
+	m.mu.Lock()
+	status := AdminStatus{
+		Version:    version,
+		Revision:   revision,
+		StartedAt:  m.startedAt,
+		Uptime:     time.Since(m.startedAt).Round(time.Second).String(),
+		ConfigHash: m.cfg.Hash(),
+		Listeners:  make(map[string]int),
+	}
+	for _, srv := range m.servers {
+		status.Listeners[m.status(srv).State]++
+	}
+	m.mu.Unlock()
+	if checker != nil {
+		status.Health = checker.State()
+	}
+	return status
+}
+
+// buildVersion returns the module version and VCS revision echo-app was
+// built from
+func buildVersion() (string, string) {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown", ""
+	}
+	revision := ""
+	for _, setting := range info.Settings {
+		if setting.Key == "vcs.revision" {
+			revision = setting.Value
+		}
+	}
+	return info.Main.Version, revision
+}
+
+// writeAdminResult writes the listener status, or err with the matching
+// status code
+func writeAdminResult(w http.ResponseWriter, status ListenerStatus, err error) {
+	switch {
+	case err == nil:
+		writeJSON(w, http.StatusOK, status)
+	case errors.Is(err, ErrListenerNotFound):
+		http.Error(w, "Not Found: "+err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrListenerState):
+		http.Error(w, "Conflict: "+err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// writeJSON writes v as JSON with status code
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logrus.Errorf("[Admin] Failed to write response: %v", err)
+	}
+}
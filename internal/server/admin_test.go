@@ -0,0 +1,239 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/PhilipSchmid/echo-app/internal/config"
+	"github.com/PhilipSchmid/echo-app/internal/health"
+	"github.com/PhilipSchmid/echo-app/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newAdminTest starts a manager with an HTTP listener named web and serves
+// its admin API
+func newAdminTest(t *testing.T, token string) (*Manager, *health.Checker, *httptest.Server) {
+	t.Helper()
+	cfg := config.Default()
+	cfg.AdminToken = token
+	cfg.ListenerFailurePolicy = config.FailurePolicyRestart
+	require.NoError(t, cfg.SetListeners([]config.Listener{{Name: "web", Protocol: config.ListenerHTTP, Address: "127.0.0.1:0"}}))
+	require.NoError(t, cfg.Validate())
+
+	checker := health.NewChecker(cfg.ExternalReadiness)
+	manager := NewManager(cfg, checker)
+	require.NoError(t, manager.RegisterListener(cfg, cfg.Listeners[0]))
+	require.NoError(t, manager.Start(context.Background()))
+	t.Cleanup(func() { _ = manager.Shutdown(5 * time.Second) })
+
+	mux := http.NewServeMux()
+	registerAdmin(mux, manager, checker)
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+	return manager, checker, ts
+}
+
+// adminRequest sends a request to the admin API and returns the status code
+// and body
+func adminRequest(t *testing.T, ts *httptest.Server, method, path, token string) (int, string) {
+	t.Helper()
+	req, err := http.NewRequest(method, ts.URL+path, nil)
+	require.NoError(t, err)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := ts.Client().Do(req)
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, string(body)
+}
+
+func TestAdmin_Status(t *testing.T) {
+	manager, _, ts := newAdminTest(t, "secret")
+
+	code, body := adminRequest(t, ts, http.MethodGet, "/admin/listeners", "")
+	require.Equal(t, http.StatusOK, code)
+	var list struct {
+		Listeners []ListenerStatus `json:"listeners"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(body), &list))
+	require.Len(t, list.Listeners, 1)
+	web := list.Listeners[0]
+	assert.Equal(t, "web", web.Name)
+	assert.Equal(t, config.ListenerHTTP, web.Protocol)
+	assert.Equal(t, ListenerRunning, web.State)
+	assert.Equal(t, "tcp", web.Network)
+	assert.NotZero(t, web.Port)
+	require.NotNil(t, web.ActiveConnections)
+	assert.Equal(t, 0, *web.ActiveConnections)
+
+	code, body = adminRequest(t, ts, http.MethodGet, "/admin/status", "")
+	require.Equal(t, http.StatusOK, code)
+	var status AdminStatus
+	require.NoError(t, json.Unmarshal([]byte(body), &status))
+	assert.NotEmpty(t, status.Version)
+	assert.Equal(t, manager.cfg.Hash(), status.ConfigHash)
+	assert.Equal(t, health.State{Healthy: true, Ready: true}, status.Health)
+	assert.Equal(t, map[string]int{ListenerRunning: 1}, status.Listeners)
+	assert.False(t, status.StartedAt.IsZero())
+
+	code, _ = adminRequest(t, ts, http.MethodGet, "/admin/listeners/missing", "")
+	assert.Equal(t, http.StatusNotFound, code)
+}
+
+func TestAdmin_StopAndStartListener(t *testing.T) {
+	manager, checker, ts := newAdminTest(t, "secret")
+
+	tests := []struct {
+		name     string
+		path     string
+		token    string
+		wantCode int
+	}{
+		{name: "missing token", path: "/admin/listeners/web/stop", wantCode: http.StatusUnauthorized},
+		{name: "wrong token", path: "/admin/listeners/web/stop", token: "wrong", wantCode: http.StatusUnauthorized},
+		{name: "unknown listener", path: "/admin/listeners/missing/stop", token: "secret", wantCode: http.StatusNotFound},
+		{name: "start running listener", path: "/admin/listeners/web/start", token: "secret", wantCode: http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _ := adminRequest(t, ts, http.MethodPost, tt.path, tt.token)
+			assert.Equal(t, tt.wantCode, code)
+		})
+	}
+	running, err := manager.Listener("web")
+	require.NoError(t, err)
+	require.Equal(t, ListenerRunning, running.State, "rejected requests leave the listener running")
+
+	code, body := adminRequest(t, ts, http.MethodPost, "/admin/listeners/web/stop", "secret")
+	require.Equal(t, http.StatusOK, code, body)
+	assert.Contains(t, body, `"state":"stopped"`)
+	_, err = http.Get("http://" + running.Address)
+	assert.Error(t, err, "stopped listener refuses connections")
+	assert.Equal(t, http.StatusOK, readyCode(checker), "stopped listeners do not affect readiness")
+	assert.Equal(t, 0.0, testutil.ToFloat64(metrics.ListenerUp.WithLabelValues("web")))
+
+	// The restart policy leaves listeners stopped through the admin API alone
+	select {
+	case err := <-manager.Failed():
+		t.Fatalf("stopping reported as failure: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	stopped, err := manager.Listener("web")
+	require.NoError(t, err)
+	assert.Equal(t, ListenerStopped, stopped.State)
+	assert.Zero(t, stopped.Restarts)
+	code, _ = adminRequest(t, ts, http.MethodPost, "/admin/listeners/web/stop", "secret")
+	assert.Equal(t, http.StatusConflict, code)
+
+	code, body = adminRequest(t, ts, http.MethodPost, "/admin/listeners/web/start", "secret")
+	require.Equal(t, http.StatusOK, code, body)
+	var started ListenerStatus
+	require.NoError(t, json.Unmarshal([]byte(body), &started))
+	assert.Equal(t, ListenerRunning, started.State)
+	resp, err := http.Get("http://" + started.Address)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.ListenerUp.WithLabelValues("web")))
+}
+
+func TestAdmin_StatusDuringSlowStop(t *testing.T) {
+	manager, _, ts := newAdminTest(t, "secret")
+	running, err := manager.Listener("web")
+	require.NoError(t, err)
+
+	// A partially sent request keeps the connection active, so stopping the
+	// listener waits for it
+	conn, err := net.Dial("tcp", running.Address)
+	require.NoError(t, err)
+	defer func() { _ = conn.Close() }()
+	_, err = conn.Write([]byte("GET / HTTP/1.1\r\n"))
+	require.NoError(t, err)
+
+	stopped := make(chan error, 1)
+	go func() {
+		_, err := manager.StopListener(context.Background(), "web")
+		stopped <- err
+	}()
+	require.Eventually(t, func() bool {
+		status, err := manager.Listener("web")
+		return err == nil && status.State == ListenerStopped
+	}, 2*time.Second, 10*time.Millisecond)
+
+	ts.Client().Timeout = time.Second
+	code, body := adminRequest(t, ts, http.MethodGet, "/admin/listeners", "secret")
+	assert.Equal(t, http.StatusOK, code, body)
+	assert.Contains(t, body, `"state":"stopped"`)
+	select {
+	case err := <-stopped:
+		t.Fatalf("stop finished before the connection closed: %v", err)
+	default:
+	}
+
+	_ = conn.Close()
+	select {
+	case err := <-stopped:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("stop did not finish after the connection closed")
+	}
+}
+
+func TestAdmin_DisabledWithoutToken(t *testing.T) {
+	_, _, ts := newAdminTest(t, "")
+
+	code, _ := adminRequest(t, ts, http.MethodPost, "/admin/listeners/web/stop", "anything")
+	assert.Equal(t, http.StatusForbidden, code)
+	code, _ = adminRequest(t, ts, http.MethodGet, "/admin/listeners/web", "")
+	assert.Equal(t, http.StatusOK, code, "status endpoints need no token")
+}
+
+func TestAdmin_Overrides(t *testing.T) {
+	_, checker, ts := newAdminTest(t, "secret")
+
+	tests := []struct {
+		name      string
+		method    string
+		path      string
+		token     string
+		wantCode  int
+		wantReady bool
+		wantLive  bool
+	}{
+		{name: "missing token", method: http.MethodPost, path: "/admin/ready?state=false", wantCode: http.StatusUnauthorized, wantReady: true, wantLive: true},
+		{name: "invalid state", method: http.MethodPost, path: "/admin/ready?state=maybe", token: "secret", wantCode: http.StatusBadRequest, wantReady: true, wantLive: true},
+		{name: "invalid duration", method: http.MethodPost, path: "/admin/ready?state=false&duration=-1s", token: "secret", wantCode: http.StatusBadRequest, wantReady: true, wantLive: true},
+		{name: "not ready", method: http.MethodPost, path: "/admin/ready?state=false&duration=60s", token: "secret", wantCode: http.StatusOK, wantLive: true},
+		{name: "ready again", method: http.MethodDelete, path: "/admin/ready", token: "secret", wantCode: http.StatusOK, wantReady: true, wantLive: true},
+		{name: "not live", method: http.MethodPost, path: "/admin/live?state=false", token: "secret", wantCode: http.StatusOK},
+		{name: "live again", method: http.MethodDelete, path: "/admin/live", token: "secret", wantCode: http.StatusOK, wantReady: true, wantLive: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, body := adminRequest(t, ts, tt.method, tt.path, tt.token)
+			assert.Equal(t, tt.wantCode, code, body)
+			state := checker.State()
+			assert.Equal(t, tt.wantReady, state.Ready)
+			assert.Equal(t, tt.wantLive, state.Healthy)
+			if code == http.StatusOK {
+				var got health.State
+				require.NoError(t, json.Unmarshal([]byte(body), &got))
+				assert.Equal(t, state.Ready, got.Ready)
+				assert.Equal(t, state.Healthy, got.Healthy)
+				assert.Equal(t, state.Reason, got.Reason)
+				assert.Len(t, got.Overrides, len(state.Overrides))
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package server
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/PhilipSchmid/echo-app/internal/metrics"
+	"github.com/sirupsen/logrus"
+)
+
+// drainProgressInterval is how often Drain reports the connections left
+var drainProgressInterval = time.Second
+
+// keepAliveDisabler is implemented by servers that can ask clients to close
+// their connections after the current request while still serving
+type keepAliveDisabler interface {
+	disableKeepAlives()
+}
+
+// Drain gracefully takes the servers out of service. It marks the app not
+// ready and keeps serving for delay, so load balancers stop routing new
+// requests here, while asking HTTP clients to reconnect with Connection: close
+// and HTTP/2 GOAWAY. It then shuts the servers down as Shutdown does, sending
+// gRPC and HTTP/3 GOAWAY and waiting for in-flight requests until timeout. A
+// done ctx cuts the delay short.
+func (m *Manager) Drain(ctx context.Context, delay, timeout time.Duration) error {
+	start := time.Now()
+	if m.health != nil {
+		m.health.SetDraining()
+	}
+	metrics.DrainPhase.Set(1)
+	m.mu.Lock()
+	servers := slices.Clone(m.servers)
+	m.mu.Unlock()
+	for _, srv := range servers {
+		if d, ok := unwrap(srv).(keepAliveDisabler); ok {
+			d.disableKeepAlives()
+		}
+	}
+
+	if delay > 0 {
+		logrus.Infof("Draining: not ready, serving for %s until load balancers stop routing here...", delay)
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			logrus.Infof("Draining: delay cut short after %s", time.Since(start).Round(time.Millisecond))
+		}
+	}
+
+	metrics.DrainPhase.Set(2)
+	logrus.Infof("Draining: closing connections, waiting up to %s for %d open connections...", timeout, m.activeConnections())
+	done := make(chan struct{})
+	go m.reportDrain(done)
+	err := m.Shutdown(timeout)
+	close(done)
+	remaining := m.activeConnections()
+	metrics.DrainRemainingConnections.Set(float64(remaining))
+	if remaining > 0 {
+		logrus.Warnf("Drain finished after %s with %d connections cut off", time.Since(start).Round(time.Millisecond), remaining)
+	} else {
+		logrus.Infof("Drain finished after %s", time.Since(start).Round(time.Millisecond))
+	}
+	return err
+}
+
+// reportDrain logs and records the connections left until done is closed
+func (m *Manager) reportDrain(done <-chan struct{}) {
+	ticker := time.NewTicker(drainProgressInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			remaining := m.activeConnections()
+			metrics.DrainRemainingConnections.Set(float64(remaining))
+			if remaining > 0 {
+				logrus.Infof("Draining: %d connections remaining", remaining)
+			}
+		}
+	}
+}
+
+// activeConnections returns the number of connections open on the servers
+// counting them
+func (m *Manager) activeConnections() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	total := 0
+	for _, srv := range m.servers {
+		if counter, ok := unwrap(srv).(connectionCounter); ok {
+			total += counter.activeConnections()
+		}
+	}
+	return total
+}
@@ -0,0 +1,103 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/PhilipSchmid/echo-app/internal/config"
+	"github.com/PhilipSchmid/echo-app/internal/health"
+	"github.com/PhilipSchmid/echo-app/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newDrainTest starts a manager with an HTTP listener and returns its
+// address. The test must drain the manager.
+func newDrainTest(t *testing.T) (*Manager, *health.Checker, string) {
+	t.Helper()
+	cfg := config.Default()
+	require.NoError(t, cfg.SetListeners([]config.Listener{{Name: "web", Protocol: config.ListenerHTTP, Address: "127.0.0.1:0"}}))
+	checker := health.NewChecker(cfg.ExternalReadiness)
+	manager := NewManager(cfg, checker)
+	require.NoError(t, manager.RegisterListener(cfg, cfg.Listeners[0]))
+	require.NoError(t, manager.Start(context.Background()))
+	addrs := manager.Addresses(context.Background())
+	require.Len(t, addrs, 1)
+	return manager, checker, addrs[0].Address
+}
+
+func TestManager_Drain(t *testing.T) {
+	manager, checker, addr := newDrainTest(t)
+
+	drained := make(chan error, 1)
+	go func() { drained <- manager.Drain(context.Background(), 300*time.Millisecond, 5*time.Second) }()
+	require.Eventually(t, func() bool { return readyCode(checker) == http.StatusServiceUnavailable }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, "draining", checker.State().Reason)
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.DrainPhase))
+
+	// Requests are still served during the delay, asking clients to reconnect
+	resp, err := http.Get("http://" + addr)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.True(t, resp.Close, "response should carry Connection: close")
+
+	select {
+	case err := <-drained:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("drain did not finish")
+	}
+	assert.Equal(t, 2.0, testutil.ToFloat64(metrics.DrainPhase))
+	assert.Equal(t, 0.0, testutil.ToFloat64(metrics.DrainRemainingConnections))
+	_, err = http.Get("http://" + addr)
+	assert.Error(t, err, "listener should be closed after draining")
+}
+
+func TestManager_DrainRejectsReadinessOverride(t *testing.T) {
+	manager, checker, ts := newAdminTest(t, "secret")
+	code, body := adminRequest(t, ts, http.MethodPost, "/admin/ready?state=true", "secret")
+	require.Equal(t, http.StatusOK, code, body)
+
+	drained := make(chan error, 1)
+	go func() { drained <- manager.Drain(context.Background(), 300*time.Millisecond, 5*time.Second) }()
+	require.Eventually(t, func() bool { return readyCode(checker) == http.StatusServiceUnavailable }, time.Second, 10*time.Millisecond)
+	assert.Empty(t, checker.State().Overrides, "draining should end the readiness override")
+
+	// A passing override during the drain delay is rejected
+	code, body = adminRequest(t, ts, http.MethodPost, "/admin/ready?state=true", "secret")
+	assert.Equal(t, http.StatusConflict, code)
+	assert.Contains(t, body, "draining")
+	assert.Equal(t, http.StatusServiceUnavailable, readyCode(checker))
+	assert.Equal(t, "draining", checker.State().Reason)
+
+	select {
+	case err := <-drained:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("drain did not finish")
+	}
+	assert.Equal(t, http.StatusServiceUnavailable, readyCode(checker))
+}
+
+func TestManager_DrainDelayCutShort(t *testing.T) {
+	manager, _, _ := newDrainTest(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	drained := make(chan error, 1)
+	start := time.Now()
+	go func() { drained <- manager.Drain(ctx, time.Hour, 5*time.Second) }()
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-drained:
+		require.NoError(t, err)
+		assert.Less(t, time.Since(start), 5*time.Second)
+	case <-time.After(5 * time.Second):
+		t.Fatal("cancelling the context did not cut the delay short")
+	}
+}
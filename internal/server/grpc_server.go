@@ -3,29 +3,33 @@ package server
 import (
 	"context"
 	"fmt"
-	"net"
+	"sync"
 
 	"github.com/PhilipSchmid/echo-app/internal/config"
 	"github.com/PhilipSchmid/echo-app/internal/handlers"
+	"github.com/PhilipSchmid/echo-app/internal/utils"
 	pb "github.com/PhilipSchmid/echo-app/proto"
 	"github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/reflection"
 )
 
 // GRPCServer represents a gRPC server
 type GRPCServer struct {
 	cfg        *config.Config
+	mu         sync.Mutex // Guards server and stopped
 	server     *grpc.Server
-	listener   net.Listener
+	stopped    bool // Shutdown was called, so Start must not serve
 	listenAddr string
+	boundAddr
 }
 
 // NewGRPCServer creates a new gRPC server
 func NewGRPCServer(cfg *config.Config) *GRPCServer {
 	return &GRPCServer{
 		cfg:        cfg,
-		listenAddr: ":" + cfg.GRPCPort,
+		listenAddr: config.ListenAddress(cfg.GRPCAddress, cfg.GRPCPort),
 	}
 }
 
@@ -36,36 +40,57 @@ func (s *GRPCServer) Name() string {
 
 // Start starts the gRPC server
 func (s *GRPCServer) Start(ctx context.Context) error {
-	listener, err := net.Listen("tcp", s.listenAddr)
+	listener, err := listen(s.listenAddr, s.cfg.SocketMode)
 	if err != nil {
-		return fmt.Errorf("failed to listen on %s: %w", s.listenAddr, err)
+		return err
 	}
-	s.listener = listener
-
 	// Create gRPC server with options
 	opts := []grpc.ServerOption{
 		grpc.MaxConcurrentStreams(100),
 	}
-	s.server = grpc.NewServer(opts...)
+	if s.cfg.GRPCTLS {
+		tlsConfig, err := handlers.GetTLSConfig()
+		if err != nil {
+			_ = listener.Close()
+			return fmt.Errorf("failed to get TLS config: %w", err)
+		}
+		tlsConfig = tlsConfig.Clone()
+		tlsConfig.NextProtos = []string{"h2"}
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+	server := grpc.NewServer(opts...)
 
 	// Register echo service
 	echoServer := handlers.NewEchoServer(s.cfg)
-	pb.RegisterEchoServiceServer(s.server, echoServer)
+	pb.RegisterEchoServiceServer(server, echoServer)
 
 	// Register reflection service for grpcurl
-	reflection.Register(s.server)
+	reflection.Register(server)
+
+	s.mu.Lock()
+	if s.stopped {
+		s.mu.Unlock()
+		_ = listener.Close()
+		return nil
+	}
+	s.server = server
+	s.mu.Unlock()
+	// Published once the server is set, so Shutdown called after the
+	// address is reported sees it
+	s.setAddr(listener.Addr())
+	defer s.setAddr(nil)
 
-	logrus.Infof("gRPC server listening on %s", s.listenAddr)
+	logrus.Infof("gRPC server listening on %s", utils.FormatAddr(listener.Addr()))
 
 	// Start serving in a goroutine to handle context cancellation
 	errCh := make(chan error, 1)
 	go func() {
-		errCh <- s.server.Serve(listener)
+		errCh <- server.Serve(listener)
 	}()
 
 	select {
 	case <-ctx.Done():
-		s.server.GracefulStop()
+		server.GracefulStop()
 		return ctx.Err()
 	case err := <-errCh:
 		return err
@@ -74,14 +99,18 @@ func (s *GRPCServer) Start(ctx context.Context) error {
 
 // Shutdown gracefully shuts down the gRPC server
 func (s *GRPCServer) Shutdown(ctx context.Context) error {
-	if s.server == nil {
+	s.mu.Lock()
+	s.stopped = true
+	server := s.server
+	s.mu.Unlock()
+	if server == nil {
 		return nil
 	}
 
 	// Try graceful stop first
 	stopped := make(chan struct{})
 	go func() {
-		s.server.GracefulStop()
+		server.GracefulStop()
 		close(stopped)
 	}()
 
@@ -90,7 +119,7 @@ func (s *GRPCServer) Shutdown(ctx context.Context) error {
 		return nil
 	case <-ctx.Done():
 		// Force stop if graceful stop times out
-		s.server.Stop()
+		server.Stop()
 		return fmt.Errorf("gRPC server forced shutdown due to timeout")
 	}
 }
@@ -3,12 +3,15 @@ package server
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
+	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/PhilipSchmid/echo-app/internal/config"
 	"github.com/PhilipSchmid/echo-app/internal/handlers"
+	"github.com/PhilipSchmid/echo-app/internal/utils"
 	"github.com/sirupsen/logrus"
 )
 
@@ -20,19 +23,23 @@ const (
 // HTTPServer represents an HTTP server
 type HTTPServer struct {
 	cfg         *config.Config
+	mu          sync.Mutex // Guards server and stopped
 	server      *http.Server
+	stopped     bool // Shutdown was called, so Start must not serve
 	listenAddr  string
 	listener    string
-	activeConns int32
+	activeConns int32 // Requests in flight, bounded by maxHTTPConnections
+	openConns   int32 // Connections open, idle keep-alive ones included
+	boundAddr
 }
 
 // NewHTTPServer creates a new HTTP server
 func NewHTTPServer(cfg *config.Config, useTLS bool) *HTTPServer {
-	port := cfg.HTTPPort
+	address := config.ListenAddress(cfg.HTTPAddress, cfg.HTTPPort)
 	listener := "HTTP"
 	switch {
 	case useTLS:
-		port = cfg.TLSPort
+		address = config.ListenAddress(cfg.TLSAddress, cfg.TLSPort)
 		listener = "TLS"
 	case cfg.H2C:
 		listener = "H2C"
@@ -40,7 +47,7 @@ func NewHTTPServer(cfg *config.Config, useTLS bool) *HTTPServer {
 
 	return &HTTPServer{
 		cfg:        cfg,
-		listenAddr: ":" + port,
+		listenAddr: address,
 		listener:   listener,
 	}
 }
@@ -68,47 +75,98 @@ func (s *HTTPServer) connectionLimitMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// trackConnection counts the open connections by their state changes.
+// Hijacked connections are no longer the server's to close.
+func (s *HTTPServer) trackConnection(_ net.Conn, state http.ConnState) {
+	switch state {
+	case http.StateNew:
+		atomic.AddInt32(&s.openConns, 1)
+	case http.StateHijacked, http.StateClosed:
+		atomic.AddInt32(&s.openConns, -1)
+	}
+}
+
+// activeConnections returns the number of connections open, idle keep-alive
+// connections included
+func (s *HTTPServer) activeConnections() int {
+	return int(atomic.LoadInt32(&s.openConns))
+}
+
 // Start starts the HTTP server
 func (s *HTTPServer) Start(ctx context.Context) error {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/", handlers.HTTPHandler(s.cfg, s.listener))
+	mux.HandleFunc("/chain", handlers.ChainHandler(s.cfg, s.listener))
 
 	// Apply connection limit middleware
 	handler := s.connectionLimitMiddleware(mux)
 
-	s.server = &http.Server{
-		Addr:         s.listenAddr,
+	ln, err := listen(s.listenAddr, s.cfg.SocketMode)
+	if err != nil {
+		return err
+	}
+
+	server := &http.Server{
 		Handler:      handler,
 		ReadTimeout:  30 * time.Second,
 		WriteTimeout: 30 * time.Second,
 		IdleTimeout:  120 * time.Second,
+		ConnState:    s.trackConnection,
 	}
-
-	logrus.Infof("%s server listening on %s", s.listener, s.listenAddr)
-
 	if s.listener == "TLS" {
 		tlsConfig, err := handlers.GetTLSConfig()
 		if err != nil {
+			_ = ln.Close()
 			return fmt.Errorf("failed to get TLS config: %w", err)
 		}
-		s.server.TLSConfig = tlsConfig
-		return s.server.ListenAndServeTLS("", "")
+		server.TLSConfig = tlsConfig
 	}
-
 	if s.listener == "H2C" {
 		protocols := new(http.Protocols)
 		protocols.SetHTTP1(true)
 		protocols.SetUnencryptedHTTP2(true)
-		s.server.Protocols = protocols
+		server.Protocols = protocols
+	}
+	s.mu.Lock()
+	if s.stopped {
+		s.mu.Unlock()
+		_ = ln.Close()
+		return nil
+	}
+	s.server = server
+	s.mu.Unlock()
+	// Published once the server is set, so Shutdown and disableKeepAlives
+	// called after the address is reported see it
+	s.setAddr(ln.Addr())
+	defer s.setAddr(nil)
+
+	logrus.Infof("%s server listening on %s", s.listener, utils.FormatAddr(ln.Addr()))
+
+	if server.TLSConfig != nil {
+		return server.ServeTLS(ln, "", "")
 	}
+	return server.Serve(ln)
+}
 
-	return s.server.ListenAndServe()
+// disableKeepAlives makes HTTP/1 responses close their connection and HTTP/2
+// connections send GOAWAY after their next request, and closes idle
+// connections, so clients reconnect elsewhere
+func (s *HTTPServer) disableKeepAlives() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.server != nil {
+		s.server.SetKeepAlivesEnabled(false)
+	}
 }
 
 // Shutdown gracefully shuts down the HTTP server
 func (s *HTTPServer) Shutdown(ctx context.Context) error {
-	if s.server == nil {
+	s.mu.Lock()
+	s.stopped = true
+	server := s.server
+	s.mu.Unlock()
+	if server == nil {
 		return nil
 	}
-	return s.server.Shutdown(ctx)
+	return server.Shutdown(ctx)
 }
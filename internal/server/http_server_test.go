@@ -323,6 +323,10 @@ func TestHTTPServer_ShutdownWithoutStart(t *testing.T) {
 
 	err := server.Shutdown(shutdownCtx)
 	assert.NoError(t, err)
+
+	// A Start racing the shutdown must not serve
+	assert.NoError(t, server.Start(context.Background()))
+	assert.Nil(t, server.Addr())
 }
 
 func TestHTTPServer_ActiveConnectionTracking(t *testing.T) {
@@ -364,6 +368,25 @@ func TestHTTPServer_ActiveConnectionTracking(t *testing.T) {
 	cancel()
 }
 
+func TestHTTPServer_OpenConnections(t *testing.T) {
+	server := NewHTTPServer(&config.Config{HTTPAddress: "127.0.0.1:0"}, false)
+	go func() { _ = server.Start(context.Background()) }()
+	defer func() { _ = server.Shutdown(context.Background()) }()
+	require.Eventually(t, func() bool { return server.Addr() != nil }, 2*time.Second, 10*time.Millisecond)
+
+	// Idle keep-alive connections count until they close
+	client := &http.Client{Transport: &http.Transport{}}
+	resp, err := client.Get("http://" + server.Addr().String() + "/")
+	require.NoError(t, err)
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+	assert.Equal(t, 1, server.activeConnections())
+	assert.Equal(t, int32(0), atomic.LoadInt32(&server.activeConns), "no request in flight")
+
+	client.CloseIdleConnections()
+	assert.Eventually(t, func() bool { return server.activeConnections() == 0 }, 2*time.Second, 10*time.Millisecond)
+}
+
 func TestH2CServer_HTTP2Negotiation(t *testing.T) {
 	cfg := &config.Config{
 		HTTPPort: "18085",
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/PhilipSchmid/echo-app/internal/utils"
+)
+
+// listen opens a stream listener on address, which is host:port or
+// unix:///path. A stale Unix socket left by a previous run is removed, and
+// mode, if set, is applied to new sockets.
+func listen(address string, mode os.FileMode) (net.Listener, error) {
+	network, addr, err := utils.ParseAddress(address)
+	if err != nil {
+		return nil, err
+	}
+	if network == "unix" {
+		if err := removeStaleSocket(addr); err != nil {
+			return nil, fmt.Errorf("failed to listen on %s: %w", address, err)
+		}
+	}
+
+	ln, err := net.Listen(network, addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", address, err)
+	}
+	if network == "unix" && mode != 0 {
+		if err := os.Chmod(addr, mode); err != nil {
+			_ = ln.Close()
+			return nil, fmt.Errorf("failed to set permissions of %s: %w", addr, err)
+		}
+	}
+	return ln, nil
+}
+
+// removeStaleSocket removes the socket at path unless another process still
+// accepts connections on it. Other kinds of files are left alone.
+func removeStaleSocket(path string) error {
+	info, err := os.Lstat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.Mode().Type() != fs.ModeSocket {
+		return fmt.Errorf("%s exists and is not a socket", path)
+	}
+	if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+		_ = conn.Close()
+		return fmt.Errorf("%s is in use", path)
+	}
+	return os.Remove(path)
+}
+
+// boundAddr records the address a server is listening on, so servers bound to
+// an ephemeral port can report the port picked
+type boundAddr struct {
+	mu   sync.RWMutex
+	addr net.Addr
+}
+
+// Addr returns the address the server is listening on, or nil if it is not
+// listening
+func (b *boundAddr) Addr() net.Addr {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.addr
+}
+
+func (b *boundAddr) setAddr(addr net.Addr) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.addr = addr
+}
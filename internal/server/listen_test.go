@@ -0,0 +1,138 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/PhilipSchmid/echo-app/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListen_UnixSocket(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name          string
+		setup         func(t *testing.T, path string)
+		mode          os.FileMode
+		expectedMode  os.FileMode
+		expectedError string
+	}{
+		{name: "new socket", mode: 0o660, expectedMode: 0o660},
+		{
+			name: "stale socket",
+			setup: func(t *testing.T, path string) {
+				ln, err := net.Listen("unix", path)
+				require.NoError(t, err)
+				// Keep the socket file behind, as a crashed process would
+				ln.(*net.UnixListener).SetUnlinkOnClose(false)
+				require.NoError(t, ln.Close())
+			},
+			mode:         0o600,
+			expectedMode: 0o600,
+		},
+		{
+			name: "socket in use",
+			setup: func(t *testing.T, path string) {
+				ln, err := net.Listen("unix", path)
+				require.NoError(t, err)
+				t.Cleanup(func() { _ = ln.Close() })
+			},
+			expectedError: "is in use",
+		},
+		{
+			name: "regular file",
+			setup: func(t *testing.T, path string) {
+				require.NoError(t, os.WriteFile(path, nil, 0o600))
+			},
+			expectedError: "exists and is not a socket",
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, string(rune('a'+i))+".sock")
+			if tt.setup != nil {
+				tt.setup(t, path)
+			}
+			ln, err := listen("unix://"+path, tt.mode)
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			defer func() { _ = ln.Close() }()
+			info, err := os.Stat(path)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedMode, info.Mode().Perm())
+		})
+	}
+}
+
+func TestListen_InvalidAddress(t *testing.T) {
+	_, err := listen("unix://relative.sock", 0)
+	assert.ErrorContains(t, err, "unix socket path must be absolute")
+}
+
+func TestHTTPServer_UnixSocket(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "http.sock")
+	cfg := &config.Config{HTTPAddress: "unix://" + socket, Message: "unix"}
+	server := NewHTTPServer(cfg, false)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = server.Start(ctx) }()
+	defer func() { _ = server.Shutdown(context.Background()) }()
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socket)
+		},
+	}}
+	var resp *http.Response
+	require.Eventually(t, func() bool {
+		var err error
+		resp, err = client.Get("http://localhost/")
+		return err == nil
+	}, 2*time.Second, 20*time.Millisecond)
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	var response map[string]any
+	require.NoError(t, json.Unmarshal(body, &response))
+	assert.Equal(t, "unix", response["message"])
+	assert.Equal(t, "unix://"+socket, response["local_address"])
+}
+
+func TestTCPServer_LoopbackAddress(t *testing.T) {
+	cfg := &config.Config{TCPAddress: "127.0.0.1:19099", TCPFormat: "json"}
+	server := NewTCPServer(cfg)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = server.Start(ctx) }()
+	defer func() { _ = server.Shutdown(context.Background()) }()
+
+	var conn net.Conn
+	require.Eventually(t, func() bool {
+		var err error
+		conn, err = net.Dial("tcp", "127.0.0.1:19099")
+		return err == nil
+	}, 2*time.Second, 20*time.Millisecond)
+	defer func() { _ = conn.Close() }()
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(2*time.Second)))
+	body, err := io.ReadAll(conn)
+	require.NoError(t, err)
+	var response map[string]any
+	require.NoError(t, json.Unmarshal(body, &response))
+	assert.Equal(t, "127.0.0.1:19099", response["local_address"])
+}
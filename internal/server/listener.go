@@ -0,0 +1,55 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/PhilipSchmid/echo-app/internal/config"
+)
+
+// namedServer reports the configured listener name instead of the protocol
+type namedServer struct {
+	Server
+	name string
+}
+
+// Name returns the listener name
+func (s namedServer) Name() string {
+	return s.name
+}
+
+// NewListener creates the server for a configured listener, applying the
+// listener's settings on top of cfg
+func NewListener(cfg *config.Config, l config.Listener) (Server, error) {
+	return newListener(cfg.ForListener(l), l)
+}
+
+// newListener creates the server for l from its per-listener configuration
+func newListener(lc *config.Config, l config.Listener) (Server, error) {
+	var srv Server
+	switch l.Protocol {
+	case config.ListenerHTTP:
+		srv = NewHTTPServer(lc, false)
+	case config.ListenerTLS:
+		srv = NewHTTPServer(lc, true)
+	case config.ListenerTCP:
+		srv = NewTCPServer(lc)
+	case config.ListenerGRPC:
+		srv = NewGRPCServer(lc)
+	case config.ListenerQUIC:
+		srv = NewQUICServer(lc)
+	default:
+		return nil, fmt.Errorf("listener %s: invalid protocol %q", l.Name, l.Protocol)
+	}
+
+	if l.Name == "" || l.Name == srv.Name() {
+		return srv, nil
+	}
+	return namedServer{Server: srv, name: l.Name}, nil
+}
+
+// sameAddress reports whether a and b can be served by the same running
+// server, as only the settings read per request may change in place
+func sameAddress(a, b config.Listener) bool {
+	return a.Protocol == b.Protocol && a.Port == b.Port && a.Address == b.Address &&
+		a.SocketMode == b.SocketMode && a.H2C == b.H2C && a.TLS == b.TLS && a.WebTransport == b.WebTransport
+}
@@ -0,0 +1,162 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/PhilipSchmid/echo-app/internal/config"
+	pb "github.com/PhilipSchmid/echo-app/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+func TestNewListener_Names(t *testing.T) {
+	cfg := &config.Config{Message: "global"}
+	tests := []struct {
+		listener     config.Listener
+		expectedName string
+	}{
+		{listener: config.Listener{Name: "HTTP", Protocol: config.ListenerHTTP, Port: "8080"}, expectedName: "HTTP"},
+		{listener: config.Listener{Name: "public", Protocol: config.ListenerHTTP, Port: "8080"}, expectedName: "public"},
+		{listener: config.Listener{Name: "secure", Protocol: config.ListenerTLS, Port: "8443"}, expectedName: "secure"},
+		{listener: config.Listener{Name: "raw", Protocol: config.ListenerTCP, Port: "9090"}, expectedName: "raw"},
+		{listener: config.Listener{Name: "grpc-tls", Protocol: config.ListenerGRPC, Port: "50051", TLS: true}, expectedName: "grpc-tls"},
+		{listener: config.Listener{Name: "QUIC", Protocol: config.ListenerQUIC, Port: "4433"}, expectedName: "QUIC"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expectedName, func(t *testing.T) {
+			srv, err := NewListener(cfg, tt.listener)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedName, srv.Name())
+		})
+	}
+
+	_, err := NewListener(cfg, config.Listener{Name: "mail", Protocol: "smtp", Port: "25"})
+	assert.EqualError(t, err, `listener mail: invalid protocol "smtp"`)
+}
+
+func TestNewListener_PerListenerSettings(t *testing.T) {
+	cfg := &config.Config{Message: "global"}
+	manager := NewManager(cfg, nil)
+	for _, l := range []config.Listener{
+		{Name: "public", Protocol: config.ListenerHTTP, Port: "18087"},
+		{Name: "internal", Protocol: config.ListenerHTTP, Port: "18088", Message: "internal"},
+		{Name: "grpc-tls", Protocol: config.ListenerGRPC, Port: "19098", TLS: true, Message: "secure"},
+	} {
+		srv, err := NewListener(cfg, l)
+		require.NoError(t, err)
+		manager.RegisterServer(srv)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, manager.Start(ctx))
+	defer func() { _ = manager.Shutdown(5 * time.Second) }()
+
+	for url, expected := range map[string]string{
+		"http://localhost:18087/": "global",
+		"http://localhost:18088/": "internal",
+	} {
+		resp := getWithRetry(t, http.DefaultClient, url)
+		var body struct {
+			Message string `json:"message"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		_ = resp.Body.Close()
+		assert.Equal(t, expected, body.Message, url)
+	}
+
+	conn, err := grpc.NewClient("localhost:19098", grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{
+		InsecureSkipVerify: true, // #nosec G402 -- self-signed test certificate
+	})))
+	require.NoError(t, err)
+	defer func() { _ = conn.Close() }()
+	callCtx, callCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer callCancel()
+	resp, err := pb.NewEchoServiceClient(conn).Echo(callCtx, &pb.EchoRequest{}, grpc.WaitForReady(true))
+	require.NoError(t, err)
+	assert.Equal(t, "secure", resp.GetMessage())
+}
+
+func TestManager_Reload(t *testing.T) {
+	message := func(t *testing.T, client *http.Client, url string) string {
+		t.Helper()
+		resp := getWithRetry(t, client, url)
+		defer func() { _ = resp.Body.Close() }()
+		var body struct {
+			Message string `json:"message"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		return body.Message
+	}
+
+	cfg := &config.Config{Message: "v1", Listeners: []config.Listener{
+		{Name: "public", Protocol: config.ListenerHTTP, Port: "18089"},
+		{Name: "internal", Protocol: config.ListenerHTTP, Port: "18090"},
+		{Name: "legacy", Protocol: config.ListenerTCP, Port: "13013"},
+	}}
+	manager := NewManager(cfg, nil)
+	for _, l := range cfg.Listeners {
+		require.NoError(t, manager.RegisterListener(cfg, l))
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, manager.Start(ctx))
+	defer func() { _ = manager.Shutdown(5 * time.Second) }()
+
+	// Keep a connection open to the listener updated in place
+	client := &http.Client{Transport: &http.Transport{}}
+	assert.Equal(t, "v1", message(t, client, "http://localhost:18089/"))
+	assert.Equal(t, "v1", message(t, http.DefaultClient, "http://localhost:18090/"))
+	public := manager.listeners[0].server
+
+	next := &config.Config{Message: "v2", Listeners: []config.Listener{
+		{Name: "public", Protocol: config.ListenerHTTP, Port: "18089"},
+		{Name: "internal", Protocol: config.ListenerHTTP, Port: "18091", Message: "moved"},
+		{Name: "added", Protocol: config.ListenerHTTP, Port: "18092"},
+	}}
+	applied, err := manager.Reload(next)
+	require.NoError(t, err)
+	assert.Same(t, next, applied)
+
+	// Settings read per request apply to the running server
+	assert.True(t, public == manager.listeners[0].server, "public listener was restarted")
+	assert.Equal(t, "v2", message(t, client, "http://localhost:18089/"))
+
+	// Listeners whose address changed are restarted, removed ones stopped
+	assert.Equal(t, "moved", message(t, http.DefaultClient, "http://localhost:18091/"))
+	assert.Equal(t, "v2", message(t, http.DefaultClient, "http://localhost:18092/"))
+	for _, addr := range []string{"localhost:18090", "localhost:13013"} {
+		require.Eventually(t, func() bool {
+			conn, err := net.DialTimeout("tcp", addr, time.Second)
+			if err != nil {
+				return true
+			}
+			_ = conn.Close()
+			return false
+		}, 5*time.Second, 20*time.Millisecond, addr)
+	}
+
+	names := make([]string, 0, len(manager.servers))
+	for _, srv := range manager.servers {
+		names = append(names, srv.Name())
+	}
+	assert.ElementsMatch(t, []string{"public", "internal", "added"}, names)
+
+	// A rejected configuration leaves the current one in effect
+	invalid := &config.Config{Message: "v3", Listeners: []config.Listener{
+		{Name: "public", Protocol: config.ListenerHTTP, Port: "18089"},
+		{Name: "broken", Protocol: "smtp", Port: "2525"},
+	}}
+	applied, err = manager.Reload(invalid)
+	assert.ErrorContains(t, err, `listener broken: invalid protocol "smtp"`)
+	assert.Same(t, next, applied)
+	assert.Equal(t, "v2", message(t, client, "http://localhost:18089/"))
+}
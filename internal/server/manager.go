@@ -2,23 +2,76 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"slices"
 	"sync"
 	"time"
 
 	"github.com/PhilipSchmid/echo-app/internal/config"
 	"github.com/PhilipSchmid/echo-app/internal/health"
+	"github.com/PhilipSchmid/echo-app/internal/metrics"
 	"github.com/sirupsen/logrus"
 )
 
+const (
+	// reloadShutdownTimeout bounds how long a listener replaced by a reload
+	// may take to drain
+	reloadShutdownTimeout = 30 * time.Second
+	// startupTimeout bounds how long Start waits for the servers to bind
+	startupTimeout = 30 * time.Second
+)
+
+// Delays before the restart failure policy restarts a failed server, doubling
+// from restartBackoffMin with every consecutive failure up to
+// restartBackoffMax. Variables so tests can shorten them.
+var (
+	restartBackoffMin = time.Second
+	restartBackoffMax = 30 * time.Second
+)
+
 // Manager manages all servers and handles graceful shutdown
 type Manager struct {
-	cfg      *config.Config
-	health   *health.Checker
-	servers  []Server
-	wg       sync.WaitGroup
-	shutdown chan struct{}
+	cfg       *config.Config
+	health    *health.Checker
+	mu        sync.Mutex // Guards servers, listeners and ctx against reloads, never held while a server shuts down
+	changeMu  sync.Mutex // Serializes reloads and starting and stopping listeners through the admin API
+	servers   []Server
+	states    map[Server]*serverState // Launched servers
+	listeners []*managedListener
+	ctx       context.Context // Context passed to Start, nil before
+	startedAt time.Time       // When Start was called
+	started   bool            // Start has collected the startup failures
+	wg        sync.WaitGroup
+	shutdown  chan struct{}
+	failed    chan error // Receives a server failure under the exit policy
+}
+
+// serverState tracks a launched server
+type serverState struct {
+	stopped    chan struct{} // Closed when the server's Start returns
+	status     string        // One of the Listener states
+	since      time.Time     // When status last changed
+	err        error         // Why the server failed, nil while it runs
+	launched   time.Time     // When the server was last launched
+	failures   int           // Consecutive failures, lengthening the restart backoff
+	restarts   int           // Restarts by the restart failure policy
+	adminStart bool          // Started through the admin API and not bound yet
+}
+
+// setStatus moves the server to status
+func (s *serverState) setStatus(status string) {
+	s.status, s.since = status, time.Now()
+}
+
+// managedListener is a server registered for a configured listener, which a
+// reload may update in place, restart or remove
+type managedListener struct {
+	listener config.Listener
+	cfg      *config.Config // Per-listener settings the server's handlers read
+	server   Server
 }
 
 // Server interface for all server types
@@ -26,6 +79,9 @@ type Server interface {
 	Start(ctx context.Context) error
 	Shutdown(ctx context.Context) error
 	Name() string
+	// Addr returns the address the server is listening on, or nil if it is
+	// not listening
+	Addr() net.Addr
 }
 
 // NewManager creates a new server manager
@@ -34,37 +90,544 @@ func NewManager(cfg *config.Config, healthChecker *health.Checker) *Manager {
 		cfg:      cfg,
 		health:   healthChecker,
 		servers:  make([]Server, 0),
+		states:   make(map[Server]*serverState),
 		shutdown: make(chan struct{}),
+		failed:   make(chan error, 1),
 	}
 }
 
 // RegisterServer adds a server to be managed
 func (m *Manager) RegisterServer(s Server) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.servers = append(m.servers, s)
 }
 
-// Start starts all registered servers
+// RegisterListener creates and adds the server for a configured listener,
+// which Reload can later update
+func (m *Manager) RegisterListener(cfg *config.Config, l config.Listener) error {
+	ml, err := newManagedListener(cfg, l)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.servers = append(m.servers, ml.server)
+	m.listeners = append(m.listeners, ml)
+	return nil
+}
+
+func newManagedListener(cfg *config.Config, l config.Listener) (*managedListener, error) {
+	lc := cfg.ForListener(l)
+	srv, err := newListener(lc, l)
+	if err != nil {
+		return nil, err
+	}
+	return &managedListener{listener: l, cfg: lc, server: srv}, nil
+}
+
+// Start starts all registered servers and waits until each has bound its
+// address or failed, returning every startup failure joined into one error.
+// The servers that started keep running; the caller decides whether to shut
+// them down. Servers failing later are handled by the listener failure
+// policy.
 func (m *Manager) Start(ctx context.Context) error {
+	m.mu.Lock()
+	m.ctx, m.startedAt = ctx, time.Now()
 	for _, srv := range m.servers {
-		srv := srv // capture loop variable
+		m.launch(srv)
+	}
+	m.mu.Unlock()
+
+	waitCtx, cancel := context.WithTimeout(ctx, startupTimeout)
+	defer cancel()
+	m.Addresses(waitCtx)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.started = true
+	var errs []error
+	for _, srv := range m.servers {
+		state := m.states[srv]
+		switch {
+		case state.err != nil:
+			errs = append(errs, fmt.Errorf("failed to start %s server: %w", srv.Name(), state.err))
+		case srv.Addr() == nil && !isClosed(state.stopped):
+			errs = append(errs, fmt.Errorf("%s server did not start listening: %w", srv.Name(), waitCtx.Err()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Failed returns a channel receiving the first server failure after Start
+// when the listener failure policy is exit
+func (m *Manager) Failed() <-chan error {
+	return m.failed
+}
+
+// launch runs srv until it stops. m.mu must be held.
+func (m *Manager) launch(srv Server) {
+	state := m.states[srv]
+	if state == nil {
+		state = &serverState{}
+		m.states[srv] = state
+	}
+	stopped := make(chan struct{})
+	state.stopped, state.err, state.launched = stopped, nil, time.Now()
+	state.setStatus(ListenerStarting)
+
+	ctx := m.ctx
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		logrus.Infof("Starting %s server...", srv.Name())
+		err := srv.Start(ctx)
+		m.serverStopped(srv, stopped, err)
+	}()
+	go m.watchBind(srv, stopped)
+}
+
+// watchBind marks srv as serving once it has bound its address
+func (m *Manager) watchBind(srv Server, stopped chan struct{}) {
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	for srv.Addr() == nil {
+		select {
+		case <-stopped:
+			return
+		case <-ticker.C:
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	// The server may have failed, stopped or been replaced meanwhile
+	state := m.states[srv]
+	if state == nil || state.stopped != stopped || state.status != ListenerStarting {
+		return
+	}
+	state.setStatus(ListenerRunning)
+	state.adminStart = false
+	if m.health != nil {
+		m.health.SetListenerRunning(srv.Name())
+	}
+	metrics.ListenerServing(srv.Name())
+}
+
+// serverStopped handles srv's Start returning err. Servers stop cleanly when
+// shut down, removed by a reload or stopped through the admin API; any other
+// stop is a failure.
+func (m *Manager) serverStopped(srv Server, stopped chan struct{}, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	defer close(stopped)
+	state := m.states[srv]
+	if state == nil || state.stopped != stopped || state.status == ListenerStopped || m.stopping() {
+		return
+	}
+
+	if err == nil || errors.Is(err, http.ErrServerClosed) {
+		err = errors.New("stopped unexpectedly")
+	}
+	state.err = err
+	state.setStatus(ListenerFailed)
+	if m.health != nil {
+		m.health.SetListenerFailed(srv.Name(), err)
+	}
+	metrics.ListenerFailed(srv.Name())
+	// Start reports startup failures before it returns, and StartListener
+	// reports the servers it failed to start
+	if m.started {
+		logrus.Errorf("%s server error: %v", srv.Name(), err)
+		if !state.adminStart {
+			m.applyFailurePolicy(srv, state)
+		}
+	}
+}
+
+// applyFailurePolicy handles srv failing after Start. m.mu must be held.
+func (m *Manager) applyFailurePolicy(srv Server, state *serverState) {
+	switch m.cfg.ListenerFailurePolicy {
+	case config.FailurePolicyRestart:
+		// A server that ran for a while restarts without the backoff built
+		// up by earlier failures
+		if time.Since(state.launched) >= restartBackoffMax {
+			state.failures = 0
+		}
+		delay := restartDelay(state.failures)
+		state.failures++
+		logrus.Warnf("Restarting %s server in %v", srv.Name(), delay)
 		m.wg.Add(1)
-		go func() {
-			defer m.wg.Done()
-			logrus.Infof("Starting %s server...", srv.Name())
-			if err := srv.Start(ctx); err != nil && err != http.ErrServerClosed {
-				logrus.Errorf("%s server error: %v", srv.Name(), err)
-			}
-		}()
+		go m.restart(srv, state.stopped, delay)
+	case config.FailurePolicyDegrade:
+		logrus.Warnf("Continuing without the %s server", srv.Name())
+	default:
+		select {
+		case m.failed <- fmt.Errorf("%s server failed: %w", srv.Name(), state.err):
+		default: // An earlier failure is already shutting everything down
+		}
+	}
+}
+
+// restartDelay returns the backoff before restarting a server that failed
+// failures times in a row before
+func restartDelay(failures int) time.Duration {
+	delay := restartBackoffMin
+	for i := 0; i < failures && delay < restartBackoffMax; i++ {
+		delay *= 2
+	}
+	return min(delay, restartBackoffMax)
+}
+
+// restart relaunches the failed srv after delay, unless the servers shut
+// down or a reload replaced it meanwhile
+func (m *Manager) restart(srv Server, stopped chan struct{}, delay time.Duration) {
+	defer m.wg.Done()
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-m.shutdown:
+		return
+	case <-m.ctx.Done():
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	// A reload or the admin API may have replaced, stopped or started the
+	// server meanwhile
+	state := m.states[srv]
+	if state == nil || state.stopped != stopped || state.status != ListenerFailed || m.stopping() {
+		return
+	}
+	state.restarts++
+	metrics.ListenerRestarted(srv.Name())
+	m.launch(srv)
+}
+
+// stopping reports whether the servers are shutting down. m.mu must be held.
+func (m *Manager) stopping() bool {
+	select {
+	case <-m.shutdown:
+		return true
+	default:
+		return m.ctx.Err() != nil
+	}
+}
+
+// isClosed reports whether ch is closed
+func isClosed(ch chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
+
+// Reload applies cfg to the registered listeners, matched by name. Listeners
+// whose protocol and address are unchanged switch to the new settings in
+// place without dropping connections; the others are restarted. Listeners
+// missing from cfg are stopped and new ones started. It returns the
+// configuration in effect afterwards: cfg once adopted, even if stopping a
+// listener failed, or the previous configuration if cfg was rejected.
+func (m *Manager) Reload(cfg *config.Config) (*config.Config, error) {
+	m.changeMu.Lock()
+	defer m.changeMu.Unlock()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if isClosed(m.shutdown) {
+		return m.cfg, fmt.Errorf("servers are shutting down")
+	}
+
+	// Build every new server first so an invalid listener changes nothing
+	current := make(map[string]*managedListener, len(m.listeners))
+	for _, ml := range m.listeners {
+		current[ml.listener.Name] = ml
+	}
+	listeners := make([]*managedListener, 0, len(cfg.Listeners))
+	var added []*managedListener
+	kept := make(map[*managedListener]config.Listener)
+	for _, l := range cfg.Listeners {
+		if ml, ok := current[l.Name]; ok && sameAddress(ml.listener, l) {
+			delete(current, l.Name)
+			listeners = append(listeners, ml)
+			kept[ml] = l
+			continue
+		}
+		ml, err := newManagedListener(cfg, l)
+		if err != nil {
+			return m.cfg, err
+		}
+		listeners = append(listeners, ml)
+		added = append(added, ml)
+	}
+
+	// Stop replaced and removed listeners before starting new ones, which
+	// may bind the same ports. They are removed first so stopping is not
+	// taken for a failure, and shut down without holding m.mu so the status
+	// endpoints keep responding meanwhile.
+	stopping := make([]Server, 0, len(current))
+	for _, ml := range current {
+		m.removeServer(ml.server)
+		stopping = append(stopping, ml.server)
+	}
+	m.mu.Unlock()
+	var errs []error
+	for _, srv := range stopping {
+		logrus.Infof("Stopping %s server for reload...", srv.Name())
+		ctx, cancel := context.WithTimeout(context.Background(), reloadShutdownTimeout)
+		if err := srv.Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("%s shutdown error: %w", srv.Name(), err))
+		}
+		cancel()
+	}
+	m.mu.Lock()
+	if isClosed(m.shutdown) {
+		return m.cfg, fmt.Errorf("servers are shutting down")
+	}
+
+	for ml, l := range kept {
+		ml.listener = l
+		ml.cfg.Update(cfg.ForListener(l))
+	}
+	for _, ml := range added {
+		m.servers = append(m.servers, ml.server)
+		if m.ctx != nil {
+			m.launch(ml.server)
+		}
+	}
+	m.listeners = listeners
+	m.cfg = cfg
+
+	if len(errs) > 0 {
+		return cfg, fmt.Errorf("reload errors: %v", errs)
+	}
+	return cfg, nil
+}
+
+// removeServer drops srv from the managed servers, along with its status.
+// m.mu must be held.
+func (m *Manager) removeServer(srv Server) {
+	m.servers = slices.DeleteFunc(m.servers, func(s Server) bool { return s == srv })
+	delete(m.states, srv)
+	if m.health != nil {
+		m.health.SetListenerRunning(srv.Name())
+	}
+	metrics.ListenerRemoved(srv.Name())
+}
+
+// Listeners returns the status of every server, in registration order
+func (m *Manager) Listeners() []ListenerStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	statuses := make([]ListenerStatus, 0, len(m.servers))
+	for _, srv := range m.servers {
+		statuses = append(statuses, m.status(srv))
+	}
+	return statuses
+}
+
+// Listener returns the status of the server name
+func (m *Manager) Listener(name string) (ListenerStatus, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, srv := range m.servers {
+		if srv.Name() == name {
+			return m.status(srv), nil
+		}
+	}
+	return ListenerStatus{}, fmt.Errorf("%w: %s", ErrListenerNotFound, name)
+}
+
+// status returns the status of srv. m.mu must be held.
+func (m *Manager) status(srv Server) ListenerStatus {
+	status := ListenerStatus{Name: srv.Name(), State: ListenerStopped}
+	if ml := m.managedListener(srv.Name()); ml != nil && ml.server == srv {
+		status.Protocol = ml.listener.Protocol
+	} else if _, ok := srv.(*MetricsServer); ok {
+		status.Protocol = "metrics"
+	}
+	if addr := srv.Addr(); addr != nil {
+		bound := newBoundAddress(srv.Name(), addr)
+		status.Network, status.Address, status.Port = bound.Network, bound.Address, bound.Port
+	}
+	if counter, ok := unwrap(srv).(connectionCounter); ok {
+		conns := counter.activeConnections()
+		status.ActiveConnections = &conns
+	}
+	if state := m.states[srv]; state != nil {
+		status.State, status.Since, status.Restarts = state.status, state.since, state.restarts
+		if state.err != nil {
+			status.Error = state.err.Error()
+		}
+	}
+	return status
+}
+
+// managedListener returns the configured listener name, or nil. m.mu must be
+// held.
+func (m *Manager) managedListener(name string) *managedListener {
+	for _, ml := range m.listeners {
+		if ml.listener.Name == name {
+			return ml
+		}
 	}
 	return nil
 }
 
-// Shutdown gracefully shuts down all servers
+// StopListener gracefully stops the configured listener name until
+// StartListener starts it again. Stopping a listener is not a failure, so it
+// leaves readiness alone and the failure policy does not apply.
+func (m *Manager) StopListener(ctx context.Context, name string) (ListenerStatus, error) {
+	m.changeMu.Lock()
+	defer m.changeMu.Unlock()
+	m.mu.Lock()
+	ml := m.managedListener(name)
+	if ml == nil {
+		m.mu.Unlock()
+		return ListenerStatus{}, fmt.Errorf("%w: %s", ErrListenerNotFound, name)
+	}
+	srv := ml.server
+	state := m.states[srv]
+	if state == nil || state.status == ListenerStopped {
+		status := m.status(srv)
+		m.mu.Unlock()
+		return status, fmt.Errorf("%w: %s is already stopped", ErrListenerState, name)
+	}
+
+	// Marked stopped first so the server stopping is not taken for a failure
+	state.setStatus(ListenerStopped)
+	state.err = nil
+	if m.health != nil {
+		m.health.SetListenerRunning(name)
+	}
+	metrics.ListenerStopped(name)
+	m.mu.Unlock()
+
+	logrus.Infof("Stopping %s server through the admin API...", name)
+	ctx, cancel := context.WithTimeout(ctx, reloadShutdownTimeout)
+	defer cancel()
+	err := srv.Shutdown(ctx)
+	m.mu.Lock()
+	status := m.status(srv)
+	m.mu.Unlock()
+	if err != nil {
+		return status, fmt.Errorf("%s shutdown error: %w", name, err)
+	}
+	return status, nil
+}
+
+// StartListener starts the configured listener name if it is stopped or
+// failed, and waits until it is listening or ctx is done. Unlike listeners
+// failing at runtime, a listener failing to start here is only reported.
+func (m *Manager) StartListener(ctx context.Context, name string) (ListenerStatus, error) {
+	m.changeMu.Lock()
+	defer m.changeMu.Unlock()
+	m.mu.Lock()
+	if m.ctx == nil || m.stopping() {
+		m.mu.Unlock()
+		return ListenerStatus{}, fmt.Errorf("%w: servers are not running", ErrListenerState)
+	}
+	ml := m.managedListener(name)
+	if ml == nil {
+		m.mu.Unlock()
+		return ListenerStatus{}, fmt.Errorf("%w: %s", ErrListenerNotFound, name)
+	}
+	if state := m.states[ml.server]; state != nil && state.status != ListenerStopped && state.status != ListenerFailed {
+		status := m.status(ml.server)
+		m.mu.Unlock()
+		return status, fmt.Errorf("%w: %s is already %s", ErrListenerState, name, status.State)
+	}
+
+	// Servers cannot all be started again once shut down, so a fresh one
+	// replaces the stopped server
+	fresh, err := newManagedListener(m.cfg, ml.listener)
+	if err != nil {
+		m.mu.Unlock()
+		return ListenerStatus{}, err
+	}
+	m.servers[slices.Index(m.servers, ml.server)] = fresh.server
+	delete(m.states, ml.server)
+	ml.server, ml.cfg = fresh.server, fresh.cfg
+	logrus.Infof("Starting %s server through the admin API...", name)
+	m.launch(fresh.server)
+	m.states[fresh.server].adminStart = true
+	m.mu.Unlock()
+
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		m.mu.Lock()
+		status := m.status(fresh.server)
+		m.mu.Unlock()
+		switch status.State {
+		case ListenerStarting:
+		case ListenerFailed:
+			return status, fmt.Errorf("failed to start %s server: %s", name, status.Error)
+		default:
+			return status, nil
+		}
+		select {
+		case <-ctx.Done():
+			return status, fmt.Errorf("%s server did not start listening: %w", name, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// Addresses returns the addresses the servers are listening on, in
+// registration order. It waits until every server has bound its address or
+// stopped, or until ctx is done, and leaves out servers that are not
+// listening.
+func (m *Manager) Addresses(ctx context.Context) []BoundAddress {
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		addrs, pending := m.addresses()
+		if !pending {
+			return addrs
+		}
+		select {
+		case <-ctx.Done():
+			return addrs
+		case <-ticker.C:
+		}
+	}
+}
+
+// addresses returns the bound addresses and whether a running server has not
+// bound its address yet
+func (m *Manager) addresses() ([]BoundAddress, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var addrs []BoundAddress
+	pending := false
+	for _, srv := range m.servers {
+		if addr := srv.Addr(); addr != nil {
+			addrs = append(addrs, newBoundAddress(srv.Name(), addr))
+			continue
+		}
+		if state, launched := m.states[srv]; launched && !isClosed(state.stopped) {
+			pending = true
+		}
+	}
+	return addrs, pending
+}
+
+// Shutdown gracefully shuts down all servers. It may be called again, such
+// as after Drain.
 func (m *Manager) Shutdown(timeout time.Duration) error {
 	if m.health != nil {
 		m.health.SetReady(false, "shutting down")
 	}
-	close(m.shutdown)
+	m.mu.Lock()
+	if !isClosed(m.shutdown) {
+		close(m.shutdown)
+	}
+	servers := slices.Clone(m.servers)
+	m.mu.Unlock()
 
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
@@ -72,9 +635,9 @@ func (m *Manager) Shutdown(timeout time.Duration) error {
 	logrus.Info("Shutting down all servers...")
 
 	var shutdownWg sync.WaitGroup
-	errors := make(chan error, len(m.servers))
+	errors := make(chan error, len(servers))
 
-	for _, srv := range m.servers {
+	for _, srv := range servers {
 		srv := srv // capture loop variable
 		shutdownWg.Add(1)
 		go func() {
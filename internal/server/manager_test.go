@@ -3,12 +3,18 @@ package server
 import (
 	"context"
 	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/PhilipSchmid/echo-app/internal/config"
+	"github.com/PhilipSchmid/echo-app/internal/health"
+	"github.com/PhilipSchmid/echo-app/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -23,6 +29,8 @@ type mockServer struct {
 	startError     error
 	shutdownError  error
 	blockStart     bool
+	bound          atomic.Bool // Whether Start is running past startError
+	failCh         chan error  // Makes a blocked Start return the error sent
 	shutdownCh     chan struct{}
 	shutdownOnce   sync.Once
 	ctx            context.Context
@@ -33,6 +41,7 @@ func newMockServer(name string) *mockServer {
 	ctx, cancel := context.WithCancel(context.Background())
 	return &mockServer{
 		name:       name,
+		failCh:     make(chan error),
 		shutdownCh: make(chan struct{}),
 		ctx:        ctx,
 		cancel:     cancel,
@@ -45,9 +54,15 @@ func (m *mockServer) Start(ctx context.Context) error {
 	if m.startDelay > 0 {
 		time.Sleep(m.startDelay)
 	}
+	if m.startError != nil {
+		return m.startError
+	}
+	m.bound.Store(true)
+	defer m.bound.Store(false)
 
 	if m.blockStart {
-		// Block until shutdown is called or context is cancelled
+		// Block until shutdown is called, the context is cancelled or the
+		// test makes the server fail
 		select {
 		case <-m.shutdownCh:
 			return nil
@@ -55,10 +70,11 @@ func (m *mockServer) Start(ctx context.Context) error {
 			return ctx.Err()
 		case <-m.ctx.Done():
 			return nil
+		case err := <-m.failCh:
+			return err
 		}
 	}
-
-	return m.startError
+	return nil
 }
 
 func (m *mockServer) Shutdown(ctx context.Context) error {
@@ -81,6 +97,13 @@ func (m *mockServer) Name() string {
 	return m.name
 }
 
+func (m *mockServer) Addr() net.Addr {
+	if !m.bound.Load() {
+		return nil
+	}
+	return &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 8080}
+}
+
 func TestNewManager(t *testing.T) {
 	cfg := &config.Config{}
 	manager := NewManager(cfg, nil)
@@ -229,12 +252,12 @@ func TestManager_Wait(t *testing.T) {
 
 	manager.RegisterServer(srv)
 
-	// Start server
+	// Start server, which stops right after binding
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
 	err := manager.Start(ctx)
-	require.NoError(t, err)
+	require.EqualError(t, err, "failed to start server server: stopped unexpectedly")
 
 	// Wait for all servers to complete
 	done := make(chan struct{})
@@ -371,3 +394,114 @@ func TestManager_ConcurrentShutdown(t *testing.T) {
 
 	cancel()
 }
+
+func TestManager_StartReportsEveryFailure(t *testing.T) {
+	checker := health.NewChecker(config.ExternalReadiness{})
+	manager := NewManager(&config.Config{}, checker)
+	ok := newMockServer("startup-ok")
+	ok.blockStart = true
+	first := newMockServer("startup-first")
+	first.startError = errors.New("address already in use")
+	second := newMockServer("startup-second")
+	second.startError = errors.New("permission denied")
+	for _, srv := range []Server{ok, first, second} {
+		manager.RegisterServer(srv)
+	}
+
+	err := manager.Start(context.Background())
+	defer func() { _ = manager.Shutdown(5 * time.Second) }()
+	assert.EqualError(t, err, "failed to start startup-first server: address already in use\n"+
+		"failed to start startup-second server: permission denied")
+	assert.Equal(t, http.StatusServiceUnavailable, readyCode(checker))
+	assert.True(t, ok.bound.Load(), "servers that started keep running")
+	assert.Equal(t, 0.0, testutil.ToFloat64(metrics.ListenerUp.WithLabelValues("startup-first")))
+	assert.Eventually(t, func() bool {
+		return testutil.ToFloat64(metrics.ListenerUp.WithLabelValues("startup-ok")) == 1
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestManager_FailurePolicy(t *testing.T) {
+	restartBackoffMin = 10 * time.Millisecond
+	defer func() { restartBackoffMin = time.Second }()
+
+	tests := []struct {
+		policy      string
+		exits       bool
+		restarts    bool
+		readyLater  bool
+		startsAfter int32
+	}{
+		{policy: config.FailurePolicyExit, exits: true, startsAfter: 1},
+		{policy: "", exits: true, startsAfter: 1},
+		{policy: config.FailurePolicyDegrade, startsAfter: 1},
+		{policy: config.FailurePolicyRestart, restarts: true, readyLater: true, startsAfter: 2},
+	}
+	for _, tt := range tests {
+		t.Run("policy "+tt.policy, func(t *testing.T) {
+			checker := health.NewChecker(config.ExternalReadiness{})
+			manager := NewManager(&config.Config{ListenerFailurePolicy: tt.policy}, checker)
+			name := "policy-" + tt.policy
+			srv := newMockServer(name)
+			srv.blockStart = true
+			other := newMockServer(name + "-other")
+			other.blockStart = true
+			manager.RegisterServer(srv)
+			manager.RegisterServer(other)
+			require.NoError(t, manager.Start(context.Background()))
+			defer func() { _ = manager.Shutdown(5 * time.Second) }()
+			require.Eventually(t, func() bool { return readyCode(checker) == http.StatusOK }, time.Second, 10*time.Millisecond)
+			restarts := testutil.ToFloat64(metrics.ListenerRestartsTotal.WithLabelValues(name))
+
+			srv.failCh <- errors.New("accept failed")
+			select {
+			case err := <-manager.Failed():
+				assert.True(t, tt.exits, "unexpected exit: %v", err)
+				assert.EqualError(t, err, name+" server failed: accept failed")
+			case <-time.After(200 * time.Millisecond):
+				assert.False(t, tt.exits, "no exit requested")
+			}
+			assert.True(t, other.bound.Load(), "other servers keep running")
+
+			if tt.readyLater {
+				assert.Eventually(t, func() bool { return readyCode(checker) == http.StatusOK }, time.Second, 10*time.Millisecond)
+			} else {
+				assert.Equal(t, http.StatusServiceUnavailable, readyCode(checker))
+			}
+			assert.Equal(t, tt.startsAfter, atomic.LoadInt32(&srv.startCalled))
+			if tt.restarts {
+				assert.Equal(t, restarts+1, testutil.ToFloat64(metrics.ListenerRestartsTotal.WithLabelValues(name)))
+			}
+		})
+	}
+}
+
+func TestManager_RestartDelay(t *testing.T) {
+	assert.Equal(t, time.Second, restartDelay(0))
+	assert.Equal(t, 2*time.Second, restartDelay(1))
+	assert.Equal(t, 16*time.Second, restartDelay(4))
+	assert.Equal(t, 30*time.Second, restartDelay(5))
+	assert.Equal(t, 30*time.Second, restartDelay(100))
+}
+
+func TestManager_ShutdownIsNotAFailure(t *testing.T) {
+	checker := health.NewChecker(config.ExternalReadiness{})
+	manager := NewManager(&config.Config{}, checker)
+	srv := newMockServer("clean-stop")
+	srv.blockStart = true
+	manager.RegisterServer(srv)
+	require.NoError(t, manager.Start(context.Background()))
+
+	require.NoError(t, manager.Shutdown(5*time.Second))
+	select {
+	case err := <-manager.Failed():
+		t.Fatalf("shutdown reported as failure: %v", err)
+	default:
+	}
+}
+
+// readyCode returns the status code of the checker's readiness endpoint
+func readyCode(checker *health.Checker) int {
+	w := httptest.NewRecorder()
+	checker.ReadyHandler(w, httptest.NewRequest(http.MethodGet, "/ready", nil))
+	return w.Code
+}
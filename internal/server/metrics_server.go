@@ -4,10 +4,15 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/PhilipSchmid/echo-app/internal/config"
+	"github.com/PhilipSchmid/echo-app/internal/diagnostics"
 	"github.com/PhilipSchmid/echo-app/internal/health"
+	"github.com/PhilipSchmid/echo-app/internal/reload"
+	"github.com/PhilipSchmid/echo-app/internal/utils"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/sirupsen/logrus"
 )
@@ -15,24 +20,34 @@ import (
 // MetricsServer represents a Prometheus metrics server
 type MetricsServer struct {
 	cfg        *config.Config
+	mu         sync.Mutex // Guards server and stopped
 	server     *http.Server
+	stopped    bool // Shutdown was called, so Start must not serve
 	listenAddr string
 	health     *health.Checker
+	manager    *Manager // Feeds the admin API, if set
+	boundAddr
 }
 
 // NewMetricsServer creates a new metrics server
 func NewMetricsServer(cfg *config.Config, healthChecker *health.Checker) *MetricsServer {
 	if healthChecker == nil {
-		healthChecker = health.NewChecker(config.ExternalReadinessProbe{})
+		healthChecker = health.NewChecker(config.ExternalReadiness{})
 	}
 
 	return &MetricsServer{
 		cfg:        cfg,
-		listenAddr: ":" + cfg.MetricsPort,
+		listenAddr: config.ListenAddress(cfg.MetricsAddress, cfg.MetricsPort),
 		health:     healthChecker,
 	}
 }
 
+// WithManager serves the listener status admin API fed by manager
+func (s *MetricsServer) WithManager(manager *Manager) *MetricsServer {
+	s.manager = manager
+	return s
+}
+
 // Name returns the server name
 func (s *MetricsServer) Name() string {
 	return "Metrics"
@@ -41,25 +56,54 @@ func (s *MetricsServer) Name() string {
 // Start starts the metrics server
 func (s *MetricsServer) Start(ctx context.Context) error {
 	mux := http.NewServeMux()
+	// Serve OpenMetrics on request so trace exemplars are exposed
+	metricsHandler := promhttp.InstrumentMetricHandler(prometheus.DefaultRegisterer,
+		promhttp.HandlerFor(prometheus.DefaultGatherer, promhttp.HandlerOpts{EnableOpenMetrics: true}))
 	// Wrap metrics handler with timeout to prevent hung scrapers
-	mux.Handle("/metrics", http.TimeoutHandler(promhttp.Handler(), 10*time.Second, "Metrics collection timeout"))
+	mux.Handle("/metrics", http.TimeoutHandler(metricsHandler, 10*time.Second, "Metrics collection timeout"))
 
 	if s.health != nil {
 		mux.HandleFunc("/health", s.health.HealthHandler)
 		mux.HandleFunc("/ready", s.health.ReadyHandler)
 	}
+	mux.HandleFunc("/admin/reload", reload.Handler)
+	if s.manager != nil {
+		registerAdmin(mux, s.manager, s.health)
+	}
+
+	// Network diagnostics are opt-in as they let callers probe the pod's network
+	if s.cfg.Diagnostics.Enabled {
+		mux.Handle("/debug/", diagnostics.Handler(s.cfg.Diagnostics))
+		logrus.Infof("Diagnostics endpoints enabled on %s/debug/", s.listenAddr)
+	}
 
-	s.server = &http.Server{
-		Addr:         s.listenAddr,
+	ln, err := listen(s.listenAddr, s.cfg.SocketMode)
+	if err != nil {
+		return err
+	}
+
+	server := &http.Server{
 		Handler:      mux,
 		ReadTimeout:  5 * time.Second,
 		WriteTimeout: 10 * time.Second,
 		IdleTimeout:  120 * time.Second,
 	}
+	s.mu.Lock()
+	if s.stopped {
+		s.mu.Unlock()
+		_ = ln.Close()
+		return nil
+	}
+	s.server = server
+	s.mu.Unlock()
+	// Published once the server is set, so Shutdown called after the
+	// address is reported sees it
+	s.setAddr(ln.Addr())
+	defer s.setAddr(nil)
 
-	logrus.Infof("Metrics server listening on %s", s.listenAddr)
+	logrus.Infof("Metrics server listening on %s", utils.FormatAddr(ln.Addr()))
 
-	if err := s.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+	if err := server.Serve(ln); err != nil && err != http.ErrServerClosed {
 		return fmt.Errorf("metrics server error: %w", err)
 	}
 
@@ -68,8 +112,12 @@ func (s *MetricsServer) Start(ctx context.Context) error {
 
 // Shutdown gracefully shuts down the metrics server
 func (s *MetricsServer) Shutdown(ctx context.Context) error {
-	if s.server == nil {
+	s.mu.Lock()
+	s.stopped = true
+	server := s.server
+	s.mu.Unlock()
+	if server == nil {
 		return nil
 	}
-	return s.server.Shutdown(ctx)
+	return server.Shutdown(ctx)
 }
@@ -9,8 +9,10 @@ import (
 	"time"
 
 	"github.com/PhilipSchmid/echo-app/internal/config"
+	"github.com/PhilipSchmid/echo-app/internal/metrics"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func TestNewMetricsServer(t *testing.T) {
@@ -171,6 +173,48 @@ func TestMetricsServer_MetricsEndpoint(t *testing.T) {
 	_ = server.Shutdown(shutdownCtx)
 }
 
+func TestMetricsServer_OpenMetricsExemplars(t *testing.T) {
+	cfg := &config.Config{
+		MetricsPort: "13010",
+	}
+
+	// Record a request that belongs to a sampled trace
+	traceID, err := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	require.NoError(t, err)
+	spanID, err := trace.SpanIDFromHex("00f067aa0ba902b7")
+	require.NoError(t, err)
+	traceCtx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	}))
+	metrics.RecordRequestContext(traceCtx, "ExemplarTest", "GET", "/", 0.01)
+
+	server := NewMetricsServer(cfg, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() { _ = server.Start(ctx) }()
+	time.Sleep(100 * time.Millisecond)
+
+	req, err := http.NewRequest("GET", "http://localhost:13010/metrics", nil)
+	require.NoError(t, err)
+	req.Header.Set("Accept", "application/openmetrics-text; version=1.0.0")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+
+	assert.Contains(t, resp.Header.Get("Content-Type"), "application/openmetrics-text")
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), `listener="ExemplarTest"`)
+	assert.Contains(t, string(body), `# {trace_id="4bf92f3577b34da6a3ce929d0e0e4736"} 0.01`)
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer shutdownCancel()
+	_ = server.Shutdown(shutdownCtx)
+}
+
 func TestMetricsServer_MetricsTimeout(t *testing.T) {
 	cfg := &config.Config{
 		MetricsPort: "13006",
@@ -219,6 +263,10 @@ func TestMetricsServer_ShutdownWithoutStart(t *testing.T) {
 
 	err := server.Shutdown(shutdownCtx)
 	assert.NoError(t, err)
+
+	// A Start racing the shutdown must not serve
+	assert.NoError(t, server.Start(context.Background()))
+	assert.Nil(t, server.Addr())
 }
 
 func TestMetricsServer_GracefulShutdown(t *testing.T) {
@@ -296,6 +344,11 @@ func TestMetricsServer_MultipleEndpoints(t *testing.T) {
 			expectedStatus: http.StatusOK,
 			expectedBody:   "go_", // Should contain go metrics
 		},
+		{
+			name:           "reload status endpoint",
+			endpoint:       "/admin/reload",
+			expectedStatus: http.StatusOK,
+		},
 	}
 
 	for _, tt := range tests {
@@ -326,3 +379,35 @@ func TestMetricsServer_MultipleEndpoints(t *testing.T) {
 	defer shutdownCancel()
 	_ = server.Shutdown(shutdownCtx)
 }
+
+func TestMetricsServer_DiagnosticsEndpoints(t *testing.T) {
+	tests := []struct {
+		name           string
+		port           string
+		enabled        bool
+		expectedStatus int
+	}{
+		{name: "disabled by default", port: "13011", expectedStatus: http.StatusNotFound},
+		{name: "enabled", port: "13012", enabled: true, expectedStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{
+				MetricsPort: tt.port,
+				Diagnostics: config.Diagnostics{Enabled: tt.enabled, Timeout: time.Second},
+			}
+
+			server := NewMetricsServer(cfg, nil)
+			go func() { _ = server.Start(context.Background()) }()
+			defer func() { _ = server.Shutdown(context.Background()) }()
+			time.Sleep(100 * time.Millisecond)
+
+			// Dial the metrics server itself
+			resp, err := http.Get("http://localhost:" + tt.port + "/debug/dial?target=127.0.0.1:" + tt.port)
+			require.NoError(t, err)
+			defer func() { _ = resp.Body.Close() }()
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+		})
+	}
+}
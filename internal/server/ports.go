@@ -0,0 +1,81 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+
+	"github.com/PhilipSchmid/echo-app/internal/utils"
+)
+
+// BoundAddress is the address a server is listening on
+type BoundAddress struct {
+	Name    string `json:"name"`
+	Network string `json:"network"`        // tcp, udp or unix
+	Address string `json:"address"`        // host:port, or unix:///path for Unix sockets
+	Port    int    `json:"port,omitempty"` // Bound port, including ports picked for port 0
+}
+
+func newBoundAddress(name string, addr net.Addr) BoundAddress {
+	bound := BoundAddress{Name: name, Network: addr.Network(), Address: utils.FormatAddr(addr)}
+	switch a := addr.(type) {
+	case *net.TCPAddr:
+		bound.Port = a.Port
+	case *net.UDPAddr:
+		bound.Port = a.Port
+	}
+	return bound
+}
+
+// portsFile is the JSON document written by WritePortsFile
+type portsFile struct {
+	Listeners []BoundAddress `json:"listeners"`
+}
+
+// ReadPortsFile reads the addresses written by WritePortsFile
+func ReadPortsFile(path string) ([]BoundAddress, error) {
+	data, err := os.ReadFile(path) // #nosec G304 -- path is the configured ports file
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ports file: %w", err)
+	}
+	var ports portsFile
+	if err := json.Unmarshal(data, &ports); err != nil {
+		return nil, fmt.Errorf("failed to read ports file %s: %w", path, err)
+	}
+	return ports.Listeners, nil
+}
+
+// WritePortsFile writes addrs as JSON to path, so test harnesses can find the
+// ports picked for listeners bound to port 0. The file is replaced atomically
+// and never read half-written.
+func WritePortsFile(path string, addrs []BoundAddress) error {
+	if addrs == nil {
+		addrs = []BoundAddress{}
+	}
+	data, err := json.MarshalIndent(portsFile{Listeners: addrs}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode ports file: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".ports-*.json")
+	if err != nil {
+		return fmt.Errorf("failed to write ports file: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write ports file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write ports file: %w", err)
+	}
+	// CreateTemp creates files readable by the owner only
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return fmt.Errorf("failed to write ports file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write ports file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package server
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/PhilipSchmid/echo-app/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager_EphemeralPorts(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "tcp.sock")
+	cfg := &config.Config{Metrics: true, MetricsAddress: "127.0.0.1:0", Listeners: []config.Listener{
+		{Name: "web", Protocol: config.ListenerHTTP, Port: "0"},
+		{Name: "secure", Protocol: config.ListenerTLS, Address: "127.0.0.1:0"},
+		{Name: "raw", Protocol: config.ListenerTCP, Address: "unix://" + socket},
+		{Name: "rpc", Protocol: config.ListenerGRPC, Address: "127.0.0.1:0"},
+		{Name: "h3", Protocol: config.ListenerQUIC, Address: "127.0.0.1:0"},
+	}}
+	manager := NewManager(cfg, nil)
+	for _, l := range cfg.Listeners {
+		require.NoError(t, manager.RegisterListener(cfg, l))
+	}
+	manager.RegisterServer(NewMetricsServer(cfg, nil))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, manager.Start(ctx))
+	defer func() { _ = manager.Shutdown(5 * time.Second) }()
+
+	waitCtx, waitCancel := context.WithTimeout(ctx, 5*time.Second)
+	defer waitCancel()
+	addrs := manager.Addresses(waitCtx)
+	require.Len(t, addrs, 6)
+
+	expected := []struct{ name, network string }{
+		{"web", "tcp"}, {"secure", "tcp"}, {"raw", "unix"}, {"rpc", "tcp"}, {"h3", "udp"}, {"Metrics", "tcp"},
+	}
+	for i, e := range expected {
+		assert.Equal(t, e.name, addrs[i].Name)
+		assert.Equal(t, e.network, addrs[i].Network)
+		if e.network == "unix" {
+			assert.Equal(t, "unix://"+socket, addrs[i].Address)
+			assert.Zero(t, addrs[i].Port)
+			continue
+		}
+		assert.NotZero(t, addrs[i].Port)
+		_, port, err := net.SplitHostPort(addrs[i].Address)
+		require.NoError(t, err)
+		assert.Equal(t, strconv.Itoa(addrs[i].Port), port)
+	}
+
+	// The reported addresses are the ones actually served
+	resp, err := http.Get("http://127.0.0.1:" + strconv.Itoa(addrs[0].Port) + "/")
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp, err = http.Get("http://" + addrs[5].Address + "/health")
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestManager_AddressesSkipsStoppedServers(t *testing.T) {
+	// Both listeners cannot bind the same port, so one of them stops
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() { _ = ln.Close() }()
+
+	cfg := &config.Config{Listeners: []config.Listener{
+		{Name: "ok", Protocol: config.ListenerHTTP, Address: "127.0.0.1:0"},
+		{Name: "taken", Protocol: config.ListenerHTTP, Address: ln.Addr().String()},
+	}}
+	manager := NewManager(cfg, nil)
+	for _, l := range cfg.Listeners {
+		require.NoError(t, manager.RegisterListener(cfg, l))
+	}
+	err = manager.Start(context.Background())
+	defer func() { _ = manager.Shutdown(5 * time.Second) }()
+	require.ErrorContains(t, err, "failed to start taken server: failed to listen on "+ln.Addr().String())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	addrs := manager.Addresses(ctx)
+	require.NoError(t, ctx.Err(), "Addresses waited for a stopped server")
+	require.Len(t, addrs, 1)
+	assert.Equal(t, "ok", addrs[0].Name)
+}
+
+func TestWritePortsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ports.json")
+	addrs := []BoundAddress{
+		{Name: "HTTP", Network: "tcp", Address: "[::]:41234", Port: 41234},
+		{Name: "TCP", Network: "unix", Address: "unix:///run/echo-app/tcp.sock"},
+	}
+	require.NoError(t, WritePortsFile(path, addrs))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"listeners": [
+		{"name": "HTTP", "network": "tcp", "address": "[::]:41234", "port": 41234},
+		{"name": "TCP", "network": "unix", "address": "unix:///run/echo-app/tcp.sock"}
+	]}`, string(data))
+
+	// Rewriting replaces the file and leaves no temporary files behind
+	require.NoError(t, WritePortsFile(path, nil))
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"listeners": []}`, string(data))
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	err = WritePortsFile(filepath.Join(t.TempDir(), "missing", "ports.json"), addrs)
+	assert.ErrorContains(t, err, "failed to write ports file")
+}
@@ -3,11 +3,13 @@ package server
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
 
 	"github.com/PhilipSchmid/echo-app/internal/config"
 	"github.com/PhilipSchmid/echo-app/internal/handlers"
 	"github.com/quic-go/quic-go/http3"
+	"github.com/quic-go/webtransport-go"
 	"github.com/sirupsen/logrus"
 )
 
@@ -15,14 +17,16 @@ import (
 type QUICServer struct {
 	cfg        *config.Config
 	server     *http3.Server
+	wt         *webtransport.Server
 	listenAddr string
+	boundAddr
 }
 
 // NewQUICServer creates a new QUIC server
 func NewQUICServer(cfg *config.Config) *QUICServer {
 	return &QUICServer{
 		cfg:        cfg,
-		listenAddr: ":" + cfg.QUICPort,
+		listenAddr: config.ListenAddress(cfg.QUICAddress, cfg.QUICPort),
 	}
 }
 
@@ -45,40 +49,81 @@ func (s *QUICServer) Start(ctx context.Context) error {
 	// Create HTTP handler
 	mux := http.NewServeMux()
 	mux.HandleFunc("/", handlers.QUICHandler(s.cfg))
+	mux.HandleFunc("/chain", handlers.ChainHandler(s.cfg, "QUIC"))
+
+	conn, err := net.ListenPacket("udp", s.listenAddr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.listenAddr, err)
+	}
+	// Closing the HTTP/3 server leaves the connection open
+	defer func() { _ = conn.Close() }()
 
 	// Create QUIC server
 	s.server = &http3.Server{
-		Addr:      s.listenAddr,
 		Handler:   mux,
 		TLSConfig: tlsConfig,
 	}
 
-	logrus.Infof("QUIC server listening on %s", s.listenAddr)
+	serve := s.server.Serve
+	if s.cfg.WebTransport {
+		s.wt = &webtransport.Server{
+			H3: s.server,
+			// Accept sessions from any origin so browser-based test pages
+			// hosted elsewhere can reach the echo endpoint
+			CheckOrigin: func(*http.Request) bool { return true },
+		}
+		mux.HandleFunc("/webtransport", handlers.WebTransportHandler(s.cfg, s.wt))
+		serve = s.wt.Serve
+		logrus.Infof("WebTransport endpoint enabled on %s/webtransport", s.listenAddr)
+	}
+
+	// Published once the server is set, so Shutdown called after the address
+	// is reported sees it
+	s.setAddr(conn.LocalAddr())
+	defer s.setAddr(nil)
+
+	logrus.Infof("QUIC server listening on %s", conn.LocalAddr())
 
 	// Start serving in a goroutine to handle context cancellation
 	errCh := make(chan error, 1)
 	go func() {
-		errCh <- s.server.ListenAndServe()
+		errCh <- serve(conn)
 	}()
 
 	select {
 	case <-ctx.Done():
-		return s.server.Close()
+		return s.close()
 	case err := <-errCh:
 		return err
 	}
 }
 
+// close stops the WebTransport server, if any, and the underlying HTTP/3 server
+func (s *QUICServer) close() error {
+	if s.wt != nil {
+		return s.wt.Close()
+	}
+	return s.server.Close()
+}
+
 // Shutdown gracefully shuts down the QUIC server
 func (s *QUICServer) Shutdown(ctx context.Context) error {
 	if s.server == nil {
 		return nil
 	}
 
-	// QUIC server doesn't have a graceful shutdown method, just close
-	if err := s.server.Close(); err != nil {
-		return fmt.Errorf("failed to close QUIC server: %w", err)
+	// WebTransport sessions cannot be drained, so they are closed right away
+	if s.wt != nil {
+		if err := s.close(); err != nil {
+			return fmt.Errorf("failed to close QUIC server: %w", err)
+		}
+		return nil
 	}
 
+	// Send GOAWAY and wait for in-flight requests, closing the remaining
+	// connections once ctx is done
+	if err := s.server.Shutdown(ctx); err != nil {
+		return fmt.Errorf("failed to shut down QUIC server: %w", err)
+	}
 	return nil
 }
@@ -0,0 +1,132 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/PhilipSchmid/echo-app/internal/config"
+	"github.com/PhilipSchmid/echo-app/internal/handlers"
+	"github.com/quic-go/webtransport-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// dialWebTransportWithRetry dials a WebTransport session, retrying until the
+// QUIC listener is up or the deadline elapses
+func dialWebTransportWithRetry(t *testing.T, url string) *webtransport.Session {
+	t.Helper()
+	dialer := &webtransport.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	}
+	t.Cleanup(func() { _ = dialer.Close() })
+
+	deadline := time.Now().Add(5 * time.Second)
+	var lastErr error
+	for time.Now().Before(deadline) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		rsp, sess, err := dialer.Dial(ctx, url, http.Header{})
+		cancel()
+		if err == nil {
+			require.Equal(t, http.StatusOK, rsp.StatusCode)
+			return sess
+		}
+		lastErr = err
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatalf("WebTransport session could not be established at %s: %v", url, lastErr)
+	return nil
+}
+
+func TestQUICServer_WebTransportEcho(t *testing.T) {
+	cfg := &config.Config{
+		QUICPort:       "14433",
+		WebTransport:   true,
+		Message:        "wt-test",
+		MaxRequestSize: 1024,
+	}
+
+	server := NewQUICServer(cfg)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.Start(ctx)
+	}()
+
+	sess := dialWebTransportWithRetry(t, "https://localhost:14433/webtransport")
+	defer func() { _ = sess.CloseWithError(0, "") }()
+
+	streamCtx, streamCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer streamCancel()
+
+	// The first unidirectional stream carries the session metadata
+	metaStream, err := sess.AcceptUniStream(streamCtx)
+	require.NoError(t, err)
+	metaData, err := io.ReadAll(metaStream)
+	require.NoError(t, err)
+
+	var meta handlers.WebTransportResponse
+	require.NoError(t, json.Unmarshal(metaData, &meta))
+	assert.Equal(t, "WebTransport", meta.Listener)
+	assert.Equal(t, "wt-test", meta.Message)
+	assert.Equal(t, "/webtransport", meta.HTTPEndpoint)
+
+	// Bidirectional streams are echoed on the same stream
+	str, err := sess.OpenStreamSync(streamCtx)
+	require.NoError(t, err)
+	_, err = str.Write([]byte("bidi"))
+	require.NoError(t, err)
+	require.NoError(t, str.Close())
+	reply, err := io.ReadAll(str)
+	require.NoError(t, err)
+	assert.Equal(t, "bidi", string(reply))
+
+	// Bidirectional echoes stop at the maximum request size
+	large, err := sess.OpenStreamSync(streamCtx)
+	require.NoError(t, err)
+	_, err = large.Write(make([]byte, 2*cfg.MaxRequestSize))
+	require.NoError(t, err)
+	require.NoError(t, large.Close())
+	largeReply, err := io.ReadAll(large)
+	require.NoError(t, err)
+	assert.Len(t, largeReply, int(cfg.MaxRequestSize))
+
+	// Unidirectional streams are echoed on a new server-initiated stream
+	uni, err := sess.OpenUniStreamSync(streamCtx)
+	require.NoError(t, err)
+	_, err = uni.Write([]byte("uni"))
+	require.NoError(t, err)
+	require.NoError(t, uni.Close())
+	uniReply, err := sess.AcceptUniStream(streamCtx)
+	require.NoError(t, err)
+	uniData, err := io.ReadAll(uniReply)
+	require.NoError(t, err)
+	assert.Equal(t, "uni", string(uniData))
+
+	// Datagrams are unreliable, so resend until one echo arrives
+	require.Eventually(t, func() bool {
+		if err := sess.SendDatagram([]byte("datagram")); err != nil {
+			return false
+		}
+		dgCtx, dgCancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer dgCancel()
+		data, err := sess.ReceiveDatagram(dgCtx)
+		return err == nil && string(data) == "datagram"
+	}, 5*time.Second, 10*time.Millisecond)
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer shutdownCancel()
+	assert.NoError(t, server.Shutdown(shutdownCtx))
+
+	select {
+	case <-errCh:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Server did not stop in time")
+	}
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/PhilipSchmid/echo-app/internal/config"
 	"github.com/PhilipSchmid/echo-app/internal/handlers"
+	"github.com/PhilipSchmid/echo-app/internal/utils"
 	"github.com/sirupsen/logrus"
 )
 
@@ -33,13 +34,14 @@ type TCPServer struct {
 	wg           sync.WaitGroup
 	ctx          context.Context
 	mu           sync.RWMutex // Protects listener and ctx
+	boundAddr
 }
 
 // NewTCPServer creates a new TCP server
 func NewTCPServer(cfg *config.Config) *TCPServer {
 	return &TCPServer{
 		cfg:        cfg,
-		listenAddr: ":" + cfg.TCPPort,
+		listenAddr: config.ListenAddress(cfg.TCPAddress, cfg.TCPPort),
 		shutdown:   make(chan struct{}),
 	}
 }
@@ -49,12 +51,21 @@ func (s *TCPServer) Name() string {
 	return "TCP"
 }
 
+// activeConnections returns the number of open connections
+func (s *TCPServer) activeConnections() int {
+	return int(atomic.LoadInt32(&s.activeConns))
+}
+
 // Start starts the TCP server
 func (s *TCPServer) Start(ctx context.Context) error {
-	listener, err := net.Listen("tcp", s.listenAddr)
+	listener, err := listen(s.listenAddr, s.cfg.SocketMode)
 	if err != nil {
-		return fmt.Errorf("failed to listen on %s: %w", s.listenAddr, err)
+		return err
 	}
+	// TCP and Unix listeners both support accept deadlines
+	deadliner := listener.(interface{ SetDeadline(time.Time) error })
+	s.setAddr(listener.Addr())
+	defer s.setAddr(nil)
 
 	// Store context and listener with mutex protection
 	s.mu.Lock()
@@ -62,7 +73,7 @@ func (s *TCPServer) Start(ctx context.Context) error {
 	s.listener = listener
 	s.mu.Unlock()
 
-	logrus.Infof("TCP server listening on %s", s.listenAddr)
+	logrus.Infof("TCP server listening on %s", utils.FormatAddr(listener.Addr()))
 
 	// Accept connections
 	for {
@@ -73,7 +84,7 @@ func (s *TCPServer) Start(ctx context.Context) error {
 			return nil
 		default:
 			// Set accept deadline to check for shutdown periodically
-			if err := listener.(*net.TCPListener).SetDeadline(time.Now().Add(1 * time.Second)); err != nil {
+			if err := deadliner.SetDeadline(time.Now().Add(1 * time.Second)); err != nil {
 				logrus.Errorf("Failed to set accept deadline: %v", err)
 			}
 
@@ -0,0 +1,201 @@
+// Package tracing sets up OpenTelemetry tracing and W3C trace context
+// propagation for all listeners
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/PhilipSchmid/echo-app/internal/config"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.43.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName identifies spans created by echo-app
+const tracerName = "github.com/PhilipSchmid/echo-app"
+
+// propagator handles W3C traceparent/tracestate and baggage. It is used
+// directly so trace context is echoed even before Setup runs.
+var propagator = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+
+type parentKey struct{}
+
+// Info is the trace context of a request as echoed in responses
+type Info struct {
+	TraceID      string            `json:"trace_id"`
+	SpanID       string            `json:"span_id"`
+	ParentSpanID string            `json:"parent_span_id,omitempty"`
+	Sampled      bool              `json:"sampled"`
+	Baggage      map[string]string `json:"baggage,omitempty"`
+}
+
+// Setup installs the W3C propagator and, when enabled, a tracer provider
+// exporting spans via OTLP. The returned function flushes and stops the
+// exporter.
+func Setup(ctx context.Context, cfg config.Tracing) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagator)
+	if !cfg.Enabled {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := newExporter(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s trace exporter: %w", cfg.Exporter, err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(cfg.ServiceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		logrus.Warnf("[Tracing] %v", err)
+	}))
+
+	logrus.Infof("[Tracing] Exporting spans via %s (endpoint: %s, sample ratio: %g)",
+		cfg.Exporter, endpointOrDefault(cfg.Endpoint), cfg.SampleRatio)
+	return provider.Shutdown, nil
+}
+
+// newExporter creates the configured OTLP exporter
+func newExporter(ctx context.Context, cfg config.Tracing) (*otlptrace.Exporter, error) {
+	isURL := strings.Contains(cfg.Endpoint, "://")
+
+	switch cfg.Exporter {
+	case config.TracingExporterOTLPHTTP:
+		var opts []otlptracehttp.Option
+		if isURL {
+			opts = append(opts, otlptracehttp.WithEndpointURL(cfg.Endpoint))
+		} else if cfg.Endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpoint(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		return otlptracehttp.New(ctx, opts...)
+	case config.TracingExporterOTLPGRPC:
+		var opts []otlptracegrpc.Option
+		if isURL {
+			opts = append(opts, otlptracegrpc.WithEndpointURL(cfg.Endpoint))
+		} else if cfg.Endpoint != "" {
+			opts = append(opts, otlptracegrpc.WithEndpoint(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		return otlptracegrpc.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("unsupported exporter")
+	}
+}
+
+func endpointOrDefault(endpoint string) string {
+	if endpoint == "" {
+		return "default"
+	}
+	return endpoint
+}
+
+// Tracer returns the tracer used by all listeners
+func Tracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+// StartServerSpan extracts the remote trace context and baggage from carrier,
+// which may be nil, and starts a server span as its child
+func StartServerSpan(ctx context.Context, carrier propagation.TextMapCarrier, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if carrier != nil {
+		ctx = propagator.Extract(ctx, carrier)
+	}
+	parent := trace.SpanContextFromContext(ctx)
+
+	ctx, span := Tracer().Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(attrs...))
+	if parent.IsValid() {
+		ctx = context.WithValue(ctx, parentKey{}, parent)
+	}
+	return ctx, span
+}
+
+// StartClientSpan starts a client span for an outgoing call and injects its
+// trace context and the baggage in ctx into carrier
+func StartClientSpan(ctx context.Context, carrier propagation.TextMapCarrier, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	ctx, span := Tracer().Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...))
+	propagator.Inject(ctx, carrier)
+	return ctx, span
+}
+
+// InfoFromContext returns the trace context of the span in ctx, or nil when
+// the request is neither traced nor carried a valid traceparent
+func InfoFromContext(ctx context.Context) *Info {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return nil
+	}
+
+	info := &Info{
+		TraceID: sc.TraceID().String(),
+		SpanID:  sc.SpanID().String(),
+		Sampled: sc.IsSampled(),
+	}
+	// Without an exporting tracer the span is the remote parent itself
+	if parent, ok := ctx.Value(parentKey{}).(trace.SpanContext); ok && parent.SpanID() != sc.SpanID() {
+		info.ParentSpanID = parent.SpanID().String()
+	}
+	if members := baggage.FromContext(ctx).Members(); len(members) > 0 {
+		info.Baggage = make(map[string]string, len(members))
+		for _, member := range members {
+			info.Baggage[member.Key()] = member.Value()
+		}
+	}
+	return info
+}
+
+// MetadataCarrier adapts gRPC metadata to a propagation.TextMapCarrier
+type MetadataCarrier map[string][]string
+
+// Get returns the first value for key
+func (c MetadataCarrier) Get(key string) string {
+	values := c[strings.ToLower(key)]
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// Set replaces the values for key
+func (c MetadataCarrier) Set(key, value string) {
+	c[strings.ToLower(key)] = []string{value}
+}
+
+// Keys lists all keys in the carrier
+func (c MetadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
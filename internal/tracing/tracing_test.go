@@ -0,0 +1,197 @@
+package tracing
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/PhilipSchmid/echo-app/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace/noop"
+	collectortrace "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	testTraceID     = "4bf92f3577b34da6a3ce929d0e0e4736"
+	testParentID    = "00f067aa0ba902b7"
+	testTraceparent = "00-" + testTraceID + "-" + testParentID + "-01"
+)
+
+// collector is an in-process OTLP collector recording exported span names
+type collector struct {
+	collectortrace.UnimplementedTraceServiceServer
+	mu    sync.Mutex
+	spans []string
+}
+
+func (c *collector) Export(_ context.Context, req *collectortrace.ExportTraceServiceRequest) (*collectortrace.ExportTraceServiceResponse, error) {
+	c.record(req)
+	return &collectortrace.ExportTraceServiceResponse{}, nil
+}
+
+func (c *collector) record(req *collectortrace.ExportTraceServiceRequest) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, rs := range req.ResourceSpans {
+		for _, ss := range rs.ScopeSpans {
+			for _, span := range ss.Spans {
+				c.spans = append(c.spans, span.Name)
+			}
+		}
+	}
+}
+
+func (c *collector) names() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.spans...)
+}
+
+// resetTracerProvider restores the no-op provider after a test installed one
+func resetTracerProvider(t *testing.T) {
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+}
+
+// exportSpan starts a child of testTraceparent and flushes it via shutdown
+func exportSpan(t *testing.T, shutdown func(context.Context) error) *Info {
+	t.Helper()
+	carrier := propagation.MapCarrier{"traceparent": testTraceparent}
+	ctx, span := StartServerSpan(context.Background(), carrier, "GET /")
+	info := InfoFromContext(ctx)
+	span.End()
+	require.NoError(t, shutdown(context.Background()))
+	return info
+}
+
+func TestSetup_OTLPGRPC(t *testing.T) {
+	resetTracerProvider(t)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	c := &collector{}
+	srv := grpc.NewServer()
+	collectortrace.RegisterTraceServiceServer(srv, c)
+	go func() { _ = srv.Serve(lis) }()
+	defer srv.Stop()
+
+	shutdown, err := Setup(context.Background(), config.Tracing{
+		Enabled:     true,
+		Exporter:    config.TracingExporterOTLPGRPC,
+		Endpoint:    lis.Addr().String(),
+		Insecure:    true,
+		SampleRatio: 1,
+		ServiceName: "echo-app-test",
+	})
+	require.NoError(t, err)
+
+	info := exportSpan(t, shutdown)
+	require.NotNil(t, info)
+	assert.Equal(t, testTraceID, info.TraceID)
+	assert.Equal(t, testParentID, info.ParentSpanID)
+	assert.NotEqual(t, testParentID, info.SpanID)
+	assert.True(t, info.Sampled)
+	assert.Equal(t, []string{"GET /"}, c.names())
+}
+
+func TestSetup_OTLPHTTP(t *testing.T) {
+	resetTracerProvider(t)
+
+	c := &collector{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/traces", r.URL.Path)
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		var req collectortrace.ExportTraceServiceRequest
+		require.NoError(t, proto.Unmarshal(body, &req))
+		c.record(&req)
+		w.Header().Set("Content-Type", "application/x-protobuf")
+		_, _ = w.Write(nil)
+	}))
+	defer srv.Close()
+
+	shutdown, err := Setup(context.Background(), config.Tracing{
+		Enabled:     true,
+		Exporter:    config.TracingExporterOTLPHTTP,
+		Endpoint:    srv.URL + "/v1/traces",
+		Insecure:    true,
+		SampleRatio: 1,
+		ServiceName: "echo-app-test",
+	})
+	require.NoError(t, err)
+
+	exportSpan(t, shutdown)
+	assert.Equal(t, []string{"GET /"}, c.names())
+}
+
+func TestSetup_Disabled(t *testing.T) {
+	shutdown, err := Setup(context.Background(), config.Tracing{})
+	require.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+	assert.NotNil(t, otel.GetTextMapPropagator())
+}
+
+func TestInfoFromContext(t *testing.T) {
+	// Without an incoming trace and without export there is nothing to echo
+	ctx, span := StartServerSpan(context.Background(), propagation.MapCarrier{}, "GET /")
+	span.End()
+	assert.Nil(t, InfoFromContext(ctx))
+
+	// With the no-op tracer the parsed parent context is echoed as is
+	carrier := propagation.MapCarrier{
+		"traceparent": "00-" + testTraceID + "-" + testParentID + "-00",
+		"baggage":     "tenant=acme,region=eu-west-1",
+	}
+	ctx, span = StartServerSpan(context.Background(), carrier, "GET /")
+	span.End()
+	assert.Equal(t, &Info{
+		TraceID: testTraceID,
+		SpanID:  testParentID,
+		Sampled: false,
+		Baggage: map[string]string{"tenant": "acme", "region": "eu-west-1"},
+	}, InfoFromContext(ctx))
+
+	// Malformed trace context is ignored
+	ctx, span = StartServerSpan(context.Background(), propagation.MapCarrier{"traceparent": "00-invalid-01"}, "GET /")
+	span.End()
+	assert.Nil(t, InfoFromContext(ctx))
+}
+
+func TestMetadataCarrier(t *testing.T) {
+	carrier := MetadataCarrier{"traceparent": {testTraceparent}}
+	assert.Equal(t, testTraceparent, carrier.Get("Traceparent"))
+	assert.Empty(t, carrier.Get("baggage"))
+
+	carrier.Set("Baggage", "tenant=acme")
+	assert.Equal(t, []string{"tenant=acme"}, carrier["baggage"])
+	assert.ElementsMatch(t, []string{"traceparent", "baggage"}, carrier.Keys())
+}
+
+func TestStartClientSpan(t *testing.T) {
+	// The incoming trace and baggage are forwarded even with the no-op tracer
+	ctx, span := StartServerSpan(context.Background(), propagation.MapCarrier{
+		"traceparent": testTraceparent,
+		"baggage":     "tenant=acme",
+	}, "GET /chain")
+	defer span.End()
+
+	carrier := propagation.MapCarrier{}
+	_, client := StartClientSpan(ctx, carrier, "GET")
+	client.End()
+	assert.Equal(t, testTraceparent, carrier.Get("traceparent"))
+	assert.Equal(t, "tenant=acme", carrier.Get("baggage"))
+
+	// Untraced calls carry no trace context
+	carrier = propagation.MapCarrier{}
+	_, client = StartClientSpan(context.Background(), carrier, "GET")
+	client.End()
+	assert.Empty(t, carrier.Get("traceparent"))
+}
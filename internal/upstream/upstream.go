@@ -0,0 +1,271 @@
+// Package upstream calls other echo-app instances (or any HTTP, gRPC or TCP
+// service) and captures their responses for embedding in our own
+package upstream
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PhilipSchmid/echo-app/internal/metrics"
+	"github.com/PhilipSchmid/echo-app/internal/tracing"
+	"github.com/PhilipSchmid/echo-app/proto"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.43.0"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// Upstream protocols, taken from the target URL scheme
+const (
+	ProtocolHTTP  = "http"
+	ProtocolHTTPS = "https"
+	ProtocolGRPC  = "grpc"
+	ProtocolGRPCS = "grpcs"
+	ProtocolTCP   = "tcp"
+)
+
+// MaxResponseSize limits how much of an upstream response is read
+const MaxResponseSize = 1 << 20
+
+// Options configures a single upstream call
+type Options struct {
+	Timeout            time.Duration
+	Headers            http.Header // Sent as HTTP headers or gRPC metadata
+	InsecureSkipVerify bool
+}
+
+// Result is the outcome of an upstream call as embedded in responses
+type Result struct {
+	Target    string          `json:"target"`
+	Protocol  string          `json:"protocol"`
+	Status    int             `json:"status,omitempty"`
+	GRPCCode  string          `json:"grpc_code,omitempty"`
+	LatencyMs float64         `json:"latency_ms"`
+	Error     string          `json:"error,omitempty"`
+	Response  json.RawMessage `json:"response,omitempty"`
+}
+
+// OK reports whether the upstream answered successfully
+func (r Result) OK() bool {
+	return r.Error == ""
+}
+
+// Parse validates target and returns it as a URL with a supported scheme
+func Parse(target string) (*url.URL, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, fmt.Errorf("invalid upstream %q: %w", target, err)
+	}
+	switch u.Scheme {
+	case ProtocolHTTP, ProtocolHTTPS, ProtocolGRPC, ProtocolGRPCS, ProtocolTCP:
+	default:
+		return nil, fmt.Errorf("invalid upstream %q: scheme must be one of http, https, grpc, grpcs or tcp", target)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid upstream %q: missing host", target)
+	}
+	return u, nil
+}
+
+// CallAll calls all targets in parallel and returns their results in order
+func CallAll(ctx context.Context, targets []string, opts Options) []Result {
+	results := make([]Result, len(targets))
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = Call(ctx, target, opts)
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// Call calls a single target, bounded by opts.Timeout
+func Call(ctx context.Context, target string, opts Options) Result {
+	result := Result{Target: target}
+	u, err := Parse(target)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Protocol = u.Scheme
+
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	switch u.Scheme {
+	case ProtocolHTTP, ProtocolHTTPS:
+		err = callHTTP(ctx, u, opts, &result)
+	case ProtocolGRPC, ProtocolGRPCS:
+		err = callGRPC(ctx, u, opts, &result)
+	case ProtocolTCP:
+		err = callTCP(ctx, u, &result)
+	}
+	duration := time.Since(start)
+	result.LatencyMs = float64(duration.Microseconds()) / 1000
+	if err != nil {
+		result.Error = err.Error()
+	}
+	metrics.RecordUpstream(result.Protocol, result.OK(), duration.Seconds())
+	return result
+}
+
+var (
+	secureClient   = newHTTPClient(false)
+	insecureClient = newHTTPClient(true)
+)
+
+// newHTTPClient returns a client with pooled connections. Redirects are not
+// followed so each hop reports exactly what its upstream answered.
+func newHTTPClient(skipVerify bool) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: skipVerify} // #nosec G402 -- opt-in for self-signed upstreams
+	return &http.Client{
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+func callHTTP(ctx context.Context, u *url.URL, opts Options, result *Result) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	for name, values := range opts.Headers {
+		req.Header[name] = values
+	}
+	req.Header.Set("Accept", "application/json")
+
+	ctx, span := tracing.StartClientSpan(ctx, propagation.HeaderCarrier(req.Header), http.MethodGet,
+		semconv.HTTPRequestMethodKey.String(http.MethodGet),
+		semconv.URLFull(u.String()),
+	)
+	defer span.End()
+	req = req.WithContext(ctx)
+
+	client := secureClient
+	if opts.InsecureSkipVerify {
+		client = insecureClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	result.Status = resp.StatusCode
+	span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+	body, err := io.ReadAll(io.LimitReader(resp.Body, MaxResponseSize))
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+	result.Response = embed(body)
+	if resp.StatusCode >= http.StatusBadRequest {
+		span.SetStatus(codes.Error, resp.Status)
+		return fmt.Errorf("upstream returned %s", resp.Status)
+	}
+	return nil
+}
+
+func callGRPC(ctx context.Context, u *url.URL, opts Options, result *Result) error {
+	creds := insecure.NewCredentials()
+	if u.Scheme == ProtocolGRPCS {
+		creds = credentials.NewTLS(&tls.Config{InsecureSkipVerify: opts.InsecureSkipVerify}) // #nosec G402 -- opt-in for self-signed upstreams
+	}
+	conn, err := grpc.NewClient(u.Host, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return err
+	}
+	defer func() { _ = conn.Close() }()
+
+	md := metadata.MD{}
+	for name, values := range opts.Headers {
+		md[strings.ToLower(name)] = values
+	}
+	method := strings.TrimPrefix(proto.EchoService_Echo_FullMethodName, "/")
+	ctx, span := tracing.StartClientSpan(ctx, tracing.MetadataCarrier(md), method,
+		semconv.RPCSystemNameGRPC,
+		semconv.RPCMethod(method),
+	)
+	defer span.End()
+	ctx = metadata.NewOutgoingContext(ctx, md)
+
+	resp, err := proto.NewEchoServiceClient(conn).Echo(ctx, &proto.EchoRequest{})
+	result.GRPCCode = status.Code(err).String()
+	span.SetAttributes(semconv.RPCResponseStatusCode(result.GRPCCode))
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+	data, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(resp)
+	if err != nil {
+		return err
+	}
+	result.Response = data
+	return nil
+}
+
+func callTCP(ctx context.Context, u *url.URL, result *Result) error {
+	// TCP carries no headers, so the upstream starts its own trace
+	ctx, span := tracing.StartClientSpan(ctx, propagation.MapCarrier{}, "TCP connect",
+		semconv.NetworkTransportTCP,
+	)
+	defer span.End()
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", u.Host)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+	defer func() { _ = conn.Close() }()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	// echo-app writes its response and closes the connection
+	body, err := io.ReadAll(io.LimitReader(conn, MaxResponseSize))
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+	result.Response = embed(body)
+	return nil
+}
+
+// embed returns body unchanged when it is JSON, otherwise as a JSON string,
+// so non-JSON upstreams can still be embedded
+func embed(body []byte) json.RawMessage {
+	if len(body) == 0 {
+		return nil
+	}
+	if json.Valid(body) {
+		return body
+	}
+	data, _ := json.Marshal(string(body))
+	return data
+}
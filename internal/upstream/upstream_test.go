@@ -0,0 +1,201 @@
+package upstream
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/PhilipSchmid/echo-app/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// echoServer is a minimal gRPC echo service reporting the received metadata
+type echoServer struct {
+	proto.UnimplementedEchoServiceServer
+}
+
+func (echoServer) Echo(ctx context.Context, _ *proto.EchoRequest) (*proto.EchoResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	return &proto.EchoResponse{Hostname: "grpc-upstream", RequestId: first(md.Get("x-request-id"))}, nil
+}
+
+func first(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		target        string
+		expectedError string
+	}{
+		{target: "http://svc:8080/chain"},
+		{target: "https://svc"},
+		{target: "grpc://svc:50051"},
+		{target: "grpcs://svc:50051"},
+		{target: "tcp://svc:9090"},
+		{target: "ftp://svc", expectedError: "scheme must be one of"},
+		{target: "svc:8080", expectedError: "scheme must be one of"},
+		{target: "http://", expectedError: "missing host"},
+		{target: "http://%zz", expectedError: "invalid upstream"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			_, err := Parse(tt.target)
+			if tt.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.expectedError)
+			}
+		})
+	}
+}
+
+func TestCall_HTTP(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Accept"))
+		assert.Equal(t, "abc", r.Header.Get("X-Request-ID"))
+		_, _ = w.Write([]byte(`{"hostname":"upstream"}`))
+	}))
+	defer srv.Close()
+
+	result := Call(context.Background(), srv.URL, Options{
+		Timeout: time.Second,
+		Headers: http.Header{"X-Request-Id": {"abc"}},
+	})
+	assert.True(t, result.OK(), result.Error)
+	assert.Equal(t, ProtocolHTTP, result.Protocol)
+	assert.Equal(t, http.StatusOK, result.Status)
+	assert.JSONEq(t, `{"hostname":"upstream"}`, string(result.Response))
+	assert.Greater(t, result.LatencyMs, 0.0)
+}
+
+func TestCall_HTTPErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/slow":
+			time.Sleep(200 * time.Millisecond)
+		case "/redirect":
+			http.Redirect(w, r, "/elsewhere", http.StatusFound)
+		default:
+			http.Error(w, "boom", http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	// Error statuses fail the call but keep non-JSON bodies as strings
+	result := Call(context.Background(), srv.URL+"/fail", Options{})
+	assert.Equal(t, "upstream returned 503 Service Unavailable", result.Error)
+	assert.Equal(t, http.StatusServiceUnavailable, result.Status)
+	assert.JSONEq(t, `"boom\n"`, string(result.Response))
+
+	// Redirects are reported rather than followed
+	result = Call(context.Background(), srv.URL+"/redirect", Options{})
+	assert.True(t, result.OK(), result.Error)
+	assert.Equal(t, http.StatusFound, result.Status)
+
+	result = Call(context.Background(), srv.URL+"/slow", Options{Timeout: 50 * time.Millisecond})
+	assert.Contains(t, result.Error, "context deadline exceeded")
+}
+
+func TestCall_HTTPSInsecureSkipVerify(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	result := Call(context.Background(), srv.URL, Options{Timeout: time.Second})
+	assert.Contains(t, result.Error, "certificate")
+
+	result = Call(context.Background(), srv.URL, Options{Timeout: time.Second, InsecureSkipVerify: true})
+	assert.True(t, result.OK(), result.Error)
+	assert.Equal(t, ProtocolHTTPS, result.Protocol)
+}
+
+func TestCall_GRPC(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := grpc.NewServer()
+	proto.RegisterEchoServiceServer(srv, echoServer{})
+	go func() { _ = srv.Serve(lis) }()
+	defer srv.Stop()
+
+	result := Call(context.Background(), "grpc://"+lis.Addr().String(), Options{
+		Timeout: time.Second,
+		Headers: http.Header{"X-Request-Id": {"abc"}},
+	})
+	assert.True(t, result.OK(), result.Error)
+	assert.Equal(t, ProtocolGRPC, result.Protocol)
+	assert.Equal(t, "OK", result.GRPCCode)
+
+	var response map[string]any
+	require.NoError(t, json.Unmarshal(result.Response, &response))
+	assert.Equal(t, "grpc-upstream", response["hostname"])
+	assert.Equal(t, "abc", response["request_id"])
+}
+
+func TestCall_GRPCUnavailable(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := lis.Addr().String()
+	require.NoError(t, lis.Close())
+
+	result := Call(context.Background(), "grpc://"+addr, Options{Timeout: time.Second})
+	assert.False(t, result.OK())
+	assert.Equal(t, "Unavailable", result.GRPCCode)
+}
+
+func TestCall_TCP(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() { _ = lis.Close() }()
+	go func() {
+		conn, err := lis.Accept()
+		if err != nil {
+			return
+		}
+		_, _ = conn.Write([]byte(`{"listener":"TCP"}`))
+		_ = conn.Close()
+	}()
+
+	result := Call(context.Background(), "tcp://"+lis.Addr().String(), Options{Timeout: time.Second})
+	assert.True(t, result.OK(), result.Error)
+	assert.Equal(t, ProtocolTCP, result.Protocol)
+	assert.JSONEq(t, `{"listener":"TCP"}`, string(result.Response))
+}
+
+func TestCall_InvalidTarget(t *testing.T) {
+	result := Call(context.Background(), "ftp://svc", Options{})
+	assert.False(t, result.OK())
+	assert.Empty(t, result.Protocol)
+	assert.Equal(t, "ftp://svc", result.Target)
+}
+
+func TestCallAll(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			time.Sleep(100 * time.Millisecond)
+		}
+		_, _ = w.Write([]byte(`"` + r.URL.Path + `"`))
+	}))
+	defer srv.Close()
+
+	// Calls run in parallel and results keep the order of targets
+	start := time.Now()
+	results := CallAll(context.Background(), []string{srv.URL + "/slow", srv.URL + "/slow", srv.URL + "/fast"}, Options{Timeout: time.Second})
+	assert.Less(t, time.Since(start), 250*time.Millisecond)
+	require.Len(t, results, 3)
+	assert.JSONEq(t, `"/slow"`, string(results[0].Response))
+	assert.JSONEq(t, `"/slow"`, string(results[1].Response))
+	assert.JSONEq(t, `"/fast"`, string(results[2].Response))
+}
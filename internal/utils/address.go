@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// UnixScheme prefixes listen addresses of Unix domain sockets
+const UnixScheme = "unix://"
+
+// EphemeralPort lets the operating system pick a free port to listen on
+const EphemeralPort = "0"
+
+// ParseAddress validates a listen address and returns the network and
+// address to pass to net.Listen: "unix" and the socket path for
+// unix:///path, or "tcp" and host:port. An empty host binds all interfaces,
+// IPv6 hosts must be bracketed, as in [::1]:8080, and port 0 picks a free
+// port.
+func ParseAddress(address string) (network, addr string, err error) {
+	if path, ok := strings.CutPrefix(address, UnixScheme); ok {
+		if !filepath.IsAbs(path) {
+			return "", "", fmt.Errorf("invalid address %q: unix socket path must be absolute", address)
+		}
+		return "unix", path, nil
+	}
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid address %q: must be host:port, [ipv6]:port or %s/path", address, UnixScheme)
+	}
+	if port != EphemeralPort {
+		if err := ValidatePort(port); err != nil {
+			return "", "", err
+		}
+	}
+	if host != "" && !isIP(host) && !isHostname(host) {
+		return "", "", fmt.Errorf("invalid address %q: invalid host %q", address, host)
+	}
+	return "tcp", address, nil
+}
+
+// FormatAddr formats a bound address as a listen address, prefixing Unix
+// socket paths with unix://
+func FormatAddr(addr net.Addr) string {
+	if addr == nil {
+		return ""
+	}
+	if addr.Network() == "unix" {
+		return UnixScheme + addr.String()
+	}
+	return addr.String()
+}
+
+// ValidatePort checks that port is a number between 1 and 65535
+func ValidatePort(port string) error {
+	p, err := strconv.Atoi(port)
+	if err != nil || p <= 0 || p > 65535 {
+		return fmt.Errorf("invalid port: %s", port)
+	}
+	return nil
+}
+
+// isIP reports whether host is an IP address, with an optional IPv6 zone
+func isIP(host string) bool {
+	_, err := netip.ParseAddr(host)
+	return err == nil
+}
+
+// isHostname reports whether host is a valid DNS name, such as localhost
+func isHostname(host string) bool {
+	host = strings.TrimSuffix(host, ".")
+	if host == "" || len(host) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(host, ".") {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, r := range label {
+			if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && (r < '0' || r > '9') && r != '-' {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,97 @@
+package utils
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAddress(t *testing.T) {
+	tests := []struct {
+		name            string
+		address         string
+		expectedNetwork string
+		expectedAddr    string
+		expectedError   string
+	}{
+		{name: "all interfaces", address: ":8080", expectedNetwork: "tcp", expectedAddr: ":8080"},
+		{name: "IPv4", address: "127.0.0.1:8080", expectedNetwork: "tcp", expectedAddr: "127.0.0.1:8080"},
+		{name: "IPv4 wildcard", address: "0.0.0.0:8080", expectedNetwork: "tcp", expectedAddr: "0.0.0.0:8080"},
+		{name: "IPv6 loopback", address: "[::1]:8080", expectedNetwork: "tcp", expectedAddr: "[::1]:8080"},
+		{name: "IPv6 wildcard", address: "[::]:8080", expectedNetwork: "tcp", expectedAddr: "[::]:8080"},
+		{name: "IPv6 zone", address: "[fe80::1%eth0]:8080", expectedNetwork: "tcp", expectedAddr: "[fe80::1%eth0]:8080"},
+		{name: "hostname", address: "localhost:8080", expectedNetwork: "tcp", expectedAddr: "localhost:8080"},
+		{name: "unix socket", address: "unix:///tmp/echo.sock", expectedNetwork: "unix", expectedAddr: "/tmp/echo.sock"},
+		{name: "relative unix socket", address: "unix://echo.sock", expectedError: `invalid address "unix://echo.sock": unix socket path must be absolute`},
+		{name: "missing port", address: "127.0.0.1", expectedError: `invalid address "127.0.0.1": must be host:port, [ipv6]:port or unix:///path`},
+		{name: "unbracketed IPv6", address: "::1:8080", expectedError: `invalid address "::1:8080": must be host:port, [ipv6]:port or unix:///path`},
+		{name: "port only", address: "8080", expectedError: `invalid address "8080": must be host:port, [ipv6]:port or unix:///path`},
+		{name: "port too high", address: ":65536", expectedError: "invalid port: 65536"},
+		{name: "ephemeral port", address: "127.0.0.1:0", expectedNetwork: "tcp", expectedAddr: "127.0.0.1:0"},
+		{name: "named port", address: ":http", expectedError: "invalid port: http"},
+		{name: "invalid host", address: "bad_host:8080", expectedError: `invalid address "bad_host:8080": invalid host "bad_host"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			network, addr, err := ParseAddress(tt.address)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedNetwork, network)
+			assert.Equal(t, tt.expectedAddr, addr)
+		})
+	}
+}
+
+func TestValidatePort(t *testing.T) {
+	tests := []struct {
+		name     string
+		port     string
+		expected bool
+	}{
+		{name: "valid port 80", port: "80", expected: true},
+		{name: "valid port 8080", port: "8080", expected: true},
+		{name: "valid port 65535", port: "65535", expected: true},
+		{name: "invalid port 0", port: "0", expected: false},
+		{name: "invalid port negative", port: "-1", expected: false},
+		{name: "invalid port too high", port: "65536", expected: false},
+		{name: "invalid port not a number", port: "abc", expected: false},
+		{name: "invalid empty port", port: "", expected: false},
+		{name: "invalid port with spaces", port: " 80 ", expected: false},
+		{name: "invalid port decimal", port: "80.5", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidatePort(tt.port)
+			if tt.expected {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, "invalid port: "+tt.port)
+			}
+		})
+	}
+}
+
+func TestFormatAddr(t *testing.T) {
+	tests := []struct {
+		name     string
+		addr     net.Addr
+		expected string
+	}{
+		{name: "nil", addr: nil, expected: ""},
+		{name: "TCP", addr: &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 8080}, expected: "127.0.0.1:8080"},
+		{name: "UDP IPv6", addr: &net.UDPAddr{IP: net.IPv6loopback, Port: 4433}, expected: "[::1]:4433"},
+		{name: "unix socket", addr: &net.UnixAddr{Name: "/run/echo.sock", Net: "unix"}, expected: "unix:///run/echo.sock"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, FormatAddr(tt.addr))
+		})
+	}
+}
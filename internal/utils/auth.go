@@ -0,0 +1,20 @@
+package utils
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// RequireBearerToken reports whether r carries token as bearer token in its
+// Authorization header, compared in constant time. Otherwise it answers 401
+// with a challenge for realm.
+func RequireBearerToken(w http.ResponseWriter, r *http.Request, token, realm string) bool {
+	bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if ok && subtle.ConstantTimeCompare([]byte(bearer), []byte(token)) == 1 {
+		return true
+	}
+	w.Header().Set("WWW-Authenticate", `Bearer realm="`+realm+`"`)
+	http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	return false
+}
@@ -0,0 +1,40 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireBearerToken(t *testing.T) {
+	tests := []struct {
+		name          string
+		authorization string
+		want          bool
+	}{
+		{name: "valid token", authorization: "Bearer secret", want: true},
+		{name: "missing header"},
+		{name: "wrong token", authorization: "Bearer wrong"},
+		{name: "token prefix", authorization: "Bearer secre"},
+		{name: "other scheme", authorization: "Basic secret"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.authorization != "" {
+				r.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			assert.Equal(t, tt.want, RequireBearerToken(w, r, "secret", "test"))
+			if tt.want {
+				assert.Equal(t, http.StatusOK, w.Code)
+				assert.Empty(t, w.Header().Get("WWW-Authenticate"))
+			} else {
+				assert.Equal(t, http.StatusUnauthorized, w.Code)
+				assert.Equal(t, `Bearer realm="test"`, w.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
@@ -66,6 +66,9 @@ type EchoResponse struct {
 	Node          string                 `protobuf:"bytes,5,opt,name=node,proto3" json:"node,omitempty"`
 	SourceIp      string                 `protobuf:"bytes,6,opt,name=source_ip,json=sourceIp,proto3" json:"source_ip,omitempty"`
 	GrpcMethod    string                 `protobuf:"bytes,7,opt,name=grpc_method,json=grpcMethod,proto3" json:"grpc_method,omitempty"`
+	RequestId     string                 `protobuf:"bytes,8,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	Trace         *TraceInfo             `protobuf:"bytes,9,opt,name=trace,proto3" json:"trace,omitempty"`
+	LocalAddress  string                 `protobuf:"bytes,10,opt,name=local_address,json=localAddress,proto3" json:"local_address,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -149,12 +152,109 @@ func (x *EchoResponse) GetGrpcMethod() string {
 	return ""
 }
 
+func (x *EchoResponse) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *EchoResponse) GetTrace() *TraceInfo {
+	if x != nil {
+		return x.Trace
+	}
+	return nil
+}
+
+func (x *EchoResponse) GetLocalAddress() string {
+	if x != nil {
+		return x.LocalAddress
+	}
+	return ""
+}
+
+type TraceInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TraceId       string                 `protobuf:"bytes,1,opt,name=trace_id,json=traceId,proto3" json:"trace_id,omitempty"`
+	SpanId        string                 `protobuf:"bytes,2,opt,name=span_id,json=spanId,proto3" json:"span_id,omitempty"`
+	ParentSpanId  string                 `protobuf:"bytes,3,opt,name=parent_span_id,json=parentSpanId,proto3" json:"parent_span_id,omitempty"`
+	Sampled       bool                   `protobuf:"varint,4,opt,name=sampled,proto3" json:"sampled,omitempty"`
+	Baggage       map[string]string      `protobuf:"bytes,5,rep,name=baggage,proto3" json:"baggage,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TraceInfo) Reset() {
+	*x = TraceInfo{}
+	mi := &file_proto_echo_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TraceInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TraceInfo) ProtoMessage() {}
+
+func (x *TraceInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_echo_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TraceInfo.ProtoReflect.Descriptor instead.
+func (*TraceInfo) Descriptor() ([]byte, []int) {
+	return file_proto_echo_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *TraceInfo) GetTraceId() string {
+	if x != nil {
+		return x.TraceId
+	}
+	return ""
+}
+
+func (x *TraceInfo) GetSpanId() string {
+	if x != nil {
+		return x.SpanId
+	}
+	return ""
+}
+
+func (x *TraceInfo) GetParentSpanId() string {
+	if x != nil {
+		return x.ParentSpanId
+	}
+	return ""
+}
+
+func (x *TraceInfo) GetSampled() bool {
+	if x != nil {
+		return x.Sampled
+	}
+	return false
+}
+
+func (x *TraceInfo) GetBaggage() map[string]string {
+	if x != nil {
+		return x.Baggage
+	}
+	return nil
+}
+
 var File_proto_echo_proto protoreflect.FileDescriptor
 
 const file_proto_echo_proto_rawDesc = "" +
 	"\n" +
 	"\x10proto/echo.proto\x12\x04echo\"\r\n" +
-	"\vEchoRequest\"\xd0\x01\n" +
+	"\vEchoRequest\"\xbb\x02\n" +
 	"\fEchoResponse\x12\x1c\n" +
 	"\ttimestamp\x18\x01 \x01(\tR\ttimestamp\x12\x18\n" +
 	"\amessage\x18\x02 \x01(\tR\amessage\x12\x1a\n" +
@@ -163,7 +263,21 @@ const file_proto_echo_proto_rawDesc = "" +
 	"\x04node\x18\x05 \x01(\tR\x04node\x12\x1b\n" +
 	"\tsource_ip\x18\x06 \x01(\tR\bsourceIp\x12\x1f\n" +
 	"\vgrpc_method\x18\a \x01(\tR\n" +
-	"grpcMethod2>\n" +
+	"grpcMethod\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\b \x01(\tR\trequestId\x12%\n" +
+	"\x05trace\x18\t \x01(\v2\x0f.echo.TraceInfoR\x05trace\x12#\n" +
+	"\rlocal_address\x18\n" +
+	" \x01(\tR\flocalAddress\"\xf3\x01\n" +
+	"\tTraceInfo\x12\x19\n" +
+	"\btrace_id\x18\x01 \x01(\tR\atraceId\x12\x17\n" +
+	"\aspan_id\x18\x02 \x01(\tR\x06spanId\x12$\n" +
+	"\x0eparent_span_id\x18\x03 \x01(\tR\fparentSpanId\x12\x18\n" +
+	"\asampled\x18\x04 \x01(\bR\asampled\x126\n" +
+	"\abaggage\x18\x05 \x03(\v2\x1c.echo.TraceInfo.BaggageEntryR\abaggage\x1a:\n" +
+	"\fBaggageEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x012>\n" +
 	"\vEchoService\x12/\n" +
 	"\x04Echo\x12\x11.echo.EchoRequest\x1a\x12.echo.EchoResponse\"\x00B(Z&github.com/PhilipSchmid/echo-app/protob\x06proto3"
 
@@ -179,19 +293,23 @@ func file_proto_echo_proto_rawDescGZIP() []byte {
 	return file_proto_echo_proto_rawDescData
 }
 
-var file_proto_echo_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_proto_echo_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
 var file_proto_echo_proto_goTypes = []any{
 	(*EchoRequest)(nil),  // 0: echo.EchoRequest
 	(*EchoResponse)(nil), // 1: echo.EchoResponse
+	(*TraceInfo)(nil),    // 2: echo.TraceInfo
+	nil,                  // 3: echo.TraceInfo.BaggageEntry
 }
 var file_proto_echo_proto_depIdxs = []int32{
-	0, // 0: echo.EchoService.Echo:input_type -> echo.EchoRequest
-	1, // 1: echo.EchoService.Echo:output_type -> echo.EchoResponse
-	1, // [1:2] is the sub-list for method output_type
-	0, // [0:1] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	2, // 0: echo.EchoResponse.trace:type_name -> echo.TraceInfo
+	3, // 1: echo.TraceInfo.baggage:type_name -> echo.TraceInfo.BaggageEntry
+	0, // 2: echo.EchoService.Echo:input_type -> echo.EchoRequest
+	1, // 3: echo.EchoService.Echo:output_type -> echo.EchoResponse
+	3, // [3:4] is the sub-list for method output_type
+	2, // [2:3] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
 }
 
 func init() { file_proto_echo_proto_init() }
@@ -205,7 +323,7 @@ func file_proto_echo_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_echo_proto_rawDesc), len(file_proto_echo_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   2,
+			NumMessages:   4,
 			NumExtensions: 0,
 			NumServices:   1,
 		},